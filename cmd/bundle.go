@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	api "github.com/jauhararifin/cptool/pkg/cptool"
+	"github.com/spf13/cobra"
+)
+
+func initBundleCommand() *cobra.Command {
+	var stripLocal bool
+	var libraryPaths []string
+
+	cmd := &cobra.Command{
+		Use:   "bundle [LANGUAGE] SOLUTION",
+		Short: "Bundle local includes of competitive programming solution into a single file",
+		Long: "Bundle local includes of competitive programming solution into a single file. Every quoted include is searched\n" +
+			"relative to the including file, then in library paths given using --include option, and then in library_paths\n" +
+			"defined in config file. The bundled solution is saved in .cptool/bundles directory.",
+		Args:    cobra.RangeArgs(1, 2),
+		Version: GetVersion(),
+		Run: func(cmd *cobra.Command, args []string) {
+			client, logger := newClient(cmd)
+			solution := findSolution(client, logger, args)
+			bundle, err := client.Bundle(solution, api.BundleOptions{StripLocal: stripLocal, LibraryPaths: libraryPaths})
+			if err != nil {
+				logger.PrintError(err)
+				os.Exit(1)
+			}
+			fmt.Printf("Bundled solution : %s\n", bundle.Path)
+		},
+	}
+
+	cmd.Flags().BoolVar(&stripLocal, "strip-local", false, "remove #ifdef LOCAL blocks from bundled solution")
+	cmd.Flags().StringSliceVarP(&libraryPaths, "include", "I", nil, "add directory to library paths")
+
+	return cmd
+}
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/jauhararifin/cptool/internal/core"
+	"github.com/jauhararifin/cptool/internal/logger"
+)
+
+// parseSolution returns the solution name and its language from "[language] solution" arguments.
+func parseSolution(cptool *core.CPTool, logger *logger.Logger, args []string) (string, core.Language) {
+	if len(args) > 1 {
+		solutionName := args[1]
+		language, err := cptool.GetLanguageByName(args[0])
+		if err != nil {
+			logger.PrintError("cannot determine language")
+			os.Exit(1)
+		}
+		return solutionName, language
+	}
+	solutionName := args[0]
+	return solutionName, detectLanguage(cptool, logger, solutionName)
+}
+
+// detectLanguage returns the language of a solution based on its file extension. The default language is used when there is
+// no solution file yet.
+func detectLanguage(cptool *core.CPTool, logger *logger.Logger, solutionName string) core.Language {
+	solution, err := cptool.FindSolution(solutionName)
+	if err == nil {
+		return solution.Language
+	}
+	if err != core.ErrNoSuchSolution {
+		logger.PrintError(err)
+		os.Exit(1)
+	}
+	language, err := cptool.GetDefaultLanguage()
+	if err != nil {
+		logger.PrintError("cannot determine language")
+		os.Exit(1)
+	}
+	return language
+}
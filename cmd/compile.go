@@ -2,63 +2,111 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
+	"time"
 
-	"github.com/jauhararifin/cptool/internal/core"
-	"github.com/jauhararifin/cptool/internal/logger"
+	api "github.com/jauhararifin/cptool/pkg/cptool"
 	"github.com/spf13/cobra"
 )
 
-func parseSolution(cptool *core.CPTool, logger *logger.Logger, args []string) (string, core.Language) {
-	if len(args) > 1 {
-		solutionName := args[1]
-		language, err := cptool.GetLanguageByName(args[0])
-		if err != nil {
-			logger.PrintError("cannot determine language")
-			os.Exit(1)
-		}
-		return solutionName, language
-	}
-	solutionName := args[0]
-	language, err := cptool.GetDefaultLanguage()
-	if err != nil {
-		logger.PrintError("cannot determine language")
-		os.Exit(1)
-	}
-	return solutionName, language
-}
-
 func initCompileCommand() *cobra.Command {
 	var debug bool
+	var bundle bool
+	var stripLocal bool
+	var libraryPaths []string
+	var jsonOutput bool
 
 	cmd := &cobra.Command{
-		Use:     "compile [LANGUAGE] SOLUTION",
-		Short:   "Compile competitive programming solution",
+		Use:   "compile [LANGUAGE] SOLUTION",
+		Short: "Compile competitive programming solution",
+		Long: "Compile competitive programming solution. The compiler's warnings are printed when the compilation succeeded. Use\n" +
+			"--json option to print the result and the compiler's errors and warnings as JSON, for editors and other tools.",
 		Version: GetVersion(),
 		Args:    cobra.RangeArgs(1, 2),
 		Run: func(cmd *cobra.Command, args []string) {
-			cptool, log := newDefaultCptool(cmd)
-			solutionName, language := parseSolution(cptool, log, args)
-			log.PrintInfo("Compiling solution: ", solutionName)
-			result, err := cptool.CompileByName(context.Background(), language.Name, solutionName, debug)
-			if err != nil {
-				log.PrintError(err)
-				if len(result.ErrorMessage) > 0 {
-					log.Println(logger.ERROR, "")
-					log.Println(logger.ERROR, result.ErrorMessage)
+			client, log := newClient(cmd)
+			solution := findSolution(client, log, args)
+			if !jsonOutput {
+				log.PrintInfo("Compiling solution: ", solution.Name)
+			}
+			start := time.Now()
+			if bundle {
+				var err error
+				solution, err = client.Bundle(solution, api.BundleOptions{StripLocal: stripLocal, LibraryPaths: libraryPaths})
+				if err != nil {
+					log.PrintError(err)
+					os.Exit(1)
+				}
+				if !jsonOutput {
+					log.PrintInfo("Compiling bundled solution: ", solution.Path)
 				}
+			}
+			if jsonOutput {
+				printCompileJSON(client, solution, debug)
+				return
+			}
+			result, err := client.Compile(context.Background(), solution, debug)
+			if err != nil {
+				printError(log, err)
 				os.Exit(1)
 			}
 			if result.Skipped {
 				log.PrintWarning("Compilation skipped because solution already compiled")
 			}
+			printCompilerWarnings(log, result)
 			fmt.Printf("Compiled program : %s\n", result.TargetPath)
-			fmt.Printf("Done in %.2f seconds\n", result.Duration.Seconds())
+			fmt.Printf("Done in %.2f seconds\n", time.Since(start).Seconds())
 		},
 	}
 
 	cmd.Flags().BoolVarP(&debug, "debug", "d", false, "compile your solution as debug mode")
+	cmd.Flags().BoolVarP(&bundle, "bundle", "b", false, "bundle your solution's local includes before compiling it")
+	cmd.Flags().BoolVar(&stripLocal, "strip-local", false, "remove #ifdef LOCAL blocks when bundling your solution")
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "print the result and the compiler's diagnostics as JSON")
+	cmd.Flags().StringSliceVarP(&libraryPaths, "include", "I", nil, "add directory to library paths when bundling your solution")
 
 	return cmd
 }
+
+// compileJSON is the result of compiling a solution printed by --json option. Error is set when the compilation failed.
+type compileJSON struct {
+	Solution    string           `json:"solution"`
+	Skipped     bool             `json:"skipped"`
+	TargetPath  string           `json:"target_path,omitempty"`
+	Duration    float64          `json:"duration"`
+	Error       string           `json:"error,omitempty"`
+	Output      string           `json:"output"`
+	Diagnostics []api.Diagnostic `json:"diagnostics"`
+}
+
+// printCompileJSON compiles the solution and prints the result as JSON. The process exits with 1 when the compilation failed.
+func printCompileJSON(client *api.CPTool, solution api.Solution, debug bool) {
+	result, err := client.Compile(context.Background(), solution, debug)
+	output := compileJSON{
+		Solution:    solution.Path,
+		Skipped:     result.Skipped,
+		TargetPath:  result.TargetPath,
+		Duration:    result.Duration.Seconds(),
+		Output:      result.Output,
+		Diagnostics: result.Diagnostics,
+	}
+	if compilationErr, ok := err.(*api.CompilationError); ok {
+		output.Output = compilationErr.Output
+		output.Diagnostics = compilationErr.Diagnostics
+	}
+	if err != nil {
+		output.Error = err.Error()
+	}
+	if output.Diagnostics == nil {
+		output.Diagnostics = make([]api.Diagnostic, 0)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(output)
+	if err != nil {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/jauhararifin/cptool/internal/config"
+	"github.com/spf13/cobra"
+)
+
+func initConfigCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Manage cptool configuration",
+		Long: "Manage cptool configuration. The configuration is merged from .cptool/config in the project root (project),\n" +
+			"$CPTOOL_HOME/config (cptool_home), ~/.cptool/config (user) and /etc/cptool/config (system), in that order\n" +
+			"of priority. The project root is the nearest directory containing .cptool directory, searched from current working\n" +
+			"directory up to its parents. Every setting can also be overridden using environment variable, like\n" +
+			"CPTOOL_DEFAULT_LANGUAGE for default_language. Known settings: " + strings.Join(config.Keys(), ", ") + ".",
+		Version: GetVersion(),
+	}
+	cmd.AddCommand(initConfigGetCommand())
+	cmd.AddCommand(initConfigSetCommand())
+	cmd.AddCommand(initConfigListCommand())
+	cmd.AddCommand(initConfigValidateCommand())
+	return cmd
+}
+
+func initConfigGetCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:     "get KEY",
+		Short:   "Print the value of a setting and where it comes from",
+		Args:    cobra.ExactArgs(1),
+		Version: GetVersion(),
+		Run: func(cmd *cobra.Command, args []string) {
+			cptool, logger := newDefaultCptool(cmd)
+			value, err := cptool.GetConfig().Get(args[0])
+			if err != nil {
+				logger.PrintError(err, ": ", args[0])
+				os.Exit(1)
+			}
+			fmt.Printf("%s (from %s)\n", value.Value, value.Source)
+		},
+	}
+}
+
+func initConfigSetCommand() *cobra.Command {
+	var layer string
+
+	cmd := &cobra.Command{
+		Use:   "set KEY VALUE",
+		Short: "Set a setting in a configuration file",
+		Long: "Set a setting in a configuration file. The setting is written to the user configuration file by default, use\n" +
+			"--layer option to write to other configuration file. List values are separated by the OS path list separator.",
+		Args:    cobra.ExactArgs(2),
+		Version: GetVersion(),
+		Run: func(cmd *cobra.Command, args []string) {
+			cptool, logger := newCptoolWithoutBootstrap(cmd)
+			if err := cptool.SetConfigValue(layer, args[0], args[1]); err != nil {
+				logger.PrintError(err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&layer, "layer", "l", "user", "configuration layer to write: project, cptool_home, user or system")
+
+	return cmd
+}
+
+func initConfigListCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:     "list",
+		Short:   "List every setting with its value and where it comes from",
+		Args:    cobra.NoArgs,
+		Version: GetVersion(),
+		Run: func(cmd *cobra.Command, args []string) {
+			cptool, _ := newDefaultCptool(cmd)
+			for _, value := range cptool.GetConfig().Values() {
+				fmt.Printf("%s = %q (from %s)\n", value.Key, value.Value, value.Source)
+			}
+		},
+	}
+}
+
+func initConfigValidateCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:     "validate",
+		Short:   "Check every configuration file for errors",
+		Args:    cobra.NoArgs,
+		Version: GetVersion(),
+		Run: func(cmd *cobra.Command, args []string) {
+			cptool, logger := newCptoolWithoutBootstrap(cmd)
+			errs := cptool.ValidateConfig()
+			paths := make([]string, 0, len(errs))
+			for path := range errs {
+				paths = append(paths, path)
+			}
+			sort.Strings(paths)
+			for _, path := range paths {
+				logger.PrintError(errs[path])
+			}
+			if len(errs) > 0 {
+				os.Exit(1)
+			}
+			logger.PrintSuccess("Configuration files are valid")
+		},
+	}
+}
@@ -2,13 +2,31 @@ package cmd
 
 import (
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/jauhararifin/cptool/internal/core"
 	"github.com/jauhararifin/cptool/internal/logger"
+	api "github.com/jauhararifin/cptool/pkg/cptool"
 	"github.com/spf13/cobra"
 )
 
 func newDefaultCptool(cmd *cobra.Command) (*core.CPTool, *logger.Logger) {
+	cptool, cptoolLogger := newCptoolWithoutBootstrap(cmd)
+
+	err := cptool.Bootstrap()
+	if err != nil {
+		cptoolLogger.PrintError(err)
+		os.Exit(-1)
+	}
+	cptoolLogger.Colored = cptool.GetConfig().Colors
+
+	return cptool, cptoolLogger
+}
+
+// newCptoolWithoutBootstrap creates cptool without loading configuration files and languages, used by commands that need to
+// work even when the configuration files are malformed.
+func newCptoolWithoutBootstrap(cmd *cobra.Command) (*core.CPTool, *logger.Logger) {
 	loggingLevel := logger.INFO
 	if cmd != nil {
 		if val, _ := cmd.Flags().GetBool("verbose"); val {
@@ -17,17 +35,94 @@ func newDefaultCptool(cmd *cobra.Command) (*core.CPTool, *logger.Logger) {
 	}
 	cptoolLogger := logger.New(os.Stderr, loggingLevel)
 
-	cptool, err := core.New(nil, cptoolLogger)
+	cptool, err := core.New(core.WithLogger(cptoolLogger))
 	if err != nil {
 		cptoolLogger.PrintError(err)
 		os.Exit(-1)
 	}
 
-	err = cptool.Bootstrap()
+	return cptool, cptoolLogger
+}
+
+// newClient creates cptool using the public API, used by commands that only compile, run and test solutions. The options are
+// applied after the default ones, so they can override where the logs are written.
+func newClient(cmd *cobra.Command, options ...api.Option) (*api.CPTool, *logger.Logger) {
+	verbose := false
+	if cmd != nil {
+		verbose, _ = cmd.Flags().GetBool("verbose")
+	}
+	loggingLevel := logger.INFO
+	if verbose {
+		loggingLevel = logger.VERBOSE
+	}
+	cptoolLogger := logger.New(os.Stderr, loggingLevel)
+
+	client, err := api.New(append([]api.Option{api.WithLogOutput(os.Stderr, verbose)}, options...)...)
 	if err != nil {
 		cptoolLogger.PrintError(err)
 		os.Exit(-1)
 	}
+	cptoolLogger.Colored = client.Config().Colors
 
-	return cptool, cptoolLogger
+	return client, cptoolLogger
+}
+
+// findSolution finds the solution named by the command's arguments, "[LANGUAGE] SOLUTION". The language is detected from the
+// solution's extension when it is not specified.
+func findSolution(client *api.CPTool, logger *logger.Logger, args []string) api.Solution {
+	languageName, solutionName := "", args[0]
+	if len(args) > 1 {
+		languageName, solutionName = args[0], args[1]
+	}
+	solution, err := client.Solution(solutionName, languageName)
+	if err != nil {
+		printError(logger, err)
+		os.Exit(1)
+	}
+	return solution
+}
+
+// printError prints an error of the public API, the compiler's message is printed for compilation error.
+func printError(log *logger.Logger, err error) {
+	log.PrintError(err)
+	if compilationErr, ok := err.(*api.CompilationError); ok && len(compilationErr.Output) > 0 {
+		log.Println(logger.ERROR, "")
+		log.Println(logger.ERROR, compilationErr.Output)
+	}
+}
+
+// printCompilerWarnings prints the warnings of a successful compilation, followed by every parsed diagnostic including the notes,
+// all at WARN level since the notes usually explain the preceding warning. When the compiler's format is not recognized, its raw
+// output is printed at VERBOSE level instead, since compilers like fpc print informational messages on every compilation.
+func printCompilerWarnings(log *logger.Logger, result api.CompileResult) {
+	if len(result.Diagnostics) == 0 {
+		if output := strings.TrimSpace(result.Output); len(output) > 0 {
+			log.Println(logger.VERBOSE, "Compiler output:\n", output)
+		}
+		return
+	}
+	warnings := 0
+	for _, diagnostic := range result.Diagnostics {
+		if diagnostic.Severity == api.SeverityWarning {
+			warnings++
+		}
+	}
+	if warnings == 1 {
+		log.PrintWarning("Compiled with 1 warning")
+	} else if warnings > 1 {
+		log.PrintWarning("Compiled with ", warnings, " warnings")
+	}
+	for _, diagnostic := range result.Diagnostics {
+		log.Println(logger.WARN, diagnostic)
+	}
+}
+
+func absolutePath(programPath string) string {
+	if len(programPath) == 0 {
+		return programPath
+	}
+	if abs, err := filepath.Abs(programPath); err == nil {
+		return abs
+	}
+	return programPath
 }
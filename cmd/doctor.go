@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jauhararifin/cptool/internal/core"
+	"github.com/jauhararifin/cptool/internal/logger"
+	"github.com/spf13/cobra"
+)
+
+// recommendedStackLimit is the smallest stack size limit that is not reported, deep recursions in competitive programming
+// usually need more than the common 8MB default.
+const recommendedStackLimit = 256 * 1024 * 1024
+
+// probeTimeout limits the duration of checking a language, some compilers like kotlinc take several seconds to start.
+const probeTimeout = time.Minute
+
+func initDoctorCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "doctor",
+		Short: "Check whether the toolchain of every language is installed and works",
+		Long: "Check whether the toolchain of every language is installed and works. Every language is checked for missing\n" +
+			"executables, non executable scripts and definitions shadowed by other configuration paths, then probed by compiling\n" +
+			"and running a tiny program. The stack size limit inherited by your solutions is checked too.",
+		Args:    cobra.NoArgs,
+		Version: GetVersion(),
+		Run: func(cmd *cobra.Command, args []string) {
+			cptool, logger := newDefaultCptool(cmd)
+			languages, _ := cptool.GetAllLanguages()
+			ok := checkLanguages(cptool, logger, languages)
+
+			if limit, limited, err := core.GetStackLimit(); err != nil {
+				logger.PrintWarning("Stack size limit is unknown: ", err)
+			} else if limited && limit < recommendedStackLimit {
+				logger.PrintWarning(fmt.Sprintf("Stack size is limited to %d KB, solutions with deep recursion may crash. ", limit/1024),
+					"Use `ulimit -s unlimited` to remove the limit")
+			} else if limited {
+				logger.PrintSuccess(fmt.Sprintf("Stack size is limited to %d KB", limit/1024))
+			} else {
+				logger.PrintSuccess("Stack size is not limited")
+			}
+
+			if !ok {
+				os.Exit(1)
+			}
+		},
+	}
+}
+
+// checkLanguages checks and prints the result of every language, it returns false when some language has problems.
+func checkLanguages(cptool *core.CPTool, log *logger.Logger, languages []core.Language) bool {
+	ok := true
+	for _, language := range languages {
+		ctx, cancel := context.WithTimeout(context.Background(), probeTimeout)
+		check := cptool.CheckLanguage(ctx, language)
+		cancel()
+		if check.OK() {
+			log.PrintSuccess("[ ", language.Name, " ] OK")
+		} else {
+			log.PrintError("[ ", language.Name, " ] has problems")
+			ok = false
+		}
+		if len(check.Version) > 0 {
+			fmt.Printf("  version:        %s\n", check.Version)
+		}
+		for _, executable := range check.MissingExecutables {
+			fmt.Printf("  missing:        %s is not found in PATH\n", executable)
+		}
+		for _, script := range check.NonExecutableScripts {
+			fmt.Printf("  script:         %s is not executable, use `chmod +x %s`\n", script, script)
+		}
+		if len(check.ShadowedPaths) > 0 {
+			fmt.Printf("  shadows:        %s\n", strings.Join(check.ShadowedPaths, ", "))
+		}
+		switch {
+		case !check.Probed:
+			fmt.Printf("  probe:          skipped\n")
+		case check.ProbeErr != nil:
+			fmt.Printf("  probe:          %v\n", check.ProbeErr)
+			for _, line := range strings.Split(strings.TrimSpace(check.ProbeMessage), "\n") {
+				fmt.Printf("    %s\n", line)
+			}
+		default:
+			fmt.Printf("  probe:          hello world compiled and ran successfully\n")
+		}
+		fmt.Println()
+	}
+	return ok
+}
@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/jauhararifin/cptool/internal/judge"
+	"github.com/jauhararifin/cptool/internal/logger"
+	api "github.com/jauhararifin/cptool/pkg/cptool"
+	"github.com/spf13/cobra"
+)
+
+func initJudgeCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "judge",
+		Short:   "Host a local judge for practice sessions",
+		Version: GetVersion(),
+	}
+	cmd.AddCommand(initJudgeServeCommand())
+	return cmd
+}
+
+func initJudgeServeCommand() *cobra.Command {
+	var problems string
+	var address string
+	var data string
+	var workers int
+	var timeout time.Duration
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve a judge that accepts submissions and shows a scoreboard",
+		Long: "Serve a small HTTP judge for practice sessions. Every directory inside the problems directory is a problem, its\n" +
+			"test cases and problem.toml are used like in \"cptool test\". Submissions are queued and judged by the workers, and\n" +
+			"the verdicts are shown in the submission's page and an ICPC style scoreboard. Submissions run on this machine\n" +
+			"without a sandbox, so only let people you trust to submit. The submissions' source code is saved in the data\n" +
+			"directory, but the verdicts and the scoreboard are kept in memory. The server only listens on 127.0.0.1 by default,\n" +
+			"use \"--address :10045\" to let other machines submit.",
+		Args:    cobra.NoArgs,
+		Version: GetVersion(),
+		Run: func(cmd *cobra.Command, args []string) {
+			verbose, _ := cmd.Flags().GetBool("verbose")
+			loggingLevel := logger.INFO
+			if verbose {
+				loggingLevel = logger.VERBOSE
+			}
+			judgeLogger := logger.New(os.Stderr, loggingLevel)
+			if workers < 1 {
+				judgeLogger.PrintError("--workers must be at least 1")
+				os.Exit(1)
+			}
+			if timeout <= 0 {
+				judgeLogger.PrintError("--timeout must be positive")
+				os.Exit(1)
+			}
+
+			opts := []judge.Option{
+				judge.WithWorkers(workers),
+				judge.WithTimeout(timeout),
+				// the submissions' stderr would be mixed with the server's logs, so it is discarded.
+				judge.WithClientOptions(api.WithLogOutput(os.Stderr, verbose), api.WithStderr(ioutil.Discard)),
+			}
+			if data != "" {
+				opts = append(opts, judge.WithDataDirectory(data))
+			}
+			j, err := judge.New(problems, opts...)
+			if err != nil {
+				judgeLogger.PrintError(err)
+				os.Exit(1)
+			}
+			j.Start(context.Background())
+
+			judgeLogger.PrintInfo("Judging ", len(j.Problems()), " problems, listening on ", address)
+			var handlerOpts []judge.HandlerOption
+			if judge.IsLoopbackAddress(address) {
+				handlerOpts = append(handlerOpts, judge.WithLoopbackOnly())
+			}
+			if err := http.ListenAndServe(address, judge.NewHandler(j, handlerOpts...)); err != nil {
+				judgeLogger.PrintError(err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&problems, "problems", "", "directory containing a directory for every problem")
+	cmd.Flags().StringVar(&address, "address", "127.0.0.1:10045", "address to listen on")
+	cmd.Flags().StringVar(&data, "data", "", "directory to save the submissions, default to .judge inside the problems directory")
+	cmd.Flags().IntVar(&workers, "workers", 1, "number of submissions judged in parallel")
+	cmd.Flags().DurationVar(&timeout, "timeout", time.Minute, "time limit of judging a submission")
+	cmd.MarkFlagRequired("problems")
+
+	return cmd
+}
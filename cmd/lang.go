@@ -2,18 +2,26 @@ package cmd
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
 
+	"github.com/jauhararifin/cptool/internal/core"
 	"github.com/spf13/cobra"
 )
 
 func initLangCommand() *cobra.Command {
-	return &cobra.Command{
-		Use:     "lang",
-		Short:   "List all available languages",
+	cmd := &cobra.Command{
+		Use:   "lang",
+		Short: "List all available languages",
+		Long: "List all available languages. The stock languages are built into cptool and have the lowest priority, the\n" +
+			"languages defined in langs directory of configuration paths override them.",
 		Version: GetVersion(),
 		Run: func(cmd *cobra.Command, args []string) {
 			cptool, _ := newDefaultCptool(cmd)
-			languages, _ := cptool.GetAllLanguages()
+			languages, languagesByName := cptool.GetAllLanguages()
 			defaultLanguage, _ := cptool.GetDefaultLanguage()
 
 			if len(languages) == 0 {
@@ -21,20 +29,290 @@ func initLangCommand() *cobra.Command {
 			}
 
 			for _, lang := range languages {
-				if defaultLanguage.Name == lang.Name {
-					fmt.Printf("[ %s ] (default language)\n", lang.Name)
-				} else {
-					fmt.Printf("[ %s ]\n", lang.Name)
+				printLanguage(lang, defaultLanguage.Name == lang.Name)
+				printLanguageSources(cptool.GetLanguageSourcesByName(lang.Name))
+				fmt.Println()
+			}
+
+			// the rejected definitions of loaded languages are already printed with the language.
+			rejected := make([]core.LanguageSource, 0)
+			for _, source := range cptool.GetLanguageSources() {
+				if _, loaded := languagesByName[source.Name]; source.Err != nil && !loaded {
+					rejected = append(rejected, source)
 				}
-				fmt.Printf("  language name:  %s\n", lang.VerboseName)
-				fmt.Printf("  file extension: %s\n", lang.Extension)
-				fmt.Printf("  compile script: %s\n", lang.CompileScript)
-				fmt.Printf("  run script:     %s\n", lang.RunScript)
-				if lang.Debuggable {
-					fmt.Printf("  debug script:   %s\n", lang.DebugScript)
+			}
+			if len(rejected) > 0 {
+				fmt.Println("Rejected language definitions:")
+				for _, source := range rejected {
+					fmt.Printf("  %s: %v\n", source.Name, source.Err)
 				}
-				fmt.Println()
 			}
 		},
 	}
+	cmd.AddCommand(initLangExportCommand())
+	cmd.AddCommand(initLangCheckCommand())
+	cmd.AddCommand(initLangAddCommand())
+	cmd.AddCommand(initLangRemoveCommand())
+	cmd.AddCommand(initLangShowCommand())
+	cmd.AddCommand(initLangEditCommand())
+	return cmd
+}
+
+func initLangAddCommand() *cobra.Command {
+	var layer string
+	var from string
+
+	cmd := &cobra.Command{
+		Use:   "add NAME",
+		Short: "Create a new language",
+		Long: "Create a new language in langs directory of a configuration layer. The language is created with template compile\n" +
+			"and run scripts that you should edit, or copied from an existing language using --from option.",
+		Args:    cobra.ExactArgs(1),
+		Version: GetVersion(),
+		Run: func(cmd *cobra.Command, args []string) {
+			cptool, logger := newDefaultCptool(cmd)
+			language, err := cptool.AddLanguage(layer, args[0], from)
+			if err != nil {
+				logger.PrintError(err, ": ", args[0])
+				os.Exit(1)
+			}
+			languagePath, _ := cptool.GetLanguagesPath(layer)
+			logger.PrintSuccess("Language created in: ", path.Join(languagePath, language.Name))
+		},
+	}
+
+	cmd.Flags().StringVarP(&layer, "layer", "l", "user", "configuration layer to write: project, cptool_home, user or system")
+	cmd.Flags().StringVarP(&from, "from", "f", "", "copy the definition of an existing language")
+
+	return cmd
+}
+
+func initLangRemoveCommand() *cobra.Command {
+	var layer string
+
+	cmd := &cobra.Command{
+		Use:     "remove NAME",
+		Short:   "Remove a language from a configuration layer",
+		Args:    cobra.ExactArgs(1),
+		Version: GetVersion(),
+		Run: func(cmd *cobra.Command, args []string) {
+			cptool, logger := newDefaultCptool(cmd)
+			languagePath, err := cptool.RemoveLanguage(layer, args[0])
+			if err != nil {
+				logger.PrintError(err, ": ", args[0])
+				os.Exit(1)
+			}
+			logger.PrintSuccess("Language removed from: ", languagePath)
+		},
+	}
+
+	cmd.Flags().StringVarP(&layer, "layer", "l", "user", "configuration layer to remove from: project, cptool_home, user or system")
+
+	return cmd
+}
+
+func initLangShowCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:     "show NAME",
+		Short:   "Show the definition of a language",
+		Args:    cobra.ExactArgs(1),
+		Version: GetVersion(),
+		Run: func(cmd *cobra.Command, args []string) {
+			cptool, logger := newDefaultCptool(cmd)
+			language, err := cptool.GetLanguageByName(args[0])
+			if err != nil {
+				logger.PrintError(err, ": ", args[0])
+				os.Exit(1)
+			}
+			defaultLanguage, _ := cptool.GetDefaultLanguage()
+			printLanguage(language, defaultLanguage.Name == language.Name)
+			printLanguageSources(cptool.GetLanguageSourcesByName(language.Name))
+
+			directory, err := cptool.GetLanguageDirectory(language.Name)
+			if err == core.ErrBuiltinLanguage {
+				fmt.Printf("  directory:      built into cptool\n")
+				return
+			}
+			fmt.Printf("  directory:      %s\n", directory)
+			if content, err := ioutil.ReadFile(path.Join(directory, "lang.conf")); err == nil {
+				fmt.Printf("\n%s\n", strings.TrimSpace(string(content)))
+			}
+		},
+	}
+}
+
+func initLangEditCommand() *cobra.Command {
+	var layer string
+
+	cmd := &cobra.Command{
+		Use:   "edit NAME",
+		Short: "Edit lang.conf of a language using $EDITOR",
+		Long: "Edit lang.conf of a language using $EDITOR. The loaded definition of the language is edited, use --layer option to\n" +
+			"edit the definition in other configuration layer. The language is validated after the editor exits.",
+		Args:    cobra.ExactArgs(1),
+		Version: GetVersion(),
+		Run: func(cmd *cobra.Command, args []string) {
+			cptool, logger := newDefaultCptool(cmd)
+			var directory string
+			var err error
+			if len(layer) > 0 {
+				var langsPath string
+				langsPath, err = cptool.GetLanguagesPath(layer)
+				directory = path.Join(langsPath, args[0])
+				if info, statErr := os.Stat(directory); err == nil && (statErr != nil || !info.IsDir()) {
+					err = core.ErrNoSuchLanguage
+				}
+			} else {
+				directory, err = cptool.GetLanguageDirectory(args[0])
+			}
+			if err == core.ErrBuiltinLanguage {
+				logger.PrintError(err, ", use `cptool lang export ", args[0], "` to customize it")
+				os.Exit(1)
+			} else if err != nil {
+				logger.PrintError(err, ": ", args[0])
+				os.Exit(1)
+			}
+
+			editor := os.Getenv("EDITOR")
+			if len(editor) == 0 {
+				logger.PrintError("Cannot open editor because $EDITOR is not set")
+				os.Exit(1)
+			}
+			editorCmd := exec.Command(editor, path.Join(directory, "lang.conf"))
+			editorCmd.Stdin = os.Stdin
+			editorCmd.Stdout = os.Stdout
+			editorCmd.Stderr = os.Stderr
+			if err := editorCmd.Run(); err != nil {
+				logger.PrintError(err)
+				os.Exit(1)
+			}
+
+			if _, err := cptool.ValidateLanguageDirectory(directory); err != nil {
+				logger.PrintError("Language is not valid after editing: ", err)
+				os.Exit(1)
+			}
+			logger.PrintSuccess("Language is valid")
+		},
+	}
+
+	cmd.Flags().StringVarP(&layer, "layer", "l", "", "configuration layer to edit: project, cptool_home, user or system")
+
+	return cmd
+}
+
+func initLangCheckCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "check [NAME...]",
+		Short: "Check whether the toolchain of languages is installed and works",
+		Long: "Check whether the toolchain of languages is installed and works by compiling and running a tiny program. Every\n" +
+			"language is checked when no language specified.",
+		Version: GetVersion(),
+		Run: func(cmd *cobra.Command, args []string) {
+			cptool, logger := newDefaultCptool(cmd)
+			languages, _ := cptool.GetAllLanguages()
+			if len(args) > 0 {
+				languages = languages[:0]
+				for _, name := range args {
+					language, err := cptool.GetLanguageByName(name)
+					if err != nil {
+						logger.PrintError(err, ": ", name)
+						os.Exit(1)
+					}
+					languages = append(languages, language)
+				}
+			}
+			if !checkLanguages(cptool, logger, languages) {
+				os.Exit(1)
+			}
+		},
+	}
+}
+
+func initLangExportCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "export NAME [DIR]",
+		Short: "Write a built-in language definition into a directory for customization",
+		Long: "Write a built-in language definition into a directory named NAME inside DIR, so it can be customized. When DIR\n" +
+			"is not specified, the language is written into .cptool/langs directory of the project root, where it overrides the\n" +
+			"built-in language.",
+		Args:    cobra.RangeArgs(1, 2),
+		Version: GetVersion(),
+		Run: func(cmd *cobra.Command, args []string) {
+			cptool, logger := newDefaultCptool(cmd)
+			directory := ""
+			if len(args) > 1 {
+				directory = args[1]
+			}
+			languagePath, err := cptool.ExportBuiltinLanguage(args[0], directory)
+			if err != nil {
+				logger.PrintError(err, ": ", args[0])
+				os.Exit(1)
+			}
+			logger.PrintSuccess("Language exported to: ", languagePath)
+		},
+	}
+}
+
+// printLanguage prints the definition of a language.
+func printLanguage(lang core.Language, isDefault bool) {
+	if isDefault {
+		fmt.Printf("[ %s ] (default language)\n", lang.Name)
+	} else {
+		fmt.Printf("[ %s ]\n", lang.Name)
+	}
+	fmt.Printf("  language name:  %s\n", lang.VerboseName)
+	fmt.Printf("  file extension: %s\n", lang.Extension)
+	if len(lang.Extends) > 0 {
+		fmt.Printf("  extends:        %s\n", lang.Extends)
+	}
+	if lang.Interpreted {
+		fmt.Printf("  interpreted:    yes\n")
+	}
+	if lang.DirectoryTarget {
+		fmt.Printf("  target:         directory\n")
+	}
+	if len(lang.CompileCommand) > 0 {
+		fmt.Printf("  compile:        %s\n", strings.Join(lang.CompileCommand, " "))
+	} else if len(lang.CompileScript) > 0 {
+		fmt.Printf("  compile script: %s\n", lang.CompileScript)
+	}
+	if len(lang.RunCommand) > 0 {
+		fmt.Printf("  run:            %s\n", strings.Join(lang.RunCommand, " "))
+	} else {
+		fmt.Printf("  run script:     %s\n", lang.RunScript)
+	}
+	if len(lang.CompileFlags) > 0 {
+		fmt.Printf("  compile flags:  %s\n", strings.Join(lang.CompileFlags, " "))
+	}
+	if len(lang.DebugFlags) > 0 {
+		fmt.Printf("  debug flags:    %s\n", strings.Join(lang.DebugFlags, " "))
+	}
+	if lang.TimeFactor > 0 && lang.TimeFactor != 1 {
+		fmt.Printf("  time factor:    %v\n", lang.TimeFactor)
+	}
+	if lang.TimeBonus > 0 {
+		fmt.Printf("  time bonus:     %v\n", lang.TimeBonus)
+	}
+	if lang.MemoryBonus > 0 {
+		fmt.Printf("  memory bonus:   %d MB\n", lang.MemoryBonus)
+	}
+	if len(lang.DebugCommand) > 0 {
+		fmt.Printf("  debug compile:  %s\n", strings.Join(lang.DebugCommand, " "))
+	} else if lang.Debuggable {
+		fmt.Printf("  debug script:   %s\n", lang.DebugScript)
+	}
+}
+
+// printLanguageSources prints where the language is defined, and the definitions that are shadowed by it.
+func printLanguageSources(sources []core.LanguageSource) {
+	for _, source := range sources {
+		switch {
+		case source.Active:
+			fmt.Printf("  defined in:     %s\n", source.Path)
+		case source.Err != nil:
+			fmt.Printf("  rejected:       %v\n", source.Err)
+		default:
+			fmt.Printf("  shadowed:       %s\n", source.Path)
+		}
+	}
 }
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/jauhararifin/cptool/internal/core"
+	"github.com/spf13/cobra"
+)
+
+func initListenCommand() *cobra.Command {
+	var port int
+	var createDirectory bool
+	var scaffold bool
+	var languageName string
+
+	cmd := &cobra.Command{
+		Use:   "listen",
+		Short: "Import problems and sample tests from Competitive Companion",
+		Long: "Listen to problems sent by Competitive Companion browser extension. Every received problem's sample tests are saved\n" +
+			"as test cases, and the problem's time limit and memory limit are saved in problem.toml. Add the port as a custom\n" +
+			"port in Competitive Companion's settings.",
+		Args:    cobra.NoArgs,
+		Version: GetVersion(),
+		Run: func(cmd *cobra.Command, args []string) {
+			cptool, logger := newDefaultCptool(cmd)
+
+			var language core.Language
+			if scaffold {
+				var err error
+				if len(languageName) > 0 {
+					language, err = cptool.GetLanguageByName(languageName)
+				} else {
+					language, err = cptool.GetDefaultLanguage()
+				}
+				if err != nil {
+					logger.PrintError("cannot determine language")
+					os.Exit(1)
+				}
+			}
+
+			var mutex sync.Mutex
+			handler := core.NewCompanionHandler(func(problem core.CompanionProblem) error {
+				mutex.Lock()
+				defer mutex.Unlock()
+
+				imported, err := cptool.ImportProblem(problem, createDirectory)
+				if err != nil {
+					logger.PrintError(err)
+					return err
+				}
+				logger.PrintSuccess("Problem imported: ", problem.Name, " (", len(imported.TestCases), " sample tests)")
+				for _, skipped := range imported.Skipped {
+					logger.PrintWarning("File already exists, keeping it unchanged: ", skipped)
+				}
+
+				if scaffold {
+					solutionName := imported.Name
+					if createDirectory {
+						solutionName = filepath.Join(imported.Name, imported.Name)
+					}
+					solution, err := cptool.CreateSolution(solutionName, language)
+					if err == core.ErrSolutionAlreadyExists {
+						logger.PrintWarning("Solution already exists: ", solutionName)
+						return nil
+					}
+					if err != nil {
+						logger.PrintError(err)
+						return err
+					}
+					logger.PrintInfo("Solution created: ", solution.Path)
+				}
+				return nil
+			})
+
+			address := fmt.Sprintf("127.0.0.1:%d", port)
+			logger.PrintInfo("Listening on ", address)
+			if err := http.ListenAndServe(address, handler); err != nil {
+				logger.PrintError(err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().IntVarP(&port, "port", "p", 10043, "port to listen on")
+	cmd.Flags().BoolVarP(&createDirectory, "directory", "d", false, "save every problem in its own directory")
+	cmd.Flags().BoolVarP(&scaffold, "scaffold", "s", false, "create solution from template for every problem")
+	cmd.Flags().StringVarP(&languageName, "language", "l", "", "language of scaffolded solution, default language is used when empty")
+
+	return cmd
+}
@@ -27,6 +27,13 @@ func Execute() {
 	rootCommand.AddCommand(initTestCommand())
 	rootCommand.AddCommand(initLangCommand())
 	rootCommand.AddCommand(initCleanCommand())
+	rootCommand.AddCommand(initNewCommand())
+	rootCommand.AddCommand(initBundleCommand())
+	rootCommand.AddCommand(initListenCommand())
+	rootCommand.AddCommand(initServeCommand())
+	rootCommand.AddCommand(initJudgeCommand())
+	rootCommand.AddCommand(initConfigCommand())
+	rootCommand.AddCommand(initDoctorCommand())
 
 	if err := rootCommand.Execute(); err != nil {
 		fmt.Println(err)
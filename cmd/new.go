@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"os"
+	"os/exec"
+
+	"github.com/spf13/cobra"
+)
+
+func initNewCommand() *cobra.Command {
+	var samples int
+	var edit bool
+
+	cmd := &cobra.Command{
+		Use:   "new [LANGUAGE] NAME",
+		Short: "Create new competitive programming solution",
+		Long: "Create new competitive programming solution from language's template. The template is a file named\n" +
+			"<language-name>.<language-extension> or <language-name> inside \"templates\" directory in your cptool\n" +
+			"configuration directory. The template can use {{.Name}}, {{.Language}}, {{.Author}} and {{.Date}} placeholders.",
+		Args:    cobra.RangeArgs(1, 2),
+		Version: GetVersion(),
+		Run: func(cmd *cobra.Command, args []string) {
+			cptool, logger := newDefaultCptool(cmd)
+			solutionName, language := parseSolution(cptool, logger, args)
+
+			solution, err := cptool.CreateSolution(solutionName, language)
+			if err != nil {
+				logger.PrintError(err)
+				os.Exit(1)
+			}
+			logger.PrintSuccess("Solution created: ", solution.Path)
+
+			testCases, err := cptool.CreateSampleTestCases(solutionName, samples)
+			if err != nil {
+				logger.PrintError(err)
+				os.Exit(1)
+			}
+			for _, testCase := range testCases {
+				logger.PrintInfo("Sample test case created: ", testCase.Name)
+			}
+
+			if edit {
+				editor := os.Getenv("EDITOR")
+				if len(editor) == 0 {
+					logger.PrintWarning("Cannot open editor because $EDITOR is not set")
+					return
+				}
+				editorCmd := exec.Command(editor, solution.Path)
+				editorCmd.Stdin = os.Stdin
+				editorCmd.Stdout = os.Stdout
+				editorCmd.Stderr = os.Stderr
+				if err := editorCmd.Run(); err != nil {
+					logger.PrintError(err)
+					os.Exit(1)
+				}
+			}
+		},
+	}
+
+	cmd.Flags().IntVarP(&samples, "samples", "s", 0, "create SAMPLES empty sample test cases for the solution")
+	cmd.Flags().BoolVarP(&edit, "edit", "e", false, "open the created solution using $EDITOR")
+
+	return cmd
+}
@@ -21,9 +21,12 @@ func initRunCommand() *cobra.Command {
 		Args:    cobra.RangeArgs(1, 2),
 		Version: GetVersion(),
 		Run: func(cmd *cobra.Command, args []string) {
-			cptool, logger := newDefaultCptool(cmd)
+			client, logger := newClient(cmd)
 
-			solutionName, language := parseSolution(cptool, logger, args)
+			solution := findSolution(client, logger, args)
+			if !cmd.Flags().Changed("timeout") {
+				timeout = client.Config().Timeout
+			}
 
 			isTerminal := true
 			stat, _ := os.Stdin.Stat()
@@ -31,7 +34,7 @@ func initRunCommand() *cobra.Command {
 				isTerminal = false
 			}
 
-			if isTerminal && timeout != 10*time.Second {
+			if isTerminal && cmd.Flags().Changed("timeout") {
 				logger.PrintWarning("Timeout flag only works on piped stdin")
 			}
 
@@ -44,9 +47,17 @@ func initRunCommand() *cobra.Command {
 			}
 			defer cancel()
 
-			result, err := cptool.RunByName(ctx, language.Name, solutionName, os.Stdin, os.Stdout, os.Stderr)
+			// the solution is compiled first, so the compiler's warnings are printed before the solution's output.
+			compilation, err := client.Compile(ctx, solution, false)
 			if err != nil {
-				logger.PrintError(err)
+				printError(logger, err)
+				os.Exit(1)
+			}
+			printCompilerWarnings(logger, compilation)
+
+			result, err := client.Run(ctx, solution, os.Stdin, os.Stdout, os.Stderr)
+			if err != nil {
+				printError(logger, err)
 				os.Exit(1)
 			}
 			if ctx.Err() != nil {
@@ -55,6 +66,12 @@ func initRunCommand() *cobra.Command {
 			if !hideTime {
 				logger.PrintInfo("Ellapsed time: ", result.Duration.Seconds(), " seconds")
 			}
+			if result.TimeLimitExceeded {
+				logger.PrintWarning("Time limit exceeded, the time limit is ", result.TimeLimit.Seconds(), " seconds")
+			}
+			if result.MemoryLimitExceeded {
+				logger.PrintWarning("Memory limit exceeded, the memory limit is ", result.MemoryLimit, " MB")
+			}
 		},
 	}
 
@@ -62,8 +79,8 @@ func initRunCommand() *cobra.Command {
 	cmd.Flags().DurationVarP(&timeout, "timeout", "t", 10*time.Second, "Kill program if still running after TIME\n"+
 		"TIME is a floating point number with an optional suffix:\n"+
 		"'s' for seconds (the default), 'm' for minutes, 'h' for hours or 'd' for days\n"+
-		"The default value of this option is 10s. This option only works if the program is\n"+
-		"running using stdin from file and not from terminal.\n")
+		"The default value of this option is \"timeout\" setting in config or 10s. This option only works\n"+
+		"if the program is running using stdin from file and not from terminal.\n")
 
 	return cmd
 }
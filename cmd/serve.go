@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+
+	api "github.com/jauhararifin/cptool/pkg/cptool"
+	"github.com/spf13/cobra"
+)
+
+func initServeCommand() *cobra.Command {
+	var port int
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve cptool as a local JSON API for editor integration",
+		Long: "Serve cptool as a local HTTP JSON API, so editor plugins can list languages and test cases, and compile, run and\n" +
+			"test solutions without parsing cptool's output. The configuration files and languages are loaded once and reused\n" +
+			"by every request. The server only listens on 127.0.0.1. The progress of testing is streamed as newline delimited\n" +
+			"JSON events, and closing the connection cancels the request. See README for the endpoints.",
+		Args:    cobra.NoArgs,
+		Version: GetVersion(),
+		Run: func(cmd *cobra.Command, args []string) {
+			// the solution's stderr of test cases would be mixed with the server's logs, so it is discarded.
+			client, logger := newClient(cmd, api.WithStderr(ioutil.Discard))
+
+			address := fmt.Sprintf("127.0.0.1:%d", port)
+			logger.PrintInfo("Listening on ", address)
+			if err := http.ListenAndServe(address, api.NewHandler(client)); err != nil {
+				logger.PrintError(err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().IntVarP(&port, "port", "p", 10044, "port to listen on")
+
+	return cmd
+}
@@ -0,0 +1,64 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package cmd
+
+import (
+	"errors"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// errNotTerminal indicates the file is not a terminal, so it cannot be switched into raw mode.
+var errNotTerminal = errors.New("Terminal UI needs stdin to be a terminal")
+
+// terminal switches a terminal into raw mode, so every key press is read immediately without echo. The original mode is
+// restored using restore.
+type terminal struct {
+	fd       uintptr
+	original syscall.Termios
+}
+
+func openTerminal(file *os.File) (*terminal, error) {
+	term := &terminal{fd: file.Fd()}
+	if err := term.ioctl(ioctlGetTermios, unsafe.Pointer(&term.original)); err != nil {
+		return nil, errNotTerminal
+	}
+
+	raw := term.original
+	raw.Iflag &^= syscall.IGNBRK | syscall.BRKINT | syscall.PARMRK | syscall.ISTRIP | syscall.INLCR | syscall.IGNCR |
+		syscall.ICRNL | syscall.IXON
+	raw.Oflag &^= syscall.OPOST
+	raw.Lflag &^= syscall.ECHO | syscall.ECHONL | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+	raw.Cflag &^= syscall.CSIZE | syscall.PARENB
+	raw.Cflag |= syscall.CS8
+	raw.Cc[syscall.VMIN] = 1
+	raw.Cc[syscall.VTIME] = 0
+	if err := term.ioctl(ioctlSetTermios, unsafe.Pointer(&raw)); err != nil {
+		return nil, err
+	}
+	return term, nil
+}
+
+func (term *terminal) restore() {
+	term.ioctl(ioctlSetTermios, unsafe.Pointer(&term.original))
+}
+
+// size returns the number of columns and rows of the terminal, or 80x24 when the size cannot be determined.
+func (term *terminal) size() (int, int) {
+	var size struct {
+		rows, columns, x, y uint16
+	}
+	if err := term.ioctl(syscall.TIOCGWINSZ, unsafe.Pointer(&size)); err != nil || size.columns == 0 || size.rows == 0 {
+		return 80, 24
+	}
+	return int(size.columns), int(size.rows)
+}
+
+func (term *terminal) ioctl(request uintptr, arg unsafe.Pointer) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, term.fd, request, uintptr(arg)); errno != 0 {
+		return errno
+	}
+	return nil
+}
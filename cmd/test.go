@@ -3,73 +3,115 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"strings"
 	"time"
 
-	"github.com/jauhararifin/cptool/internal/core"
 	"github.com/jauhararifin/cptool/internal/logger"
+	api "github.com/jauhararifin/cptool/pkg/cptool"
 	"github.com/spf13/cobra"
 )
 
-func parseSolutionAndTestcasePrefix(cptool *core.CPTool, logger *logger.Logger, args []string) (string, core.Language, string) {
-	if len(args) > 2 {
-		solutionName := args[1]
-		testcasePrefix := args[2]
-		language, err := cptool.GetLanguageByName(args[0])
-		if err != nil {
-			logger.PrintError("cannot determine language")
-			os.Exit(1)
-		}
-		return solutionName, language, testcasePrefix
-	}
-	solutionName := args[0]
-	testcasePrefix := args[1]
-	language, err := cptool.GetDefaultLanguage()
-	if err != nil {
-		logger.PrintError("cannot determine language")
-		os.Exit(1)
-	}
-	return solutionName, language, testcasePrefix
-}
-
 func initTestCommand() *cobra.Command {
 	var hideTime bool
+	var keepOutputs bool
 	var timeout time.Duration
+	var timeLimit time.Duration
+	var memoryLimit int
+	var checker string
+	var interactor string
+	var pattern string
+	var jobs int
+	var tui bool
 
 	cmd := &cobra.Command{
 		Use:   "test [LANGUAGE] SOLUTION TESTCASE_PREFIX",
 		Short: "Test competitive programming solution",
 		Long: "Test competitive programming solution. The program will compiled first if not yet compiled. The program will run\n" +
 			"with provided testcases. The program will be killed if still running after some period of time, you can change\n" +
-			"this behaviour using --timeout option.",
+			"this behaviour using --timeout option. The output of failed test cases are saved in .cptool/outputs directory, use\n" +
+			"--keep-outputs option to save the output of all test cases. Use --jobs option to test several test cases in\n" +
+			"parallel. The time limit, memory limit, checker, interactor and test case pattern are loaded from problem.toml\n" +
+			"in the solution's directory (or current working directory), and can be overridden using options. Use --tui\n" +
+			"option to show the results in a full-screen terminal UI, where each test case can be inspected and rerun.",
 		Args:    cobra.RangeArgs(2, 3),
 		Version: GetVersion(),
 		Run: func(cmd *cobra.Command, args []string) {
-			cptool, logger := newDefaultCptool(cmd)
+			clientOptions := make([]api.Option, 0)
+			if tui {
+				// the terminal UI owns the screen, so the logs and the solution's stderr would break its layout.
+				clientOptions = append(clientOptions, api.WithStderr(ioutil.Discard), api.WithLogOutput(ioutil.Discard, false))
+			}
+			client, logger := newClient(cmd, clientOptions...)
 
-			solutionName, language, testcasePrefix := parseSolutionAndTestcasePrefix(cptool, logger, args)
+			solution := findSolution(client, logger, args[:len(args)-1])
+			testcasePrefix := args[len(args)-1]
+			opts := api.TestOptions{
+				Prefix:      testcasePrefix,
+				KeepOutputs: keepOutputs,
+				TimeLimit:   timeLimit,
+				MemoryLimit: memoryLimit,
+				Checker:     absolutePath(checker),
+				Interactor:  absolutePath(interactor),
+				Pattern:     pattern,
+			}
+			if cmd.Flags().Changed("jobs") {
+				opts.Jobs = jobs
+			}
+			if !cmd.Flags().Changed("timeout") {
+				timeout = client.Config().Timeout
+			}
 
-			ctx, cancel := context.WithTimeout(context.Background(), timeout)
-			defer cancel()
+			if tui {
+				opts.KeepOutputs = true
+				if err := runTestTUI(client, solution, opts, timeout); err != nil {
+					logger.PrintError(err)
+					os.Exit(1)
+				}
+				return
+			}
 
-			result, err := cptool.TestByName(ctx, language.Name, solutionName, testcasePrefix)
+			problem, err := client.SolutionProblem(solution)
 			if err != nil {
-				logger.PrintError(err)
+				printError(logger, err)
 				os.Exit(1)
 			}
-
-			if ctx.Err() != nil {
-				logger.PrintWarning("Test stopped due to timeout")
+			if opts.TimeLimit > 0 {
+				problem.TimeLimit = opts.TimeLimit
+			}
+			if opts.MemoryLimit > 0 {
+				problem.MemoryLimit = opts.MemoryLimit
+			}
+			timeLimit, memoryLimit := solution.Language.Limits(problem.TimeLimit, problem.MemoryLimit)
+			if limits := formatLimits(timeLimit, memoryLimit); len(limits) > 0 {
+				if timeLimit != problem.TimeLimit || memoryLimit != problem.MemoryLimit {
+					limits += " (adjusted for " + solution.Language.VerboseName + ")"
+				}
+				logger.PrintInfo(limits)
 			}
-			for _, testCase := range result.TestCaseResults {
-				if testCase.Status == core.TestCaseSuccess {
-					logger.PrintSuccess(testCase.Testcase.Name, " success in ", testCase.Duration.Seconds(), " seconds")
-				} else if testCase.Status == core.TestCaseSkipped {
-					logger.PrintWarning(testCase.Testcase.Name, " skipped")
-				} else {
-					logger.PrintError(testCase.Testcase.Name, " failed in ", testCase.Duration.Seconds(), " seconds")
+
+			opts.Observer = func(event api.Event) {
+				switch event.Type {
+				case api.CompileFinished:
+					if event.Err == nil {
+						printCompilerWarnings(logger, event.Compilation)
+					}
+				case api.TestFinished:
+					printTestCaseResult(logger, event.Result)
+				case api.Aborted:
+					logger.PrintWarning("Test stopped due to timeout")
 				}
 			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			result, err := client.Test(ctx, solution, opts)
+			if err != nil {
+				printError(logger, err)
+				os.Exit(1)
+			}
 			if !hideTime {
 				fmt.Printf("Ellapsed time: %.2f seconds\n", result.Duration.Seconds())
 			}
@@ -77,11 +119,51 @@ func initTestCommand() *cobra.Command {
 	}
 
 	cmd.Flags().BoolVar(&hideTime, "hide-time", false, "hide the time indicator when execution finished")
+	cmd.Flags().BoolVar(&keepOutputs, "keep-outputs", false, "save the output of every test case, not only the failed ones")
+	cmd.Flags().BoolVar(&tui, "tui", false, "show the results in a full-screen terminal UI to inspect and rerun test cases")
+	cmd.Flags().IntVarP(&jobs, "jobs", "j", 1, "number of test cases tested in parallel, overrides jobs in config")
+	cmd.Flags().DurationVar(&timeLimit, "time-limit", 0, "time limit of every test case, overrides time_limit in problem.toml")
+	cmd.Flags().IntVar(&memoryLimit, "memory-limit", 0, "memory limit in megabytes of every test case, overrides memory_limit in problem.toml")
+	cmd.Flags().StringVar(&checker, "checker", "", "program to check solution's output, overrides checker in problem.toml")
+	cmd.Flags().StringVar(&interactor, "interactor", "", "program to interact with solution, overrides interactor in problem.toml")
+	cmd.Flags().StringVar(&pattern, "pattern", "", "glob pattern of test cases' input files, overrides test_pattern in problem.toml")
 	cmd.Flags().DurationVarP(&timeout, "timeout", "t", 10*time.Second, "Stop all test if still running after TIME\n"+
 		"TIME is a floating point number with an optional suffix:\n"+
 		"'s' for seconds (the default), 'm' for minutes, 'h' for hours or 'd' for days\n"+
-		"The default value of this option is 100s. This option only works if the program is\n"+
-		"running using stdin from file and not from terminal.\n")
+		"The default value of this option is \"timeout\" setting in config or 10s. This option only works\n"+
+		"if the program is running using stdin from file and not from terminal.\n")
 
 	return cmd
 }
+
+// printTestCaseResult prints the verdict of a test case.
+func printTestCaseResult(logger *logger.Logger, testCase api.TestCaseResult) {
+	switch testCase.Verdict {
+	case api.Accepted:
+		logger.PrintSuccess(testCase.Name, " success in ", testCase.Duration.Seconds(), " seconds")
+	case api.Skipped:
+		logger.PrintWarning(testCase.Name, " skipped")
+	case api.TimeLimitExceeded:
+		logger.PrintError(testCase.Name, " time limit exceeded in ", testCase.Duration.Seconds(), " seconds")
+	case api.MemoryLimitExceeded:
+		logger.PrintError(testCase.Name, " memory limit exceeded using ", testCase.Memory/1024, " KB")
+	default:
+		logger.PrintError(testCase.Name, " failed in ", testCase.Duration.Seconds(), " seconds")
+	}
+}
+
+// formatLimits returns the description of time limit and memory limit, or empty string when both are unlimited.
+func formatLimits(timeLimit time.Duration, memoryLimit int) string {
+	limits := make([]string, 0, 2)
+	if timeLimit > 0 {
+		limits = append(limits, fmt.Sprint("time limit: ", timeLimit.Seconds(), " seconds"))
+	}
+	if memoryLimit > 0 {
+		limits = append(limits, fmt.Sprint("memory limit: ", memoryLimit, " MB"))
+	}
+	if len(limits) == 0 {
+		return ""
+	}
+	description := strings.Join(limits, ", ")
+	return strings.ToUpper(description[:1]) + description[1:]
+}
@@ -0,0 +1,543 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	api "github.com/jauhararifin/cptool/pkg/cptool"
+)
+
+// tuiMaxFileSize limits how much of input and output files are shown in the detail view.
+const tuiMaxFileSize = 256 * 1024
+
+// tuiMinHeight is the number of lines needed for the title, the table's header, a test case, the status and the help line.
+const tuiMinHeight = 5
+
+const (
+	tuiRowPending = iota
+	tuiRowRunning
+	tuiRowDone
+)
+
+type tuiRow struct {
+	name   string
+	state  int
+	result api.TestCaseResult
+}
+
+type tuiRunDone struct {
+	report api.TestReport
+	err    error
+}
+
+// testUI is a full-screen table of test cases that is updated as the test events arrive. Every field is only accessed by the
+// goroutine that runs the UI, the events and key presses are received through channels.
+type testUI struct {
+	client   *api.CPTool
+	solution api.Solution
+	opts     api.TestOptions
+	timeout  time.Duration
+
+	out           *bufio.Writer
+	width, height int
+
+	rows     []*tuiRow
+	selected int
+	offset   int
+
+	running       bool
+	cancel        context.CancelFunc
+	events        chan api.Event
+	done          chan tuiRunDone
+	status        string
+	compileOutput []string
+
+	detail       *tuiRow
+	detailLines  []string
+	detailOffset int
+}
+
+// runTestTUI tests the solution in a full-screen terminal UI until the user quits. Every test case is shown with its verdict,
+// time and memory, and can be opened to see its input, expected output, actual output and diff.
+func runTestTUI(client *api.CPTool, solution api.Solution, opts api.TestOptions, timeout time.Duration) error {
+	term, err := openTerminal(os.Stdin)
+	if err != nil {
+		return err
+	}
+	defer term.restore()
+
+	ui := &testUI{
+		client:   client,
+		solution: solution,
+		opts:     opts,
+		timeout:  timeout,
+		out:      bufio.NewWriter(os.Stdout),
+		events:   make(chan api.Event),
+		done:     make(chan tuiRunDone, 1),
+	}
+	ui.out.WriteString("\x1b[?1049h\x1b[?25l")
+	defer func() {
+		ui.out.WriteString("\x1b[?25h\x1b[?1049l")
+		ui.out.Flush()
+	}()
+
+	keys := make(chan string)
+	go readKeys(os.Stdin, keys)
+	resize := make(chan os.Signal, 1)
+	signal.Notify(resize, syscall.SIGWINCH)
+	defer signal.Stop(resize)
+
+	ui.start(nil, false)
+	for {
+		ui.width, ui.height = term.size()
+		ui.draw()
+		select {
+		case key := <-keys:
+			if !ui.handleKey(key) {
+				if ui.cancel != nil {
+					ui.cancel()
+				}
+				return nil
+			}
+		case event := <-ui.events:
+			ui.handleEvent(event)
+		case result := <-ui.done:
+			ui.finish(result)
+		case <-resize:
+		}
+	}
+}
+
+// start tests the solution using the test cases with names, or every test case when names is empty.
+func (ui *testUI) start(names []string, recompile bool) {
+	if ui.running {
+		ui.status = "Tests are still running"
+		return
+	}
+	for _, row := range ui.rows {
+		if len(names) == 0 || containsString(names, row.name) {
+			row.state = tuiRowPending
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), ui.timeout)
+	ui.cancel = cancel
+	ui.running = true
+	ui.compileOutput = nil
+	ui.status = "Starting"
+
+	opts := ui.opts
+	opts.Names = names
+	opts.Recompile = recompile
+	opts.Observer = func(event api.Event) {
+		select {
+		case ui.events <- event:
+		case <-ctx.Done():
+		}
+	}
+	go func() {
+		report, err := ui.client.Test(ctx, ui.solution, opts)
+		ui.done <- tuiRunDone{report: report, err: err}
+	}()
+}
+
+func (ui *testUI) handleEvent(event api.Event) {
+	switch event.Type {
+	case api.CompileStarted:
+		ui.status = "Compiling " + ui.solution.Name
+	case api.CompileFinished:
+		if event.Err == nil {
+			ui.status = "Testing"
+		}
+	case api.TestStarted:
+		ui.row(event.TestCase.Name).state = tuiRowRunning
+	case api.TestFinished:
+		row := ui.row(event.Result.Name)
+		row.state = tuiRowDone
+		row.result = event.Result
+	}
+}
+
+func (ui *testUI) finish(result tuiRunDone) {
+	ui.running = false
+	ui.cancel()
+	if compilationErr, ok := result.err.(*api.CompilationError); ok {
+		ui.status = compilationErr.Error()
+		ui.compileOutput = splitLines(compilationErr.Output)
+		return
+	} else if result.err != nil {
+		ui.status = result.err.Error()
+		return
+	}
+
+	accepted := 0
+	for _, testCase := range result.report.Results {
+		row := ui.row(testCase.Name)
+		row.state = tuiRowDone
+		row.result = testCase
+		if testCase.Verdict == api.Accepted {
+			accepted++
+		}
+	}
+	ui.status = fmt.Sprintf("%d/%d accepted in %.2f seconds", accepted, len(result.report.Results),
+		result.report.Duration.Seconds())
+	if result.report.Aborted {
+		ui.status += ", stopped due to timeout"
+	}
+}
+
+// row returns the row of a test case, the row is created when the test case is not shown yet.
+func (ui *testUI) row(name string) *tuiRow {
+	for _, row := range ui.rows {
+		if row.name == name {
+			return row
+		}
+	}
+	row := &tuiRow{name: name}
+	ui.rows = append(ui.rows, row)
+	sort.SliceStable(ui.rows, func(i, j int) bool {
+		return ui.rows[i].name < ui.rows[j].name
+	})
+	return row
+}
+
+// handleKey handles a key press, it returns false when the UI should be closed.
+func (ui *testUI) handleKey(key string) bool {
+	if key == "ctrl-c" {
+		return false
+	}
+	if ui.detail != nil {
+		return ui.handleDetailKey(key)
+	}
+
+	switch key {
+	case "q":
+		return false
+	case "up", "k":
+		if ui.selected > 0 {
+			ui.selected--
+		}
+	case "down", "j":
+		if ui.selected < len(ui.rows)-1 {
+			ui.selected++
+		}
+	case "enter", " ":
+		if ui.selected < len(ui.rows) && ui.rows[ui.selected].state == tuiRowDone {
+			ui.openDetail(ui.rows[ui.selected])
+		}
+	case "r":
+		if ui.selected < len(ui.rows) {
+			ui.start([]string{ui.rows[ui.selected].name}, false)
+		}
+	case "f":
+		failed := make([]string, 0)
+		for _, row := range ui.rows {
+			if row.state == tuiRowDone && row.result.Verdict != api.Accepted {
+				failed = append(failed, row.name)
+			}
+		}
+		if len(failed) == 0 {
+			ui.status = "No failed test cases"
+		} else {
+			ui.start(failed, false)
+		}
+	case "c":
+		ui.start(nil, true)
+	}
+	return true
+}
+
+func (ui *testUI) handleDetailKey(key string) bool {
+	page := ui.bodyHeight()
+	switch key {
+	case "q", "esc", "enter":
+		ui.detail = nil
+	case "up", "k":
+		ui.detailOffset--
+	case "down", "j":
+		ui.detailOffset++
+	case "pgup":
+		ui.detailOffset -= page
+	case "pgdown":
+		ui.detailOffset += page
+	case "r":
+		name := ui.detail.name
+		ui.detail = nil
+		ui.start([]string{name}, false)
+	}
+	if ui.detailOffset > len(ui.detailLines)-page {
+		ui.detailOffset = len(ui.detailLines) - page
+	}
+	if ui.detailOffset < 0 {
+		ui.detailOffset = 0
+	}
+	return true
+}
+
+func (ui *testUI) openDetail(row *tuiRow) {
+	result := row.result
+	lines := []string{fmt.Sprintf("%s: %s", result.Name, formatTUIResult(result))}
+	if result.Err != nil {
+		lines = append(lines, "Error: "+result.Err.Error())
+	}
+
+	input := readTUIFile(result.InputPath)
+	expected := readTUIFile(result.OutputPath)
+	lines = append(lines, "", "\x1b[1mInput\x1b[0m ("+result.InputPath+")")
+	lines = append(lines, input...)
+	lines = append(lines, "", "\x1b[1mExpected output\x1b[0m ("+result.OutputPath+")")
+	lines = append(lines, expected...)
+	if len(result.ActualOutputPath) > 0 {
+		actual := readTUIFile(result.ActualOutputPath)
+		lines = append(lines, "", "\x1b[1mActual output\x1b[0m ("+result.ActualOutputPath+")")
+		lines = append(lines, actual...)
+		lines = append(lines, "", "\x1b[1mDiff\x1b[0m")
+		lines = append(lines, diffLines(expected, actual)...)
+	} else {
+		lines = append(lines, "", "\x1b[1mActual output\x1b[0m", "(not saved)")
+	}
+
+	ui.detail = row
+	ui.detailLines = lines
+	ui.detailOffset = 0
+}
+
+// bodyHeight returns the number of lines between the title and the status line.
+func (ui *testUI) bodyHeight() int {
+	if ui.height-3 < 1 {
+		return 1
+	}
+	return ui.height - 3
+}
+
+func (ui *testUI) draw() {
+	ui.out.WriteString("\x1b[H\x1b[2J")
+	if ui.height < tuiMinHeight {
+		ui.out.WriteString(truncateTUILine("Terminal too small", ui.width))
+		ui.out.Flush()
+		return
+	}
+
+	lines := make([]string, 0, ui.height)
+	lines = append(lines, fmt.Sprintf("\x1b[1mcptool test %s (%s)\x1b[0m", ui.solution.Name, ui.solution.Language.VerboseName))
+	body := ui.bodyHeight()
+
+	help := "up/down: select  enter: open  r: rerun  f: rerun failed  c: recompile  q: quit"
+	if ui.detail != nil {
+		help = "up/down/pgup/pgdown: scroll  r: rerun  esc: back"
+		end := ui.detailOffset + body
+		if end > len(ui.detailLines) {
+			end = len(ui.detailLines)
+		}
+		lines = append(lines, ui.detailLines[ui.detailOffset:end]...)
+	} else if len(ui.compileOutput) > 0 {
+		lines = append(lines, ui.compileOutput...)
+	} else {
+		lines = append(lines, ui.drawTable(body)...)
+	}
+
+	for len(lines) < ui.height-2 {
+		lines = append(lines, "")
+	}
+	lines = lines[:ui.height-2]
+	status := ui.status
+	if ui.running {
+		status = "\x1b[33m" + status + "\x1b[0m"
+	}
+	lines = append(lines, status, "\x1b[2m"+help+"\x1b[0m")
+
+	for i, line := range lines {
+		if i > 0 {
+			ui.out.WriteString("\r\n")
+		}
+		ui.out.WriteString(truncateTUILine(line, ui.width))
+	}
+	ui.out.Flush()
+}
+
+func (ui *testUI) drawTable(height int) []string {
+	nameWidth := len("TEST")
+	for _, row := range ui.rows {
+		if len(row.name) > nameWidth {
+			nameWidth = len(row.name)
+		}
+	}
+	lines := []string{fmt.Sprintf("  %-*s  %-22s %10s %12s", nameWidth, "TEST", "VERDICT", "TIME", "MEMORY")}
+	if len(ui.rows) == 0 {
+		return append(lines, "  No test cases yet")
+	}
+
+	if ui.selected < ui.offset {
+		ui.offset = ui.selected
+	}
+	if ui.selected >= ui.offset+height-1 {
+		ui.offset = ui.selected - height + 2
+	}
+	for i := ui.offset; i < len(ui.rows) && i < ui.offset+height-1; i++ {
+		row := ui.rows[i]
+		verdict, duration, memory, color := "pending", "", "", "\x1b[2m"
+		switch row.state {
+		case tuiRowRunning:
+			verdict, color = "running", "\x1b[33m"
+		case tuiRowDone:
+			verdict = row.result.Verdict.String()
+			duration = fmt.Sprintf("%.3fs", row.result.Duration.Seconds())
+			memory = fmt.Sprintf("%d KB", row.result.Memory/1024)
+			color = verdictColor(row.result.Verdict)
+		}
+		line := fmt.Sprintf("  %-*s  %s%-22s\x1b[0m %10s %12s", nameWidth, row.name, color, verdict, duration, memory)
+		if i == ui.selected {
+			line = "\x1b[7m>" + strings.Replace(line[1:], "\x1b[0m", "\x1b[0m\x1b[7m", -1) + "\x1b[0m"
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func verdictColor(verdict api.Verdict) string {
+	switch verdict {
+	case api.Accepted:
+		return "\x1b[32m"
+	case api.Skipped:
+		return "\x1b[33m"
+	}
+	return "\x1b[31m"
+}
+
+func formatTUIResult(result api.TestCaseResult) string {
+	return fmt.Sprintf("%s%s\x1b[0m in %.3f seconds using %d KB", verdictColor(result.Verdict), result.Verdict,
+		result.Duration.Seconds(), result.Memory/1024)
+}
+
+// truncateTUILine cuts a line into width columns, escape sequences don't take any column.
+func truncateTUILine(line string, width int) string {
+	var builder strings.Builder
+	columns := 0
+	escape := false
+	for _, r := range line {
+		switch {
+		case escape:
+			escape = r < '@' || r > '~' || r == '['
+		case r == '\x1b':
+			escape = true
+		case columns >= width:
+			continue
+		default:
+			columns++
+		}
+		builder.WriteRune(r)
+	}
+	return builder.String()
+}
+
+// readTUIFile reads the lines of a file for the detail view. Only the beginning of large files is read.
+func readTUIFile(filePath string) []string {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return []string{"(" + err.Error() + ")"}
+	}
+	defer file.Close()
+	content := make([]byte, tuiMaxFileSize+1)
+	n, err := io.ReadFull(file, content)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return []string{"(" + err.Error() + ")"}
+	}
+	if n <= tuiMaxFileSize {
+		return splitLines(string(content[:n]))
+	}
+	return append(splitLines(string(content[:tuiMaxFileSize])), "(truncated)")
+}
+
+// splitLines splits text into lines without the trailing empty lines, tabs are expanded so they take the expected columns.
+func splitLines(text string) []string {
+	text = strings.Replace(text, "\t", "    ", -1)
+	text = strings.Replace(text, "\r", "", -1)
+	lines := strings.Split(text, "\n")
+	for len(lines) > 0 && len(strings.TrimSpace(lines[len(lines)-1])) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines compares the expected output and the actual output line by line, ignoring trailing spaces.
+func diffLines(expected []string, actual []string) []string {
+	diff := make([]string, 0)
+	for i := 0; i < len(expected) || i < len(actual); i++ {
+		if i < len(expected) && i < len(actual) && strings.TrimRight(expected[i], " ") == strings.TrimRight(actual[i], " ") {
+			continue
+		}
+		diff = append(diff, fmt.Sprintf("line %d:", i+1))
+		if i < len(expected) {
+			diff = append(diff, "\x1b[32m- "+expected[i]+"\x1b[0m")
+		}
+		if i < len(actual) {
+			diff = append(diff, "\x1b[31m+ "+actual[i]+"\x1b[0m")
+		}
+	}
+	if len(diff) == 0 {
+		diff = append(diff, "(no difference)")
+	}
+	return diff
+}
+
+func containsString(values []string, value string) bool {
+	for _, item := range values {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// readKeys reads key presses from the terminal in raw mode and sends their names, like "up", "enter" or "q".
+func readKeys(input io.Reader, keys chan<- string) {
+	buffer := make([]byte, 64)
+	for {
+		n, err := input.Read(buffer)
+		if err != nil {
+			return
+		}
+		for i := 0; i < n; i++ {
+			switch b := buffer[i]; {
+			case b == 0x1b && i+2 < n && buffer[i+1] == '[':
+				sequence := ""
+				for j := i + 2; j < n; j++ {
+					if buffer[j] >= '@' && buffer[j] <= '~' {
+						sequence = string(buffer[i+2 : j+1])
+						i = j
+						break
+					}
+				}
+				switch sequence {
+				case "A":
+					keys <- "up"
+				case "B":
+					keys <- "down"
+				case "5~":
+					keys <- "pgup"
+				case "6~":
+					keys <- "pgdown"
+				}
+			case b == 0x1b:
+				keys <- "esc"
+			case b == '\r' || b == '\n':
+				keys <- "enter"
+			case b == 0x03:
+				keys <- "ctrl-c"
+			default:
+				keys <- string(b)
+			}
+		}
+	}
+}
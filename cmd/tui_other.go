@@ -0,0 +1,15 @@
+//go:build !linux && !darwin
+// +build !linux,!darwin
+
+package cmd
+
+import (
+	"errors"
+	"time"
+
+	api "github.com/jauhararifin/cptool/pkg/cptool"
+)
+
+func runTestTUI(client *api.CPTool, solution api.Solution, opts api.TestOptions, timeout time.Duration) error {
+	return errors.New("Terminal UI is not supported on this platform")
+}
@@ -1,13 +1,12 @@
 module github.com/jauhararifin/cptool
 
-go 1.12
+go 1.16
 
 require (
 	github.com/BurntSushi/toml v0.3.0
-	github.com/inconshreveable/mousetrap v1.0.0
+	github.com/inconshreveable/mousetrap v1.0.0 // indirect
 	github.com/spf13/afero v1.1.1
 	github.com/spf13/cobra v0.0.3
-	github.com/spf13/pflag v1.0.1
-	github.com/udhos/equalfile v0.0.0-20161210044245-1fbb66f8eea4
-	golang.org/x/text v0.3.0
+	github.com/spf13/pflag v1.0.1 // indirect
+	golang.org/x/text v0.3.0 // indirect
 )
@@ -0,0 +1,398 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/spf13/afero"
+)
+
+// Config stores cptool's global settings. The settings are merged from several configuration files (layers) and environment
+// variables. Every setting has a key that is used in configuration file and environment variable. The environment variable of
+// a setting is its key in uppercase prefixed by "CPTOOL_", for example "CPTOOL_DEFAULT_LANGUAGE" for "default_language".
+//
+// DefaultLanguage is the name of language used when no language specified. Author is used in solution templates. LibraryPaths
+// contains directories that searched when bundling local includes, the relative ones in a configuration file are relative to the
+// file's directory. Timeout is the default timeout of run and test command.
+// Checker is the default checker program used when the problem doesn't define one. Jobs is the number of test cases tested in
+// parallel. Colors indicates whether the output is colored. Templates is a directory that contains solution templates, it is
+// searched before the templates directory in configuration paths. KeepOutputs indicates whether the output of every test case
+// is saved. PreferredLanguages contains language names that are chosen first when several languages have the extension of a
+// solution file.
+type Config struct {
+	DefaultLanguage string
+	Author          string
+	LibraryPaths    []string
+	Timeout         time.Duration
+	Checker         string
+	Jobs            int
+	Colors          bool
+	Templates       string
+	KeepOutputs     bool
+
+	PreferredLanguages []string
+
+	sources map[string]string
+}
+
+// Layer is a configuration file. Name identifies the layer, like "project" or "user", and Path contains the location of the
+// configuration file.
+type Layer struct {
+	Name string
+	Path string
+}
+
+// Value is the value of a setting with the source it comes from. Source contains the path of configuration file, the name of
+// environment variable or "default" when the setting is not set anywhere.
+type Value struct {
+	Key    string
+	Value  string
+	Source string
+}
+
+// FileError indicates an error in a configuration file.
+type FileError struct {
+	Path string
+	Err  error
+}
+
+func (e *FileError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Path, e.Err)
+}
+
+// ErrUnknownSetting indicates the setting key is not known.
+var ErrUnknownSetting = errors.New("Unknown setting")
+
+// ErrInvalidSettingValue indicates the setting value has invalid type or format.
+var ErrInvalidSettingValue = errors.New("Invalid setting value")
+
+// ErrNoSuchLayer indicates no configuration layer with such name.
+var ErrNoSuchLayer = errors.New("No such configuration layer")
+
+const (
+	kindString = iota
+	kindStrings
+	kindInt
+	kindBool
+	kindDuration
+)
+
+type setting struct {
+	key   string
+	kind  int
+	field func(config *Config) interface{}
+}
+
+var settings = []setting{
+	{"default_language", kindString, func(c *Config) interface{} { return &c.DefaultLanguage }},
+	{"author", kindString, func(c *Config) interface{} { return &c.Author }},
+	{"library_paths", kindStrings, func(c *Config) interface{} { return &c.LibraryPaths }},
+	{"timeout", kindDuration, func(c *Config) interface{} { return &c.Timeout }},
+	{"checker", kindString, func(c *Config) interface{} { return &c.Checker }},
+	{"jobs", kindInt, func(c *Config) interface{} { return &c.Jobs }},
+	{"colors", kindBool, func(c *Config) interface{} { return &c.Colors }},
+	{"templates", kindString, func(c *Config) interface{} { return &c.Templates }},
+	{"keep_outputs", kindBool, func(c *Config) interface{} { return &c.KeepOutputs }},
+	{"preferred_languages", kindStrings, func(c *Config) interface{} { return &c.PreferredLanguages }},
+}
+
+// Default returns the default configuration.
+func Default() Config {
+	return Config{
+		Timeout: 10 * time.Second,
+		Jobs:    1,
+		Colors:  true,
+		sources: make(map[string]string),
+	}
+}
+
+// Keys returns all known setting keys.
+func Keys() []string {
+	keys := make([]string, 0, len(settings))
+	for _, s := range settings {
+		keys = append(keys, s.key)
+	}
+	return keys
+}
+
+// EnvironmentVariable returns the name of environment variable that overrides a setting.
+func EnvironmentVariable(key string) string {
+	return "CPTOOL_" + strings.ToUpper(key)
+}
+
+// Load loads configuration from layers and environment variables. The layers are ordered by their priority, the first layer has
+// the highest priority. Environment variables have higher priority than every layer. Missing configuration files are ignored,
+// but a malformed configuration file or invalid environment variable returns an error. Relative library paths in a configuration
+// file are resolved against the file's directory.
+func Load(fs afero.Fs, layers []Layer, environ []string) (Config, error) {
+	config := Default()
+	for i := len(layers) - 1; i >= 0; i-- {
+		values, err := readFile(fs, layers[i].Path)
+		if err != nil {
+			return config, err
+		}
+		for key, value := range values {
+			setting, _ := findSetting(key)
+			if err := setting.assign(&config, value); err != nil {
+				return config, &FileError{Path: layers[i].Path, Err: err}
+			}
+			config.sources[key] = layers[i].Path
+		}
+		if _, ok := values["library_paths"]; ok {
+			config.LibraryPaths = resolvePaths(path.Dir(layers[i].Path), config.LibraryPaths)
+		}
+	}
+
+	environment := make(map[string]string)
+	for _, env := range environ {
+		if parts := strings.SplitN(env, "=", 2); len(parts) == 2 {
+			environment[parts[0]] = parts[1]
+		}
+	}
+	for _, setting := range settings {
+		name := EnvironmentVariable(setting.key)
+		value, ok := environment[name]
+		if !ok {
+			continue
+		}
+		parsed, err := setting.parse(value)
+		if err != nil {
+			return config, &FileError{Path: "$" + name, Err: err}
+		}
+		if err := setting.assign(&config, parsed); err != nil {
+			return config, &FileError{Path: "$" + name, Err: err}
+		}
+		config.sources[setting.key] = "$" + name
+	}
+	return config, nil
+}
+
+// Validate checks a configuration file. It returns nil when the file is valid or doesn't exist.
+func Validate(fs afero.Fs, filePath string) error {
+	values, err := readFile(fs, filePath)
+	if err != nil {
+		return err
+	}
+	config := Default()
+	for key, value := range values {
+		setting, _ := findSetting(key)
+		if err := setting.assign(&config, value); err != nil {
+			return &FileError{Path: filePath, Err: err}
+		}
+	}
+	return nil
+}
+
+// Set sets a setting in a configuration file. The configuration file is created if not exists, and other settings in the file
+// are preserved.
+func Set(fs afero.Fs, filePath string, key string, value string) error {
+	setting, err := findSetting(key)
+	if err != nil {
+		return err
+	}
+	parsed, err := setting.parse(value)
+	if err != nil {
+		return err
+	}
+
+	values, err := readFile(fs, filePath)
+	if err != nil {
+		return err
+	}
+	if values == nil {
+		values = make(map[string]interface{})
+	}
+	values[key] = parsed
+
+	if err := fs.MkdirAll(path.Dir(filePath), os.ModePerm); err != nil {
+		return err
+	}
+	file, err := fs.Create(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return toml.NewEncoder(file).Encode(values)
+}
+
+// Get returns the value of a setting and its source. ErrUnknownSetting returned when no setting with such key.
+func (config Config) Get(key string) (Value, error) {
+	setting, err := findSetting(key)
+	if err != nil {
+		return Value{}, err
+	}
+	source, ok := config.sources[key]
+	if !ok {
+		source = "default"
+	}
+	return Value{
+		Key:    key,
+		Value:  setting.format(&config),
+		Source: source,
+	}, nil
+}
+
+// Values returns every setting's value and its source.
+func (config Config) Values() []Value {
+	values := make([]Value, 0, len(settings))
+	for _, setting := range settings {
+		value, _ := config.Get(setting.key)
+		values = append(values, value)
+	}
+	return values
+}
+
+func findSetting(key string) (setting, error) {
+	for _, s := range settings {
+		if s.key == key {
+			return s, nil
+		}
+	}
+	return setting{}, ErrUnknownSetting
+}
+
+// readFile returns settings in a configuration file. It returns nil when the file doesn't exist.
+func readFile(fs afero.Fs, filePath string) (map[string]interface{}, error) {
+	info, err := fs.Stat(filePath)
+	if err != nil || info.IsDir() {
+		return nil, nil
+	}
+	file, err := fs.Open(filePath)
+	if err != nil {
+		return nil, &FileError{Path: filePath, Err: err}
+	}
+	defer file.Close()
+
+	values := make(map[string]interface{})
+	if _, err := toml.DecodeReader(file, &values); err != nil {
+		return nil, &FileError{Path: filePath, Err: err}
+	}
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if _, err := findSetting(key); err != nil {
+			return nil, &FileError{Path: filePath, Err: fmt.Errorf("%v: %s", err, key)}
+		}
+	}
+	return values, nil
+}
+
+// resolvePaths returns the paths with the relative ones joined to the directory.
+func resolvePaths(directory string, paths []string) []string {
+	result := make([]string, 0, len(paths))
+	for _, p := range paths {
+		if !path.IsAbs(p) {
+			p = path.Join(directory, p)
+		}
+		result = append(result, p)
+	}
+	return result
+}
+
+// parse parses setting's value from a string, like the value of environment variable.
+func (s setting) parse(value string) (interface{}, error) {
+	switch s.kind {
+	case kindStrings:
+		if len(value) == 0 {
+			return []interface{}{}, nil
+		}
+		result := make([]interface{}, 0)
+		for _, item := range strings.Split(value, string(os.PathListSeparator)) {
+			result = append(result, item)
+		}
+		return result, nil
+	case kindInt:
+		result, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, ErrInvalidSettingValue
+		}
+		return result, nil
+	case kindBool:
+		result, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, ErrInvalidSettingValue
+		}
+		return result, nil
+	case kindDuration:
+		if _, err := time.ParseDuration(value); err != nil {
+			return nil, ErrInvalidSettingValue
+		}
+	}
+	return value, nil
+}
+
+// assign assigns value decoded from toml to the setting's field in config.
+func (s setting) assign(config *Config, value interface{}) error {
+	invalid := fmt.Errorf("%v: %s", ErrInvalidSettingValue, s.key)
+	switch field := s.field(config).(type) {
+	case *string:
+		str, ok := value.(string)
+		if !ok {
+			return invalid
+		}
+		*field = str
+	case *[]string:
+		items, ok := value.([]interface{})
+		if !ok {
+			return invalid
+		}
+		result := make([]string, 0, len(items))
+		for _, item := range items {
+			str, ok := item.(string)
+			if !ok {
+				return invalid
+			}
+			result = append(result, str)
+		}
+		*field = result
+	case *int:
+		number, ok := value.(int64)
+		if !ok || number < 0 {
+			return invalid
+		}
+		*field = int(number)
+	case *bool:
+		boolean, ok := value.(bool)
+		if !ok {
+			return invalid
+		}
+		*field = boolean
+	case *time.Duration:
+		str, ok := value.(string)
+		if !ok {
+			return invalid
+		}
+		duration, err := time.ParseDuration(str)
+		if err != nil {
+			return invalid
+		}
+		*field = duration
+	}
+	return nil
+}
+
+func (s setting) format(config *Config) string {
+	switch field := s.field(config).(type) {
+	case *string:
+		return *field
+	case *[]string:
+		return strings.Join(*field, string(os.PathListSeparator))
+	case *int:
+		return strconv.Itoa(*field)
+	case *bool:
+		return strconv.FormatBool(*field)
+	case *time.Duration:
+		return field.String()
+	}
+	return ""
+}
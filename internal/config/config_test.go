@@ -0,0 +1,137 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+func prepareConfigTest(files map[string]string) afero.Fs {
+	fs := afero.NewMemMapFs()
+	for filePath, content := range files {
+		afero.WriteFile(fs, filePath, []byte(content), 0644)
+	}
+	return fs
+}
+
+var testLayers = []Layer{
+	{Name: "project", Path: "/project/.cptool/config"},
+	{Name: "user", Path: "/home/test/.cptool/config"},
+}
+
+func TestLoadDefault(t *testing.T) {
+	config, err := Load(afero.NewMemMapFs(), testLayers, nil)
+	if err != nil {
+		t.Error(err)
+	}
+	if config.Timeout != 10*time.Second || config.Jobs != 1 || !config.Colors {
+		t.Error("Load should return default config when there is no configuration file, found:", config)
+	}
+	value, _ := config.Get("jobs")
+	if value.Value != "1" || value.Source != "default" {
+		t.Error("jobs should be 1 from default, found:", value)
+	}
+}
+
+func TestLoadLayers(t *testing.T) {
+	fs := prepareConfigTest(map[string]string{
+		"/project/.cptool/config":   "jobs = 4\n",
+		"/home/test/.cptool/config": "jobs = 2\nauthor = \"someone\"\nlibrary_paths = [\"/lib\"]\ntimeout = \"1m\"\n",
+	})
+	config, err := Load(fs, testLayers, nil)
+	if err != nil {
+		t.Error(err)
+	}
+	if config.Jobs != 4 {
+		t.Error("project layer should override user layer, found:", config.Jobs)
+	}
+	if config.Author != "someone" || len(config.LibraryPaths) != 1 || config.LibraryPaths[0] != "/lib" {
+		t.Error("Load should merge settings from every layer, found:", config)
+	}
+	if config.Timeout != time.Minute {
+		t.Error("timeout should be parsed as duration, found:", config.Timeout)
+	}
+	value, _ := config.Get("jobs")
+	if value.Source != "/project/.cptool/config" {
+		t.Error("jobs should come from project layer, found:", value.Source)
+	}
+}
+
+func TestLoadRelativeLibraryPaths(t *testing.T) {
+	fs := prepareConfigTest(map[string]string{
+		"/home/test/.cptool/config": "library_paths = [\"cplib\", \"../lib\", \"/abs\"]\n",
+	})
+	config, err := Load(fs, testLayers, nil)
+	if err != nil {
+		t.Error(err)
+	}
+	expected := []string{"/home/test/.cptool/cplib", "/home/test/lib", "/abs"}
+	if strings.Join(config.LibraryPaths, ",") != strings.Join(expected, ",") {
+		t.Error("relative library paths should be resolved against the configuration file, found:", config.LibraryPaths)
+	}
+
+	config, _ = Load(fs, testLayers, []string{"CPTOOL_LIBRARY_PATHS=cplib"})
+	if len(config.LibraryPaths) != 1 || config.LibraryPaths[0] != "cplib" {
+		t.Error("library paths from environment variable should be kept, found:", config.LibraryPaths)
+	}
+}
+
+func TestLoadEnvironment(t *testing.T) {
+	fs := prepareConfigTest(map[string]string{
+		"/project/.cptool/config": "colors = true\n",
+	})
+	config, err := Load(fs, testLayers, []string{"CPTOOL_COLORS=false", "HOME=/home/test"})
+	if err != nil {
+		t.Error(err)
+	}
+	value, _ := config.Get("colors")
+	if config.Colors || value.Source != "$CPTOOL_COLORS" {
+		t.Error("environment variable should override configuration files, found:", value)
+	}
+
+	_, err = Load(fs, testLayers, []string{"CPTOOL_JOBS=many"})
+	if err == nil || !strings.Contains(err.Error(), "CPTOOL_JOBS") {
+		t.Error("Load should return error on invalid environment variable, found:", err)
+	}
+}
+
+func TestLoadInvalidFile(t *testing.T) {
+	for _, content := range []string{"jobs = ", "unknown = 1\n", "jobs = \"four\"\n", "timeout = \"forever\"\n"} {
+		fs := prepareConfigTest(map[string]string{"/home/test/.cptool/config": content})
+		_, err := Load(fs, testLayers, nil)
+		if _, ok := err.(*FileError); !ok {
+			t.Errorf("Load should return FileError on %q, found: %v", content, err)
+		}
+		if err := Validate(fs, "/home/test/.cptool/config"); err == nil {
+			t.Errorf("Validate should return error on %q", content)
+		}
+	}
+}
+
+func TestSet(t *testing.T) {
+	fs := prepareConfigTest(map[string]string{
+		"/home/test/.cptool/config": "author = \"someone\"\n",
+	})
+	if err := Set(fs, "/home/test/.cptool/config", "jobs", "3"); err != nil {
+		t.Error(err)
+	}
+	if err := Set(fs, "/home/test/.cptool/config", "library_paths", "/a"); err != nil {
+		t.Error(err)
+	}
+	config, err := Load(fs, testLayers, nil)
+	if err != nil {
+		t.Error(err)
+	}
+	if config.Jobs != 3 || config.Author != "someone" || len(config.LibraryPaths) != 1 {
+		t.Error("Set should write the setting and preserve the others, found:", config)
+	}
+
+	if err := Set(fs, "/home/test/.cptool/config", "unknown", "1"); err != ErrUnknownSetting {
+		t.Error("Set should return ErrUnknownSetting, found:", err)
+	}
+	if err := Set(fs, "/home/test/.cptool/config", "colors", "maybe"); err != ErrInvalidSettingValue {
+		t.Error("Set should return ErrInvalidSettingValue, found:", err)
+	}
+}
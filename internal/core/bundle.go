@@ -0,0 +1,238 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/jauhararifin/cptool/internal/logger"
+	"github.com/spf13/afero"
+)
+
+var (
+	includeDirective = regexp.MustCompile(`^\s*#\s*include\s*"([^"]+)"`)
+	pragmaOnce       = regexp.MustCompile(`^\s*#\s*pragma\s+once\b`)
+	ifDirective      = regexp.MustCompile(`^\s*#\s*(if|ifdef|ifndef)\b`)
+	ifdefLocal       = regexp.MustCompile(`^\s*#\s*ifdef\s+LOCAL\b`)
+	ifndefLocal      = regexp.MustCompile(`^\s*#\s*ifndef\s+LOCAL\b`)
+	elseDirective    = regexp.MustCompile(`^\s*#\s*else\b`)
+	endifDirective   = regexp.MustCompile(`^\s*#\s*endif\b`)
+	guardIfndef      = regexp.MustCompile(`^\s*#\s*ifndef\s+(\w+)`)
+	guardDefine      = regexp.MustCompile(`^\s*#\s*define\s+(\w+)\s*$`)
+)
+
+type bundler struct {
+	cptool       *CPTool
+	libraryPaths []string
+	stripLocal   bool
+	included     map[string]bool
+	guards       map[string]bool
+	output       bytes.Buffer
+}
+
+type conditionalBlock struct {
+	local bool
+	skip  bool
+}
+
+// Bundle creates a single file solution by inlining the local includes of the solution. Every quoted include like
+// `#include "lib/segtree.h"` is searched relative to the including file, then in libraryPaths, and then in "library_paths"
+// setting in config. Every included file is inlined only once, `#pragma once` lines are removed and a file whose include
+// guard is already defined is skipped. Includes that cannot be found are left untouched. When stripLocal is true, the
+// `#ifdef LOCAL` blocks are removed (and the `#else` branch of `#ifndef LOCAL` blocks). The bundled solution is written to
+// bundle directory and returned as a new Solution with the same language.
+func (cptool *CPTool) Bundle(solution Solution, stripLocal bool, libraryPaths []string) (Solution, error) {
+	candidates := make([]string, 0, len(libraryPaths)+len(cptool.config.LibraryPaths))
+	candidates = append(candidates, libraryPaths...)
+	candidates = append(candidates, cptool.config.LibraryPaths...)
+	paths := make([]string, 0)
+	for _, libraryPath := range candidates {
+		if !filepath.IsAbs(libraryPath) {
+			libraryPath = path.Join(cptool.workingDirectory, libraryPath)
+		}
+		paths = appendPaths(paths, libraryPath)
+	}
+
+	b := &bundler{
+		cptool:       cptool,
+		libraryPaths: paths,
+		stripLocal:   stripLocal,
+		included:     make(map[string]bool),
+		guards:       make(map[string]bool),
+	}
+	if err := b.inline(solution.Path); err != nil {
+		return Solution{}, err
+	}
+
+	bundlePath := cptool.getBundleTarget(solution)
+	if cptool.logger != nil {
+		cptool.logger.Println(logger.VERBOSE, "Writing bundle to: ", bundlePath)
+	}
+	if err := cptool.fs.MkdirAll(path.Dir(bundlePath), os.ModePerm); err != nil {
+		return Solution{}, err
+	}
+	// the bundle is only rewritten when changed, so the compiled bundle stays up to date.
+	if existing, err := afero.ReadFile(cptool.fs, bundlePath); err != nil || !bytes.Equal(existing, b.output.Bytes()) {
+		if err := afero.WriteFile(cptool.fs, bundlePath, b.output.Bytes(), 0644); err != nil {
+			return Solution{}, err
+		}
+	}
+
+	info, err := cptool.fs.Stat(bundlePath)
+	if err != nil {
+		return Solution{}, err
+	}
+	return Solution{
+		Name:        solution.Name + ".bundle",
+		Language:    solution.Language,
+		Path:        bundlePath,
+		LastUpdated: info.ModTime(),
+	}, nil
+}
+
+// GetBundleRootDir returns directory of all bundled solutions.
+func (cptool *CPTool) GetBundleRootDir() string {
+	return path.Join(cptool.GetProjectRoot(), ".cptool/bundles")
+}
+
+func (cptool *CPTool) getBundleTarget(solution Solution) string {
+	return path.Join(cptool.GetBundleRootDir(), cptool.getSolutionNamespace(solution), solution.Name+"."+solution.Language.Extension)
+}
+
+func (b *bundler) resolveInclude(currentFile string, name string) string {
+	candidates := []string{path.Join(path.Dir(currentFile), name)}
+	for _, libraryPath := range b.libraryPaths {
+		candidates = append(candidates, path.Join(libraryPath, name))
+	}
+	for _, candidate := range candidates {
+		if info, err := b.cptool.fs.Stat(candidate); err == nil && !info.IsDir() {
+			return path.Clean(candidate)
+		}
+	}
+	return ""
+}
+
+func (b *bundler) inline(filePath string) error {
+	b.included[filePath] = true
+	content, err := afero.ReadFile(b.cptool.fs, filePath)
+	if err != nil {
+		return err
+	}
+
+	lines := make([]string, 0)
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(content)+1)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	if guard := includeGuard(lines); len(guard) > 0 {
+		if b.guards[guard] {
+			return nil
+		}
+		b.guards[guard] = true
+	}
+
+	blocks := make([]conditionalBlock, 0)
+	skipping := func() bool {
+		for _, block := range blocks {
+			if block.local && block.skip {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, line := range lines {
+		if b.stripLocal {
+			switch {
+			case ifdefLocal.MatchString(line):
+				blocks = append(blocks, conditionalBlock{local: true, skip: true})
+				continue
+			case ifndefLocal.MatchString(line):
+				blocks = append(blocks, conditionalBlock{local: true, skip: false})
+				continue
+			case ifDirective.MatchString(line):
+				blocks = append(blocks, conditionalBlock{})
+			case elseDirective.MatchString(line) && len(blocks) > 0 && blocks[len(blocks)-1].local:
+				blocks[len(blocks)-1].skip = !blocks[len(blocks)-1].skip
+				continue
+			case endifDirective.MatchString(line) && len(blocks) > 0:
+				block := blocks[len(blocks)-1]
+				if block.local {
+					blocks = blocks[:len(blocks)-1]
+					continue
+				}
+				skip := skipping()
+				blocks = blocks[:len(blocks)-1]
+				if !skip {
+					b.output.WriteString(line + "\n")
+				}
+				continue
+			}
+			if skipping() {
+				continue
+			}
+		}
+
+		if pragmaOnce.MatchString(line) {
+			continue
+		}
+
+		if match := includeDirective.FindStringSubmatch(line); match != nil {
+			includePath := b.resolveInclude(filePath, match[1])
+			if len(includePath) == 0 {
+				if b.cptool.logger != nil {
+					b.cptool.logger.PrintWarning("Cannot find included file: ", match[1])
+				}
+				b.output.WriteString(line + "\n")
+				continue
+			}
+			if b.included[includePath] {
+				continue
+			}
+			if b.cptool.logger != nil {
+				b.cptool.logger.Println(logger.VERBOSE, "Inlining included file: ", includePath)
+			}
+			if err := b.inline(includePath); err != nil {
+				return err
+			}
+			continue
+		}
+
+		b.output.WriteString(line + "\n")
+	}
+	return nil
+}
+
+// includeGuard returns the macro name of the file's include guard. A file has include guard when its first directive is
+// `#ifndef NAME` followed by `#define NAME`.
+func includeGuard(lines []string) string {
+	directives := make([]string, 0, 2)
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if len(trimmed) == 0 || strings.HasPrefix(trimmed, "//") {
+			continue
+		}
+		directives = append(directives, line)
+		if len(directives) == 2 {
+			break
+		}
+	}
+	if len(directives) < 2 {
+		return ""
+	}
+	ifndef := guardIfndef.FindStringSubmatch(directives[0])
+	define := guardDefine.FindStringSubmatch(directives[1])
+	if ifndef == nil || define == nil || ifndef[1] != define[1] {
+		return ""
+	}
+	return ifndef[1]
+}
@@ -0,0 +1,150 @@
+package core
+
+import (
+	"os"
+	"path"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+var bundleTestLanguage = Language{
+	Name:      "cpp",
+	Extension: "cpp",
+}
+
+func prepareBundleTest(cptool *CPTool, files map[string]string) Solution {
+	for filePath, content := range files {
+		cptool.fs.MkdirAll(path.Dir(filePath), os.ModePerm)
+		afero.WriteFile(cptool.fs, filePath, []byte(content), 0644)
+	}
+	return Solution{
+		Name:        "sol",
+		Language:    bundleTestLanguage,
+		Path:        path.Join(cptool.workingDirectory, "sol.cpp"),
+		LastUpdated: time.Now(),
+	}
+}
+
+func TestBundle(t *testing.T) {
+	cptool := newTest()
+	solution := prepareBundleTest(cptool, map[string]string{
+		path.Join(cptool.workingDirectory, "sol.cpp"): "#include <iostream>\n#include \"lib/a.h\"\n#include \"lib/b.h\"\nint main() {}\n",
+		path.Join(cptool.workingDirectory, "lib/a.h"): "#pragma once\n#include \"b.h\"\nint a;\n",
+		path.Join(cptool.workingDirectory, "lib/b.h"): "#pragma once\nint b;\n",
+	})
+
+	bundle, err := cptool.Bundle(solution, false, nil)
+	if err != nil {
+		t.Error(err)
+	}
+	if bundle.Path != path.Join(cptool.GetBundleRootDir(), "sol.cpp") {
+		t.Error("Bundle should write bundle to bundle directory, found:", bundle.Path)
+	}
+	if !reflect.DeepEqual(bundle.Language, solution.Language) {
+		t.Error("bundled solution should have the same language")
+	}
+	content, _ := afero.ReadFile(cptool.fs, bundle.Path)
+	expected := "#include <iostream>\nint b;\nint a;\nint main() {}\n"
+	if string(content) != expected {
+		t.Errorf("Bundle should inline every local include once, found:\n%s", content)
+	}
+}
+
+func TestBundleWithLibraryPaths(t *testing.T) {
+	cptool := newTest()
+	solution := prepareBundleTest(cptool, map[string]string{
+		path.Join(cptool.workingDirectory, "sol.cpp"): "#include \"segtree.h\"\n#include \"modint.h\"\n",
+		"/library/segtree.h":                          "int segtree;\n",
+		"/configured/modint.h":                        "int modint;\n",
+		"/etc/cptool/config":                          "library_paths = [\"/configured\"]\n",
+	})
+	cptool.loadConfig()
+
+	libraryPaths := make([]string, 1, 2)
+	libraryPaths[0] = "/library"
+	bundle, err := cptool.Bundle(solution, false, libraryPaths)
+	if err != nil {
+		t.Error(err)
+	}
+	content, _ := afero.ReadFile(cptool.fs, bundle.Path)
+	if string(content) != "int segtree;\nint modint;\n" {
+		t.Errorf("Bundle should search includes in library paths, found:\n%s", content)
+	}
+	if extra := libraryPaths[:2][1]; extra != "" {
+		t.Error("Bundle shouldn't modify the library paths, found:", extra)
+	}
+}
+
+func TestBundleWithIncludeGuard(t *testing.T) {
+	cptool := newTest()
+	solution := prepareBundleTest(cptool, map[string]string{
+		path.Join(cptool.workingDirectory, "sol.cpp"): "#include \"a/lib.h\"\n#include \"b/lib.h\"\n",
+		path.Join(cptool.workingDirectory, "a/lib.h"): "#ifndef LIB_H\n#define LIB_H\nint lib;\n#endif\n",
+		path.Join(cptool.workingDirectory, "b/lib.h"): "// copy of a/lib.h\n#ifndef LIB_H\n#define LIB_H\nint lib;\n#endif\n",
+	})
+
+	bundle, err := cptool.Bundle(solution, false, nil)
+	if err != nil {
+		t.Error(err)
+	}
+	content, _ := afero.ReadFile(cptool.fs, bundle.Path)
+	if string(content) != "#ifndef LIB_H\n#define LIB_H\nint lib;\n#endif\n" {
+		t.Errorf("Bundle should skip file with already defined include guard, found:\n%s", content)
+	}
+}
+
+func TestBundleWithMissingInclude(t *testing.T) {
+	cptool := newTest()
+	solution := prepareBundleTest(cptool, map[string]string{
+		path.Join(cptool.workingDirectory, "sol.cpp"): "#include \"missing.h\"\n",
+	})
+
+	bundle, err := cptool.Bundle(solution, false, nil)
+	if err != nil {
+		t.Error(err)
+	}
+	content, _ := afero.ReadFile(cptool.fs, bundle.Path)
+	if string(content) != "#include \"missing.h\"\n" {
+		t.Errorf("Bundle should keep include that cannot be found, found:\n%s", content)
+	}
+}
+
+func TestBundleWithStripLocal(t *testing.T) {
+	cptool := newTest()
+	solution := prepareBundleTest(cptool, map[string]string{
+		path.Join(cptool.workingDirectory, "sol.cpp"): "#ifdef LOCAL\n#include \"debug.h\"\n#ifdef X\nint x;\n#endif\n#else\n#define debug(...)\n#endif\n" +
+			"#ifndef LOCAL\nint judge;\n#else\nint local;\n#endif\n#ifdef X\nint y;\n#else\nint z;\n#endif\n",
+		path.Join(cptool.workingDirectory, "debug.h"): "int debug;\n",
+	})
+
+	bundle, err := cptool.Bundle(solution, true, nil)
+	if err != nil {
+		t.Error(err)
+	}
+	content, _ := afero.ReadFile(cptool.fs, bundle.Path)
+	expected := "#define debug(...)\nint judge;\n#ifdef X\nint y;\n#else\nint z;\n#endif\n"
+	if string(content) != expected {
+		t.Errorf("Bundle should strip LOCAL blocks, found:\n%s", content)
+	}
+}
+
+func TestBundleNotRewritten(t *testing.T) {
+	cptool := newTest()
+	solution := prepareBundleTest(cptool, map[string]string{
+		path.Join(cptool.workingDirectory, "sol.cpp"): "int main() {}\n",
+	})
+
+	bundle, _ := cptool.Bundle(solution, false, nil)
+	past := time.Now().Add(-time.Hour)
+	cptool.fs.Chtimes(bundle.Path, past, past)
+	bundle, err := cptool.Bundle(solution, false, nil)
+	if err != nil {
+		t.Error(err)
+	}
+	if !bundle.LastUpdated.Equal(past) {
+		t.Error("Bundle should not rewrite unchanged bundle")
+	}
+}
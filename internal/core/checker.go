@@ -0,0 +1,93 @@
+package core
+
+import (
+	"io"
+)
+
+// outputChecker compares program's output with the expected output while the program is still running. Every byte written
+// to the checker is compared to the next bytes of the expected output. The comparison stops at the first mismatch, after that
+// the checker only forwards the remaining output to the writer returned by onMismatch (if any), so that the failed output can
+// be persisted without writing the output of every test case to disk.
+type outputChecker struct {
+	expected   io.Reader
+	buffer     []byte
+	compared   int64
+	mismatch   bool
+	sink       io.Writer
+	onMismatch func(compared int64) (io.Writer, error)
+}
+
+func newOutputChecker(expected io.Reader, onMismatch func(compared int64) (io.Writer, error)) *outputChecker {
+	return &outputChecker{
+		expected:   expected,
+		onMismatch: onMismatch,
+	}
+}
+
+// Write implements io.Writer. It never returns a short write, even when the output differs from the expected output.
+func (checker *outputChecker) Write(p []byte) (int, error) {
+	if checker.mismatch {
+		if checker.sink != nil {
+			return checker.sink.Write(p)
+		}
+		return len(p), nil
+	}
+
+	if cap(checker.buffer) < len(p) {
+		checker.buffer = make([]byte, len(p))
+	}
+	buffer := checker.buffer[:len(p)]
+	n, err := io.ReadFull(checker.expected, buffer)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return 0, err
+	}
+
+	same := 0
+	for same < n && buffer[same] == p[same] {
+		same++
+	}
+	checker.compared += int64(same)
+	if same == len(p) {
+		return len(p), nil
+	}
+
+	if err := checker.markMismatch(); err != nil {
+		return 0, err
+	}
+	if checker.sink != nil {
+		if _, err := checker.sink.Write(p[same:]); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// Finish reports whether the whole output is equal to the expected output. It must be called after the program has exited,
+// because the expected output may still have bytes that were never written by the program.
+func (checker *outputChecker) Finish() (bool, error) {
+	if checker.mismatch {
+		return false, nil
+	}
+	var remaining [1]byte
+	_, err := io.ReadFull(checker.expected, remaining[:])
+	if err == io.EOF {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return false, checker.markMismatch()
+}
+
+func (checker *outputChecker) markMismatch() error {
+	checker.mismatch = true
+	if checker.onMismatch == nil {
+		return nil
+	}
+	sink, err := checker.onMismatch(checker.compared)
+	if err != nil {
+		return err
+	}
+	checker.sink = sink
+	return nil
+}
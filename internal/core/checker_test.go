@@ -0,0 +1,78 @@
+package core
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestOutputChecker(t *testing.T) {
+	checker := newOutputChecker(strings.NewReader("1 2 3\n4 5 6\n"), nil)
+	checker.Write([]byte("1 2 3\n"))
+	checker.Write([]byte("4 5 "))
+	checker.Write([]byte("6\n"))
+	same, err := checker.Finish()
+	if err != nil {
+		t.Error(err)
+	}
+	if !same {
+		t.Error("outputChecker should report same output")
+	}
+}
+
+func TestOutputCheckerWithDifferentOutput(t *testing.T) {
+	persisted := new(bytes.Buffer)
+	var mismatchAt int64 = -1
+	checker := newOutputChecker(strings.NewReader("1 2 3\n4 5 6\n"), func(compared int64) (io.Writer, error) {
+		mismatchAt = compared
+		return persisted, nil
+	})
+	checker.Write([]byte("1 2 3\n"))
+	checker.Write([]byte("4 7 "))
+	checker.Write([]byte("6\n"))
+	same, err := checker.Finish()
+	if err != nil {
+		t.Error(err)
+	}
+	if same {
+		t.Error("outputChecker should report different output")
+	}
+	if mismatchAt != 8 {
+		t.Error("outputChecker should find mismatch at byte 8, found:", mismatchAt)
+	}
+	if persisted.String() != "7 6\n" {
+		t.Error("outputChecker should forward output after the first mismatch, found:", persisted.String())
+	}
+}
+
+func TestOutputCheckerWithShorterOutput(t *testing.T) {
+	mismatchAt := int64(-1)
+	checker := newOutputChecker(strings.NewReader("1 2 3\n"), func(compared int64) (io.Writer, error) {
+		mismatchAt = compared
+		return nil, nil
+	})
+	checker.Write([]byte("1 2"))
+	same, err := checker.Finish()
+	if err != nil {
+		t.Error(err)
+	}
+	if same {
+		t.Error("outputChecker should report different output")
+	}
+	if mismatchAt != 3 {
+		t.Error("outputChecker should find mismatch at byte 3, found:", mismatchAt)
+	}
+}
+
+func TestOutputCheckerWithLongerOutput(t *testing.T) {
+	checker := newOutputChecker(strings.NewReader("1 2"), nil)
+	checker.Write([]byte("1 2 3\n"))
+	same, err := checker.Finish()
+	if err != nil {
+		t.Error(err)
+	}
+	if same {
+		t.Error("outputChecker should report different output")
+	}
+}
@@ -5,7 +5,7 @@ import (
 	"github.com/spf13/afero"
 )
 
-// CleanCacheDirectory clean cptool cache directory that contains compiled solution and output.
+// CleanCacheDirectory clean cptool cache directory that contains compiled solution, output and bundled solution.
 func (cptool *CPTool) CleanCacheDirectory() error {
 	compileDir := cptool.GetCompilationRootDir()
 	if ok, err := afero.DirExists(cptool.fs, compileDir); ok && err == nil {
@@ -27,5 +27,15 @@ func (cptool *CPTool) CleanCacheDirectory() error {
 			return err
 		}
 	}
+	bundleDir := cptool.GetBundleRootDir()
+	if ok, err := afero.DirExists(cptool.fs, bundleDir); ok && err == nil {
+		if cptool.logger != nil {
+			cptool.logger.Println(logger.VERBOSE, "Removing bundled solution: ", bundleDir)
+		}
+		err := cptool.fs.RemoveAll(bundleDir)
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
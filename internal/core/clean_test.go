@@ -11,6 +11,7 @@ func TestCleanCacheDirectory(t *testing.T) {
 	cptool := newTest()
 	cptool.fs.MkdirAll(cptool.GetCompilationRootDir(), os.ModePerm)
 	cptool.fs.MkdirAll(cptool.GetOutputRootDir(), os.ModePerm)
+	cptool.fs.MkdirAll(cptool.GetBundleRootDir(), os.ModePerm)
 	err := cptool.CleanCacheDirectory()
 	if err != nil {
 		t.Error(err)
@@ -21,6 +22,9 @@ func TestCleanCacheDirectory(t *testing.T) {
 	if ok, _ := afero.DirExists(cptool.fs, cptool.GetOutputRootDir()); ok {
 		t.Error("CleanCacheDirectory should remove output directory")
 	}
+	if ok, _ := afero.DirExists(cptool.fs, cptool.GetBundleRootDir()); ok {
+		t.Error("CleanCacheDirectory should remove bundle directory")
+	}
 }
 
 func TestCleanCacheDirectoryAlreadyEmpty(t *testing.T) {
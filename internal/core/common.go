@@ -1,22 +1,70 @@
 package core
 
 import (
+	"crypto/sha1"
+	"encoding/hex"
 	"path"
+	"path/filepath"
+	"strings"
 )
 
 // GetConfigurationPaths returns all paths to the directory that considered contain cptool configuration.
 // This directory contains language definition, and some configuration like default language. The paths that
 // considered are "/etc/cptool", "~/.cptool", your $CPTOOL_HOME directory and .cptool directory in your
-// current working directory.
+// project root.
 func (cptool *CPTool) GetConfigurationPaths() []string {
 	paths := make([]string, 0)
-	paths = appendPaths(paths, path.Join(cptool.workingDirectory, ".cptool"))
+	paths = appendPaths(paths, path.Join(cptool.GetProjectRoot(), ".cptool"))
 	paths = appendPaths(paths, cptool.cptoolHomeDirectory)
 	paths = appendPaths(paths, path.Join(cptool.homeDirectory, ".cptool"))
 	paths = appendPaths(paths, "/etc/cptool/")
 	return paths
 }
 
+// GetProjectRoot returns the root directory of current project. The project root is the nearest directory, starting from
+// current working directory and walking up to its parents, that contains .cptool directory. The project's configuration,
+// languages, templates, compiled solutions and test outputs are stored in the .cptool directory of the project root, so
+// cptool can be used from any subdirectory of the project. Current working directory is the project root when no such
+// directory found.
+func (cptool *CPTool) GetProjectRoot() string {
+	if len(cptool.projectRoot) == 0 {
+		return cptool.workingDirectory
+	}
+	return cptool.projectRoot
+}
+
+// findProjectRoot walks up from current working directory to find the nearest directory that contains .cptool directory.
+// The user's .cptool directory and $CPTOOL_HOME directory are not considered as project, otherwise every directory inside
+// home directory would belong to the same project.
+func (cptool *CPTool) findProjectRoot() string {
+	excluded := []string{path.Join(cptool.homeDirectory, ".cptool")}
+	if len(cptool.cptoolHomeDirectory) > 0 {
+		excluded = append(excluded, path.Clean(cptool.cptoolHomeDirectory))
+	}
+
+	directory := path.Clean(cptool.workingDirectory)
+	for {
+		candidate := path.Join(directory, ".cptool")
+		if info, err := cptool.fs.Stat(candidate); err == nil && info.IsDir() && !containsPath(excluded, candidate) {
+			return directory
+		}
+		parent := path.Dir(directory)
+		if parent == directory {
+			return cptool.workingDirectory
+		}
+		directory = parent
+	}
+}
+
+func containsPath(paths []string, p string) bool {
+	for _, item := range paths {
+		if item == p {
+			return true
+		}
+	}
+	return false
+}
+
 func appendPaths(paths []string, path string) []string {
 	for _, p := range paths {
 		if p == path {
@@ -25,3 +73,42 @@ func appendPaths(paths []string, path string) []string {
 	}
 	return append(paths, path)
 }
+
+// resolvePath returns the absolute path of a path that is relative to current working directory.
+func (cptool *CPTool) resolvePath(filePath string) string {
+	if filepath.IsAbs(filePath) {
+		return path.Clean(filePath)
+	}
+	return path.Join(cptool.workingDirectory, filePath)
+}
+
+// getRelativePath returns the path relative to current working directory. It returns false when the path is outside current
+// working directory.
+func (cptool *CPTool) getRelativePath(filePath string) (string, bool) {
+	relativePath, err := filepath.Rel(cptool.workingDirectory, filePath)
+	if err != nil || relativePath == ".." || strings.HasPrefix(relativePath, "../") {
+		return "", false
+	}
+	return relativePath, true
+}
+
+// getSolutionNamespace returns the directory that namespaces the compiled programs, outputs and bundles of a solution, so
+// solutions with the same name in different directories don't collide in the project's shared cache. Solutions inside current
+// working directory are namespaced by the path of current working directory relative to the project root, because their names
+// already contain the relative directory. Other solutions inside the project root are namespaced by their directory relative to
+// the project root, and solutions outside the project root are namespaced by the hash of their directory.
+func (cptool *CPTool) getSolutionNamespace(solution Solution) string {
+	directory := path.Dir(solution.Path)
+	if _, ok := cptool.getRelativePath(directory); ok {
+		directory = cptool.workingDirectory
+	}
+	if namespace, err := filepath.Rel(cptool.GetProjectRoot(), directory); err == nil && namespace != ".." &&
+		!strings.HasPrefix(namespace, "../") {
+		if namespace == "." {
+			return ""
+		}
+		return namespace
+	}
+	hash := sha1.Sum([]byte(directory))
+	return path.Join("_external", hex.EncodeToString(hash[:])[:12])
+}
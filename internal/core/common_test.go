@@ -1,6 +1,7 @@
 package core
 
 import (
+	"os"
 	"path"
 	"testing"
 )
@@ -28,3 +29,43 @@ func TestGetConfigurationPaths(t *testing.T) {
 		t.Errorf("third path should contain /etc/cptool, found: %s", paths[2])
 	}
 }
+
+func TestFindProjectRoot(t *testing.T) {
+	cptool := newTest()
+	cptool.fs.MkdirAll(path.Join(cptool.homeDirectory, ".cptool"), os.ModePerm)
+	if root := cptool.findProjectRoot(); root != cptool.workingDirectory {
+		t.Error("project root should be current working directory when no project found, found:", root)
+	}
+
+	cptool.fs.MkdirAll(path.Join(cptool.workingDirectory, ".cptool"), os.ModePerm)
+	projectRoot := cptool.workingDirectory
+	cptool.workingDirectory = path.Join(projectRoot, "problems/A")
+	cptool.fs.MkdirAll(cptool.workingDirectory, os.ModePerm)
+	if root := cptool.findProjectRoot(); root != projectRoot {
+		t.Error("project root should be the nearest parent containing .cptool directory, found:", root)
+	}
+}
+
+func TestProjectRootSharedCache(t *testing.T) {
+	cptool := newTest()
+	cptool.projectRoot = cptool.workingDirectory
+	cptool.workingDirectory = path.Join(cptool.projectRoot, "problems/A")
+	language := Language{Name: "cpp", Extension: "cpp"}
+
+	paths := cptool.GetConfigurationPaths()
+	if paths[0] != path.Join(cptool.projectRoot, ".cptool") {
+		t.Error("first configuration path should be in the project root, found:", paths[0])
+	}
+
+	solution := Solution{Name: "sol", Language: language, Path: path.Join(cptool.workingDirectory, "sol.cpp")}
+	expected := path.Join(cptool.projectRoot, ".cptool/solutions/problems/A/sol/cpp")
+	if directory := cptool.getCompiledDirectory(solution, false); directory != expected {
+		t.Error("compiled directory should be namespaced by current directory, found:", directory)
+	}
+
+	other := Solution{Name: "sol", Language: language, Path: path.Join(cptool.projectRoot, "problems/B/sol.cpp")}
+	expected = path.Join(cptool.projectRoot, ".cptool/solutions/problems/B/sol/cpp")
+	if directory := cptool.getCompiledDirectory(other, false); directory != expected {
+		t.Error("compiled directory should be namespaced by solution directory, found:", directory)
+	}
+}
@@ -0,0 +1,155 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/jauhararifin/cptool/internal/logger"
+	"github.com/spf13/afero"
+)
+
+// CompanionProblem is the problem sent by Competitive Companion browser extension. TimeLimit is in milliseconds and
+// MemoryLimit is in megabytes.
+type CompanionProblem struct {
+	Name        string          `json:"name"`
+	Group       string          `json:"group"`
+	URL         string          `json:"url"`
+	Interactive bool            `json:"interactive"`
+	MemoryLimit int             `json:"memoryLimit"`
+	TimeLimit   int             `json:"timeLimit"`
+	Tests       []CompanionTest `json:"tests"`
+}
+
+// CompanionTest is a sample test of CompanionProblem.
+type CompanionTest struct {
+	Input  string `json:"input"`
+	Output string `json:"output"`
+}
+
+// ImportedProblem stores the result of importing a CompanionProblem. Name is the problem's name that is used as solution
+// name and test case name prefix. Directory is the directory where the test cases and problem configuration are saved.
+// Skipped contains the files that already existed, they are kept unchanged.
+type ImportedProblem struct {
+	Name      string
+	Directory string
+	TestCases []TestCase
+	Skipped   []string
+}
+
+// ErrInvalidProblem indicates the problem doesn't have a valid name
+var ErrInvalidProblem = errors.New("Invalid problem")
+
+// ImportProblem saves sample tests of a problem as test cases named "NAME.sample_1", "NAME.sample_2", and so on, where NAME
+// is the problem's name converted to lowercase and non alphanumeric characters replaced by "_". The problem's time limit and
+// memory limit are saved in "problem.toml". When createDirectory is true, the files are saved in a directory named NAME inside
+// current working directory, otherwise they are saved in current working directory. Existing files are never overwritten, they
+// are listed in Skipped instead. Since every problem imported into current working directory shares the same "problem.toml",
+// only the first problem's configuration is saved there.
+func (cptool *CPTool) ImportProblem(problem CompanionProblem, createDirectory bool) (ImportedProblem, error) {
+	name := problemSlug(problem.Name)
+	if len(name) == 0 {
+		return ImportedProblem{}, ErrInvalidProblem
+	}
+
+	directory := cptool.workingDirectory
+	if createDirectory {
+		directory = path.Join(directory, name)
+	}
+
+	if err := cptool.fs.MkdirAll(directory, os.ModePerm); err != nil {
+		return ImportedProblem{}, err
+	}
+
+	imported := ImportedProblem{
+		Name:      name,
+		Directory: directory,
+		TestCases: make([]TestCase, 0, len(problem.Tests)),
+		Skipped:   make([]string, 0),
+	}
+	for i, test := range problem.Tests {
+		testName := name + ".sample_" + strconv.Itoa(i+1)
+		testCase := TestCase{
+			Name:       testName,
+			InputPath:  path.Join(directory, testName+".in"),
+			OutputPath: path.Join(directory, testName+".out"),
+		}
+		if err := cptool.importFile(&imported, testCase.InputPath, []byte(test.Input)); err != nil {
+			return imported, err
+		}
+		if err := cptool.importFile(&imported, testCase.OutputPath, []byte(test.Output)); err != nil {
+			return imported, err
+		}
+		if cptool.logger != nil {
+			cptool.logger.Println(logger.VERBOSE, "Sample test case imported: ", testCase.InputPath)
+		}
+		imported.TestCases = append(imported.TestCases, testCase)
+	}
+
+	config := problemConfigFile{
+		Name:        problem.Name,
+		URL:         problem.URL,
+		MemoryLimit: problem.MemoryLimit,
+	}
+	if problem.TimeLimit > 0 {
+		config.TimeLimit = (time.Duration(problem.TimeLimit) * time.Millisecond).String()
+	}
+	configPath := path.Join(directory, "problem.toml")
+	if exists, _ := afero.Exists(cptool.fs, configPath); exists {
+		imported.Skipped = append(imported.Skipped, configPath)
+	} else if err := cptool.writeProblemConfig(directory, config); err != nil {
+		return imported, err
+	}
+
+	return imported, nil
+}
+
+// importFile writes the file of an imported problem, or adds it to the problem's skipped files when it already exists.
+func (cptool *CPTool) importFile(imported *ImportedProblem, filePath string, content []byte) error {
+	if exists, _ := afero.Exists(cptool.fs, filePath); exists {
+		imported.Skipped = append(imported.Skipped, filePath)
+		return nil
+	}
+	return afero.WriteFile(cptool.fs, filePath, content, 0644)
+}
+
+// NewCompanionHandler creates http handler that accepts problems sent by Competitive Companion browser extension. Every
+// received problem is passed to onProblem. The handler responds with "400 Bad Request" when the request body is not a valid
+// problem and "500 Internal Server Error" when onProblem returns an error.
+func NewCompanionHandler(onProblem func(CompanionProblem) error) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		problem := CompanionProblem{}
+		if err := json.NewDecoder(r.Body).Decode(&problem); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := onProblem(problem); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+func problemSlug(name string) string {
+	slug := strings.Map(func(r rune) rune {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return unicode.ToLower(r)
+		}
+		return '_'
+	}, name)
+	for strings.Contains(slug, "__") {
+		slug = strings.Replace(slug, "__", "_", -1)
+	}
+	return strings.Trim(slug, "_")
+}
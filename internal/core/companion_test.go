@@ -0,0 +1,195 @@
+package core
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+const companionTestPayload = `{
+	"name": "A. Sum of Two",
+	"group": "Codeforces - Round #1",
+	"url": "https://codeforces.com/contest/1/problem/A",
+	"interactive": false,
+	"memoryLimit": 256,
+	"timeLimit": 2000,
+	"tests": [
+		{"input": "1 2\n", "output": "3\n"},
+		{"input": "5 6\n", "output": "11\n"}
+	],
+	"testType": "single",
+	"input": {"type": "stdin"},
+	"output": {"type": "stdout"}
+}`
+
+func TestImportProblem(t *testing.T) {
+	cptool := newTest()
+	problem := CompanionProblem{
+		Name:        "A. Sum of Two",
+		URL:         "https://codeforces.com/contest/1/problem/A",
+		MemoryLimit: 256,
+		TimeLimit:   2000,
+		Tests:       []CompanionTest{{Input: "1 2\n", Output: "3\n"}, {Input: "5 6\n", Output: "11\n"}},
+	}
+
+	imported, err := cptool.ImportProblem(problem, false)
+	if err != nil {
+		t.Error(err)
+	}
+	if imported.Name != "a_sum_of_two" {
+		t.Error("imported problem name should be a_sum_of_two, found:", imported.Name)
+	}
+	if imported.Directory != cptool.workingDirectory {
+		t.Error("problem should be imported to current working directory")
+	}
+	testCases := cptool.getAllTestCaseWithPrefix("a_sum_of_two.sample")
+	if len(testCases) != 2 {
+		t.Error("ImportProblem should create 2 test cases")
+	}
+	content, _ := afero.ReadFile(cptool.fs, path.Join(cptool.workingDirectory, "a_sum_of_two.sample_2.out"))
+	if string(content) != "11\n" {
+		t.Error("test case output should be 11, found:", string(content))
+	}
+	config, _ := afero.ReadFile(cptool.fs, path.Join(cptool.workingDirectory, "problem.toml"))
+	if !strings.Contains(string(config), "time_limit = \"2s\"") || !strings.Contains(string(config), "memory_limit = 256") {
+		t.Error("ImportProblem should save time limit and memory limit, found:", string(config))
+	}
+}
+
+func TestImportProblemWithDirectory(t *testing.T) {
+	cptool := newTest()
+	problem := CompanionProblem{
+		Name:  "B. Another Problem",
+		Tests: []CompanionTest{{Input: "1\n", Output: "1\n"}},
+	}
+
+	imported, err := cptool.ImportProblem(problem, true)
+	if err != nil {
+		t.Error(err)
+	}
+	if imported.Directory != path.Join(cptool.workingDirectory, "b_another_problem") {
+		t.Error("problem should be imported to its own directory, found:", imported.Directory)
+	}
+	if ok, _ := afero.Exists(cptool.fs, path.Join(imported.Directory, "b_another_problem.sample_1.in")); !ok {
+		t.Error("test case should be saved in problem directory")
+	}
+	if ok, _ := afero.Exists(cptool.fs, path.Join(imported.Directory, "problem.toml")); !ok {
+		t.Error("problem configuration should be saved in problem directory")
+	}
+}
+
+func TestImportProblemWithExistingFiles(t *testing.T) {
+	cptool := newTest()
+	configPath := path.Join(cptool.workingDirectory, "problem.toml")
+	afero.WriteFile(cptool.fs, configPath, []byte("time_limit = \"1s\"\n"), 0644)
+	problem := CompanionProblem{Name: "C. Third", TimeLimit: 2000, Tests: []CompanionTest{{Input: "1\n", Output: "1\n"}}}
+
+	imported, err := cptool.ImportProblem(problem, false)
+	if err != nil {
+		t.Error(err)
+	}
+	if config, _ := afero.ReadFile(cptool.fs, configPath); string(config) != "time_limit = \"1s\"\n" {
+		t.Error("ImportProblem should not overwrite existing problem configuration, found:", string(config))
+	}
+	if ok, _ := afero.Exists(cptool.fs, path.Join(cptool.workingDirectory, "c_third.sample_1.in")); !ok {
+		t.Error("ImportProblem should save the test cases when problem configuration exists")
+	}
+	if len(imported.Skipped) != 1 || imported.Skipped[0] != configPath {
+		t.Error("ImportProblem should report the existing problem configuration, found:", imported.Skipped)
+	}
+
+	second := CompanionProblem{Name: "D. Fourth", Tests: []CompanionTest{{Input: "2\n", Output: "2\n"}}}
+	if imported, err := cptool.ImportProblem(second, false); err != nil || len(imported.TestCases) != 1 {
+		t.Error("ImportProblem should import another problem into current working directory, found:", imported, err)
+	}
+
+	inputPath := path.Join(cptool.workingDirectory, "e_fifth/e_fifth.sample_1.in")
+	afero.WriteFile(cptool.fs, inputPath, []byte("edited\n"), 0644)
+	fifth := CompanionProblem{Name: "E. Fifth", Tests: []CompanionTest{{Input: "3\n", Output: "3\n"}}}
+	imported, err = cptool.ImportProblem(fifth, true)
+	if err != nil {
+		t.Error(err)
+	}
+	if input, _ := afero.ReadFile(cptool.fs, inputPath); string(input) != "edited\n" {
+		t.Error("ImportProblem should not overwrite existing test case in problem directory, found:", string(input))
+	}
+	if len(imported.Skipped) != 1 || imported.Skipped[0] != inputPath {
+		t.Error("ImportProblem should report the existing test case, found:", imported.Skipped)
+	}
+	if ok, _ := afero.Exists(cptool.fs, path.Join(cptool.workingDirectory, "e_fifth/e_fifth.sample_1.out")); !ok {
+		t.Error("ImportProblem should save the missing files in problem directory")
+	}
+}
+
+func TestImportProblemWithInvalidName(t *testing.T) {
+	cptool := newTest()
+	_, err := cptool.ImportProblem(CompanionProblem{Name: "!!!"}, false)
+	if err != ErrInvalidProblem {
+		t.Error("ImportProblem should return ErrInvalidProblem error")
+	}
+}
+
+func TestCompanionHandler(t *testing.T) {
+	var received CompanionProblem
+	handler := NewCompanionHandler(func(problem CompanionProblem) error {
+		received = problem
+		return nil
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	response, err := http.Post(server.URL, "application/json", strings.NewReader(companionTestPayload))
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		t.Error("handler should respond with 200, found:", response.StatusCode)
+	}
+	if received.Name != "A. Sum of Two" || received.TimeLimit != 2000 || received.MemoryLimit != 256 {
+		t.Error("handler should decode the problem, found:", received)
+	}
+	if len(received.Tests) != 2 || received.Tests[1].Output != "11\n" {
+		t.Error("handler should decode the problem's tests, found:", received.Tests)
+	}
+}
+
+func TestCompanionHandlerWithInvalidRequest(t *testing.T) {
+	handler := NewCompanionHandler(func(problem CompanionProblem) error {
+		return errors.New("should not be called")
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	response, err := http.Post(server.URL, "application/json", strings.NewReader("not a json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusBadRequest {
+		t.Error("handler should respond with 400, found:", response.StatusCode)
+	}
+
+	response, err = http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusMethodNotAllowed {
+		t.Error("handler should respond with 405, found:", response.StatusCode)
+	}
+
+	response, err = http.Post(server.URL, "application/json", strings.NewReader(companionTestPayload))
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusInternalServerError {
+		t.Error("handler should respond with 500, found:", response.StatusCode)
+	}
+}
@@ -1,42 +1,63 @@
 package core
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"io/ioutil"
 	"os"
 	"path"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/jauhararifin/cptool/internal/logger"
+	"github.com/spf13/afero"
 )
 
 // CompilationResult store the result of compiling solution. The Skipped property indicates whether the compilation
 // process is skipped. The compilation can be skipped when the most up to date solution is already compiled.
 // TargetPath property contain the path to compiled program. Duration property indicates the duration of compilation
-// process.
+// process. Output contains the compiler's messages, its stderr followed by its stdout, even when the compilation succeeded,
+// and Diagnostics contains the errors and warnings parsed from it. ErrorMessage is the same as Output when the compilation
+// failed, and empty otherwise.
 type CompilationResult struct {
 	Skipped      bool
 	TargetPath   string
 	Duration     time.Duration
 	ErrorMessage string
+	Output       string
+	Diagnostics  []Diagnostic
 }
 
 // ErrLanguageNotDebuggable indicates that the language is not debuggable. This happens at compilation process when
 // the language definition doesn't have debugcompile script to compile the solution with debug mode.
 var ErrLanguageNotDebuggable = errors.New("Language is not debuggable")
 
-// Compile will compile solution if not yet compiled. The compilation prosess will execute compile script of the
-// language. It will use debugcompile script when debug parameter is true. When debug is true, but the language is
-// not debuggable (doesn't contain debugcompile script), an ErrLanguageNotDebuggable error will returned. This function
-// will execute the compilation script (could be compile/debugcompile) that defined in language definition. This execution
-// could be skipped when the solution already compiled before.
+// Compile will compile solution if not yet compiled. The compilation prosess will execute compile command (or compile
+// script) of the language. It will use debug compile command (or debugcompile script) when debug parameter is true. When
+// debug is true, but the language is not debuggable, an ErrLanguageNotDebuggable error will returned. This function will
+// execute the compilation command that defined in language definition. This execution could be skipped when the solution
+// already compiled before. For interpreted language, the compilation only checks the source code (if the language has compile
+// command) and the TargetPath is the source code itself. For language with directory target, the TargetPath is a directory.
 func (cptool *CPTool) Compile(ctx context.Context, solution Solution, debug bool) (CompilationResult, error) {
 	language := solution.Language
 	if debug && !language.Debuggable {
 		return CompilationResult{}, ErrLanguageNotDebuggable
 	}
 
+	// interpreted language runs the source code, the compiled target only marks that the syntax check has passed.
+	programPath := cptool.getCompiledTarget(solution, debug)
+	if language.Interpreted {
+		programPath = solution.Path
+		if !language.hasCompileStep(debug) {
+			return CompilationResult{
+				Skipped:    true,
+				TargetPath: programPath,
+			}, nil
+		}
+	}
+
 	targetDir := cptool.getCompiledDirectory(solution, debug)
 	cptool.fs.MkdirAll(targetDir, os.ModePerm)
 
@@ -51,20 +72,30 @@ func (cptool *CPTool) Compile(ctx context.Context, solution Solution, debug bool
 		if compiledTime.After(solution.LastUpdated) {
 			return CompilationResult{
 				Skipped:    true,
-				TargetPath: targetPath,
+				TargetPath: programPath,
 			}, nil
 		}
 	}
 
-	commandPath := language.CompileScript
-	if debug {
-		commandPath = language.DebugScript
+	if language.DirectoryTarget {
+		if err := cptool.fs.RemoveAll(targetPath); err != nil {
+			return CompilationResult{}, err
+		}
+		if err := cptool.fs.MkdirAll(targetPath, os.ModePerm); err != nil {
+			return CompilationResult{}, err
+		}
 	}
+
+	values := cptool.getCommandValues(solution, targetPath)
+	commandPath, args := language.getCompileCommand(values, debug)
 	if cptool.logger != nil {
-		cptool.logger.Println(logger.VERBOSE, "Compiling using script: ", commandPath)
+		cptool.logger.Println(logger.VERBOSE, "Compiling using command: ", commandPath, " ", strings.Join(args, " "))
 	}
 
-	cmd := cptool.exec.CommandContext(ctx, commandPath, solution.Path, targetPath)
+	// some compilers, like fpc, print their messages to stdout.
+	cmd := cptool.exec.CommandContext(ctx, commandPath, args...)
+	stdout := &bytes.Buffer{}
+	cmd.SetStdout(stdout)
 	stderr, err := cmd.StderrPipe()
 	if err != nil {
 		return CompilationResult{}, err
@@ -81,23 +112,49 @@ func (cptool *CPTool) Compile(ctx context.Context, solution Solution, debug bool
 	}
 
 	err = cmd.Wait()
+	output := string(compilationError) + stdout.String()
+	for _, artifact := range language.getCompileArtifacts(values) {
+		cptool.fs.Remove(artifact)
+	}
 	if err != nil {
 		if cptool.logger != nil {
 			cptool.logger.Print(logger.VERBOSE, "Compilation script execution giving error result")
 		}
-		return CompilationResult{ErrorMessage: string(compilationError)}, err
+		if language.DirectoryTarget {
+			// the directory is removed, so the failed compilation is not considered up to date.
+			cptool.fs.RemoveAll(targetPath)
+		}
+		// the compiler killed because the context is done didn't reject the solution.
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		}
+		return CompilationResult{
+			ErrorMessage: output,
+			Output:       output,
+			Diagnostics:  ParseDiagnostics(output),
+		}, err
+	}
+
+	if language.Interpreted {
+		stamp, err := cptool.fs.Create(targetPath)
+		if err != nil {
+			return CompilationResult{}, err
+		}
+		stamp.Close()
 	}
 
 	return CompilationResult{
-		Skipped:    false,
-		TargetPath: targetPath,
+		Skipped:     false,
+		TargetPath:  programPath,
+		Output:      output,
+		Diagnostics: ParseDiagnostics(output),
 	}, nil
 }
 
 // CompileByName will compile solution if not yet compiled. This method will search the language and solution by its name
 // and then call Compile method. This method will return an error if the language or solution with it's name doesn't exist.
 func (cptool *CPTool) CompileByName(ctx context.Context, languageName string, solutionName string, debug bool) (CompilationResult, error) {
-	start := time.Now()
+	start := cptool.clock()
 
 	language, err := cptool.GetLanguageByName(languageName)
 	if err != nil {
@@ -119,18 +176,18 @@ func (cptool *CPTool) CompileByName(ctx context.Context, languageName string, so
 	if err != nil {
 		return result, err
 	}
-	result.Duration = time.Since(start)
+	result.Duration = cptool.clock().Sub(start)
 	return result, nil
 }
 
 // GetCompilationRootDir returns directory of all compiled solutions.
 func (cptool *CPTool) GetCompilationRootDir() string {
-	return path.Join(cptool.workingDirectory, ".cptool/solutions")
+	return path.Join(cptool.GetProjectRoot(), ".cptool/solutions")
 }
 
 func (cptool *CPTool) getCompiledDirectory(solution Solution, debug bool) string {
 	language := solution.Language
-	return path.Join(cptool.GetCompilationRootDir(), solution.Name, language.Name)
+	return path.Join(cptool.GetCompilationRootDir(), cptool.getSolutionNamespace(solution), solution.Name, language.Name)
 }
 
 func (cptool *CPTool) getCompiledTarget(solution Solution, debug bool) string {
@@ -140,3 +197,23 @@ func (cptool *CPTool) getCompiledTarget(solution Solution, debug bool) string {
 	}
 	return path.Join(dir, "program")
 }
+
+var mainClassPattern = regexp.MustCompile(`public\s+(?:(?:final|abstract)\s+)*class\s+(\w+)`)
+
+// getCommandValues returns the values of command template placeholders for a solution. The main class name is the name of
+// the first public class in the source code, or "Main" when there is no public class.
+func (cptool *CPTool) getCommandValues(solution Solution, target string) commandValues {
+	class := "Main"
+	if content, err := afero.ReadFile(cptool.fs, solution.Path); err == nil {
+		if match := mainClassPattern.FindSubmatch(content); match != nil {
+			class = string(match[1])
+		}
+	}
+	return commandValues{
+		source:           solution.Path,
+		target:           target,
+		name:             solution.Name,
+		class:            class,
+		workingDirectory: cptool.workingDirectory,
+	}
+}
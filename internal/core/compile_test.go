@@ -7,10 +7,13 @@ import (
 	"io"
 	"io/ioutil"
 	"path"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/jauhararifin/cptool/internal/executioner"
+	"github.com/spf13/afero"
 )
 
 var compileTestLanguage = Language{
@@ -132,6 +135,38 @@ func TestCompileWithError(t *testing.T) {
 	}
 }
 
+func TestCompileWithWarnings(t *testing.T) {
+	cptool := newTest()
+	memexec := getCptoolMemExec(cptool)
+	memexec.StderrPipeCallback = func(m *executioner.MemCmd) (io.ReadCloser, error) {
+		return ioutil.NopCloser(strings.NewReader("a.lang:2:5: warning: unused variable 'x'\n")), nil
+	}
+	memexec.StartCallback = func(m *executioner.MemCmd) error {
+		_, err := m.GetStdout().Write([]byte("a.lang(3,1) Note: printed to stdout\n"))
+		return err
+	}
+	cptool.languages["some_lang"] = compileTestLanguage
+	afero.WriteFile(cptool.fs, path.Join(cptool.workingDirectory, "a.lang"), []byte{}, 0644)
+
+	result, err := cptool.CompileByName(context.Background(), "some_lang", "a", false)
+	if err != nil {
+		t.Error(err)
+	}
+	if result.Output != "a.lang:2:5: warning: unused variable 'x'\na.lang(3,1) Note: printed to stdout\n" {
+		t.Error("Compile should return the compiler's stderr and stdout, found:", result.Output)
+	}
+	if len(result.ErrorMessage) > 0 {
+		t.Error("Compile should not return error message when the compilation succeeded, found:", result.ErrorMessage)
+	}
+	expected := []Diagnostic{
+		{File: "a.lang", Line: 2, Column: 5, Severity: DiagnosticWarning, Message: "unused variable 'x'"},
+		{File: "a.lang", Line: 3, Column: 1, Severity: DiagnosticNote, Message: "printed to stdout"},
+	}
+	if !reflect.DeepEqual(result.Diagnostics, expected) {
+		t.Error("Compile should parse the diagnostics, found:", result.Diagnostics)
+	}
+}
+
 func TestCompileWithDebugInNonDebuggableLanguage(t *testing.T) {
 	cptool := newTest()
 	executed := false
@@ -215,3 +250,240 @@ func TestCompileWithDueDate(t *testing.T) {
 		t.Error("Compile should skip the compilation")
 	}
 }
+
+func TestCompileWithCommand(t *testing.T) {
+	cptool := newTest()
+	language := Language{
+		Name:           "some_lang",
+		Extension:      "lang",
+		CompileCommand: []string{"/bin/compiler", "-o", "{target}", "{source}"},
+		RunCommand:     []string{"{target}"},
+	}
+	cptool.languages["some_lang"] = language
+	cptool.fs.Create(path.Join(cptool.workingDirectory, "a.lang"))
+
+	var args []string
+	memexec := getCptoolMemExec(cptool)
+	memexec.StderrPipeCallback = func(m *executioner.MemCmd) (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(nil)), nil
+	}
+	memexec.StartCallback = func(m *executioner.MemCmd) error {
+		if m.GetPath() == "/bin/compiler" {
+			args = m.GetArgs()
+		}
+		return nil
+	}
+
+	result, err := cptool.CompileByName(context.Background(), "some_lang", "a", false)
+	if err != nil {
+		t.Error(err)
+	}
+	expected := []string{"/bin/compiler", "-o", result.TargetPath, path.Join(cptool.workingDirectory, "a.lang")}
+	if !reflect.DeepEqual(args, expected) {
+		t.Error("Compile should execute compile command", expected, ", but found", args)
+	}
+
+	if _, err := cptool.CompileByName(context.Background(), "some_lang", "a", true); err != ErrLanguageNotDebuggable {
+		t.Error("Compile should return ErrLanguageNotDebuggable, found:", err)
+	}
+}
+
+func TestCompileWithArtifacts(t *testing.T) {
+	cptool := newTest()
+	language := Language{
+		Name:             "some_lang",
+		Extension:        "lang",
+		CompileCommand:   []string{"/bin/compiler", "-o", "{target}", "{source}"},
+		CompileArtifacts: []string{"{target}.o"},
+		RunCommand:       []string{"{target}"},
+	}
+	cptool.fs.Create(path.Join(cptool.workingDirectory, "a.lang"))
+	solution, _ := cptool.GetSolution("a", language)
+
+	memexec := getCptoolMemExec(cptool)
+	memexec.StderrPipeCallback = func(m *executioner.MemCmd) (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(nil)), nil
+	}
+	memexec.StartCallback = func(m *executioner.MemCmd) error {
+		cptool.fs.Create(m.GetArgs()[2] + ".o")
+		return nil
+	}
+
+	result, err := cptool.Compile(context.Background(), solution, false)
+	if err != nil {
+		t.Error(err)
+	}
+	if exists, _ := afero.Exists(cptool.fs, result.TargetPath+".o"); exists {
+		t.Error("Compile should remove the compile artifacts")
+	}
+}
+
+func TestCompileInterpretedLanguage(t *testing.T) {
+	cptool := newTest()
+	language := Language{Name: "script", Extension: "py", Interpreted: true, RunCommand: []string{"/bin/python", "{source}"}}
+	solutionPath := path.Join(cptool.workingDirectory, "a.py")
+	cptool.fs.Create(solutionPath)
+	solution, _ := cptool.GetSolution("a", language)
+
+	executed := false
+	memexec := getCptoolMemExec(cptool)
+	memexec.StartCallback = func(m *executioner.MemCmd) error {
+		executed = true
+		return nil
+	}
+
+	result, err := cptool.Compile(context.Background(), solution, false)
+	if err != nil {
+		t.Error(err)
+	}
+	if executed {
+		t.Error("Compile should not execute anything for interpreted language without compile command")
+	}
+	if result.TargetPath != solutionPath {
+		t.Error("Compile should use the source code as the compiled program, found:", result.TargetPath)
+	}
+}
+
+func TestCompileInterpretedLanguageWithSyntaxCheck(t *testing.T) {
+	cptool := newTest()
+	language := Language{
+		Name:           "script",
+		Extension:      "py",
+		Interpreted:    true,
+		CompileCommand: []string{"/bin/python", "-m", "py_compile", "{source}"},
+		RunCommand:     []string{"/bin/python", "{source}"},
+	}
+	solutionPath := path.Join(cptool.workingDirectory, "a.py")
+	cptool.fs.Create(solutionPath)
+	solution, _ := cptool.GetSolution("a", language)
+
+	executed := 0
+	memexec := getCptoolMemExec(cptool)
+	memexec.StderrPipeCallback = func(m *executioner.MemCmd) (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(nil)), nil
+	}
+	memexec.StartCallback = func(m *executioner.MemCmd) error {
+		if m.GetPath() == "/bin/python" {
+			executed++
+		}
+		return nil
+	}
+
+	result, err := cptool.Compile(context.Background(), solution, false)
+	if err != nil {
+		t.Error(err)
+	}
+	if executed != 1 || result.Skipped || result.TargetPath != solutionPath {
+		t.Error("Compile should check the source code and use it as the compiled program, found:", result)
+	}
+
+	result, err = cptool.Compile(context.Background(), solution, false)
+	if err != nil {
+		t.Error(err)
+	}
+	if executed != 1 || !result.Skipped || result.TargetPath != solutionPath {
+		t.Error("Compile should skip checking unchanged source code, found:", result)
+	}
+}
+
+func TestCompileDirectoryTarget(t *testing.T) {
+	cptool := newTest()
+	language := Language{
+		Name:            "java",
+		Extension:       "java",
+		DirectoryTarget: true,
+		CompileCommand:  []string{"/bin/javac", "-d", "{target}", "{source}"},
+		RunCommand:      []string{"/bin/java", "-cp", "{target}", "{class}"},
+	}
+	solutionPath := path.Join(cptool.workingDirectory, "sol.java")
+	afero.WriteFile(cptool.fs, solutionPath, []byte("public final class Solution {}\n"), 0644)
+	solution, _ := cptool.GetSolution("sol", language)
+
+	var args []string
+	targetIsDirectory := false
+	memexec := getCptoolMemExec(cptool)
+	memexec.StderrPipeCallback = func(m *executioner.MemCmd) (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(nil)), nil
+	}
+	memexec.StartCallback = func(m *executioner.MemCmd) error {
+		args = m.GetArgs()
+		info, err := cptool.fs.Stat(args[2])
+		targetIsDirectory = err == nil && info.IsDir()
+		return nil
+	}
+	memexec.WaitCallback = func(m *executioner.MemCmd) error {
+		return errors.New("compilation error")
+	}
+
+	if _, err := cptool.Compile(context.Background(), solution, false); err == nil {
+		t.Error("Compile should return compilation error")
+	}
+	if !targetIsDirectory {
+		t.Error("Compile should create the target directory before compiling")
+	}
+	if _, err := cptool.fs.Stat(args[2]); err == nil {
+		t.Error("Compile should remove the target directory when the compilation failed")
+	}
+
+	memexec.WaitCallback = nil
+	result, err := cptool.Compile(context.Background(), solution, false)
+	if err != nil {
+		t.Error(err)
+	}
+	if result.TargetPath != args[2] {
+		t.Error("Compile should compile into the target directory, found:", result.TargetPath)
+	}
+
+	program, runArgs := language.getRunCommand(cptool.getCommandValues(solution, result.TargetPath))
+	if program != "/bin/java" || !reflect.DeepEqual(runArgs, []string{"-cp", result.TargetPath, "Solution"}) {
+		t.Error("run command should use the public class name, found:", program, runArgs)
+	}
+}
+
+func TestGetCommandValues(t *testing.T) {
+	cptool := newTest()
+	solutionPath := path.Join(cptool.workingDirectory, "sol.java")
+	afero.WriteFile(cptool.fs, solutionPath, []byte("class Solution {}\n"), 0644)
+	solution := Solution{Name: "sol", Path: solutionPath}
+
+	values := cptool.getCommandValues(solution, "/target")
+	expected := commandValues{
+		source:           solutionPath,
+		target:           "/target",
+		name:             "sol",
+		class:            "Main",
+		workingDirectory: cptool.workingDirectory,
+	}
+	if values != expected {
+		t.Error("command values should be", expected, ", but found", values)
+	}
+	replaced := values.replacer().Replace("{workdir}/{name}.jar")
+	if replaced != cptool.workingDirectory+"/sol.jar" {
+		t.Error("placeholders should be replaced, found:", replaced)
+	}
+}
+
+func TestGetCompiledTargetNamespace(t *testing.T) {
+	cptool := newTest()
+	language := Language{Name: "cpp", Extension: "cpp"}
+	cptool.fs.Create(path.Join(cptool.workingDirectory, "A/sol.cpp"))
+	cptool.fs.Create(path.Join(cptool.workingDirectory, "B/sol.cpp"))
+	cptool.fs.Create("/other/sol.cpp")
+	cptool.fs.Create("/another/sol.cpp")
+
+	targets := make(map[string]bool)
+	for _, name := range []string{"A/sol", "B/sol", "/other/sol", "/another/sol"} {
+		solution, err := cptool.GetSolution(name, language)
+		if err != nil {
+			t.Error(err)
+		}
+		target := cptool.getCompiledTarget(solution, false)
+		if !strings.HasPrefix(target, cptool.GetCompilationRootDir()+"/") {
+			t.Error("compiled target should be inside compilation directory, found:", target)
+		}
+		targets[target] = true
+	}
+	if len(targets) != 4 {
+		t.Error("solutions with the same name in different directories should have different targets, found:", targets)
+	}
+}
@@ -0,0 +1,90 @@
+package core
+
+import (
+	"path"
+
+	"github.com/jauhararifin/cptool/internal/config"
+	"github.com/jauhararifin/cptool/internal/logger"
+)
+
+// GetConfigLayers returns all configuration files that considered by cptool, ordered by their priority. The first layer has
+// the highest priority. The layers are "project" (.cptool/config in the project root), "cptool_home" ($CPTOOL_HOME/config),
+// "user" (~/.cptool/config) and "system" (/etc/cptool/config). Layers with same path are only returned once.
+func (cptool *CPTool) GetConfigLayers() []config.Layer {
+	layers := make([]config.Layer, 0)
+	seen := make(map[string]bool)
+	for _, layer := range cptool.getConfigLayerCandidates() {
+		if seen[layer.Path] {
+			continue
+		}
+		seen[layer.Path] = true
+		layers = append(layers, layer)
+	}
+	return layers
+}
+
+func (cptool *CPTool) getConfigLayerCandidates() []config.Layer {
+	candidates := []config.Layer{
+		{Name: "project", Path: path.Join(cptool.GetProjectRoot(), ".cptool")},
+		{Name: "cptool_home", Path: cptool.cptoolHomeDirectory},
+		{Name: "user", Path: path.Join(cptool.homeDirectory, ".cptool")},
+		{Name: "system", Path: "/etc/cptool/"},
+	}
+	layers := make([]config.Layer, 0, len(candidates))
+	for _, layer := range candidates {
+		if len(layer.Path) > 0 {
+			layers = append(layers, config.Layer{Name: layer.Name, Path: path.Join(layer.Path, "config")})
+		}
+	}
+	return layers
+}
+
+// GetConfig returns cptool's global configuration that loaded when cptool bootstrapped.
+func (cptool *CPTool) GetConfig() config.Config {
+	return cptool.config
+}
+
+// SetConfig sets cptool's global configuration. This can be used to override the configuration using command line options.
+func (cptool *CPTool) SetConfig(conf config.Config) {
+	cptool.config = conf
+}
+
+// ValidateConfig validates every configuration file. It returns a map from configuration file's path to its error, valid files
+// are not included.
+func (cptool *CPTool) ValidateConfig() map[string]error {
+	errs := make(map[string]error)
+	for _, layer := range cptool.GetConfigLayers() {
+		if err := config.Validate(cptool.fs, layer.Path); err != nil {
+			errs[layer.Path] = err
+		}
+	}
+	return errs
+}
+
+// SetConfigValue sets a setting in the configuration file of a layer. ErrNoSuchLayer returned when there is no layer with
+// such name.
+func (cptool *CPTool) SetConfigValue(layerName string, key string, value string) error {
+	for _, layer := range cptool.getConfigLayerCandidates() {
+		if layer.Name == layerName {
+			if cptool.logger != nil {
+				cptool.logger.Println(logger.VERBOSE, "Writing config to: ", layer.Path)
+			}
+			return config.Set(cptool.fs, layer.Path, key, value)
+		}
+	}
+	return config.ErrNoSuchLayer
+}
+
+func (cptool *CPTool) loadConfig() error {
+	conf, err := config.Load(cptool.fs, cptool.GetConfigLayers(), cptool.environ)
+	if err != nil {
+		return err
+	}
+	if cptool.logger != nil {
+		for _, value := range conf.Values() {
+			cptool.logger.Printf(logger.VERBOSE, "Config %s = %q (from %s)\n", value.Key, value.Value, value.Source)
+		}
+	}
+	cptool.config = conf
+	return nil
+}
@@ -0,0 +1,63 @@
+package core
+
+import (
+	"path"
+	"testing"
+
+	"github.com/jauhararifin/cptool/internal/config"
+	"github.com/spf13/afero"
+)
+
+func TestGetConfigLayers(t *testing.T) {
+	cptool := newTest()
+	layers := cptool.GetConfigLayers()
+	if len(layers) != 3 {
+		t.Error("config layers should contain 3 layers, found:", layers)
+	}
+	if layers[0].Name != "project" || layers[0].Path != path.Join(cptool.workingDirectory, ".cptool/config") {
+		t.Error("first layer should be project layer, found:", layers[0])
+	}
+	if layers[2].Name != "system" || layers[2].Path != "/etc/cptool/config" {
+		t.Error("last layer should be system layer, found:", layers[2])
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	cptool := newTest()
+	afero.WriteFile(cptool.fs, "/etc/cptool/config", []byte("jobs = 2\nkeep_outputs = true\n"), 0644)
+	afero.WriteFile(cptool.fs, path.Join(cptool.workingDirectory, ".cptool/config"), []byte("jobs = 3\n"), 0644)
+	cptool.environ = []string{"CPTOOL_AUTHOR=someone"}
+
+	if err := cptool.Bootstrap(); err != nil {
+		t.Error(err)
+	}
+	conf := cptool.GetConfig()
+	if conf.Jobs != 3 || !conf.KeepOutputs || conf.Author != "someone" {
+		t.Error("Bootstrap should load config from every layer and environment, found:", conf)
+	}
+}
+
+func TestBootstrapWithMalformedConfig(t *testing.T) {
+	cptool := newTest()
+	afero.WriteFile(cptool.fs, "/etc/cptool/config", []byte("jobs = \n"), 0644)
+	if err := cptool.Bootstrap(); err == nil {
+		t.Error("Bootstrap should return error when config is malformed")
+	}
+	if errs := cptool.ValidateConfig(); errs["/etc/cptool/config"] == nil || len(errs) != 1 {
+		t.Error("ValidateConfig should return the malformed config, found:", errs)
+	}
+}
+
+func TestSetConfigValue(t *testing.T) {
+	cptool := newTest()
+	if err := cptool.SetConfigValue("user", "author", "someone"); err != nil {
+		t.Error(err)
+	}
+	content, _ := afero.ReadFile(cptool.fs, path.Join(cptool.homeDirectory, ".cptool/config"))
+	if string(content) != "author = \"someone\"\n" {
+		t.Error("SetConfigValue should write to user config, found:", string(content))
+	}
+	if err := cptool.SetConfigValue("nothing", "author", "someone"); err != config.ErrNoSuchLayer {
+		t.Error("SetConfigValue should return ErrNoSuchLayer, found:", err)
+	}
+}
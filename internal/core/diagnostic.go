@@ -0,0 +1,76 @@
+package core
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DiagnosticError indicates the compiler rejected the source code
+	DiagnosticError = iota
+
+	// DiagnosticWarning indicates the source code is accepted but may be wrong, like warnings enabled by -Wall
+	DiagnosticWarning = iota
+
+	// DiagnosticNote indicates additional information, usually about the preceding error or warning
+	DiagnosticNote = iota
+)
+
+// Diagnostic is an error, warning or note reported by the compiler. File is the path as printed by the compiler, usually the
+// source code path. Line and Column start from 1, and are zero when the compiler doesn't report them. Severity is one of
+// DiagnosticError, DiagnosticWarning and DiagnosticNote.
+type Diagnostic struct {
+	File     string
+	Line     int
+	Column   int
+	Severity int
+	Message  string
+}
+
+// gccDiagnosticPattern matches GCC and Clang messages like "a.cpp:3:5: error: message" or "a.cpp:3: warning: message", javac
+// uses the same format without the column. Messages without position like "cc1plus: warning: message" are matched as well.
+var gccDiagnosticPattern = regexp.MustCompile(`^(.*?[^\s:]):(?:(\d+):(?:(\d+):)?)? ?(fatal error|error|warning|note|remark): (.*)$`)
+
+// fpcDiagnosticPattern matches Free Pascal messages like "a.pas(3,5) Error: message" or "a.pas(3) Warning: message".
+var fpcDiagnosticPattern = regexp.MustCompile(`^(.+?)\((\d+)(?:,(\d+))?\) (Fatal|Error|Warning|Note|Hint): (.*)$`)
+
+var diagnosticSeverities = map[string]int{
+	"fatal error": DiagnosticError,
+	"error":       DiagnosticError,
+	"warning":     DiagnosticWarning,
+	"note":        DiagnosticNote,
+	"remark":      DiagnosticNote,
+	"Fatal":       DiagnosticError,
+	"Error":       DiagnosticError,
+	"Warning":     DiagnosticWarning,
+	"Note":        DiagnosticNote,
+	"Hint":        DiagnosticNote,
+}
+
+// ParseDiagnostics parses compiler's messages in GCC, Clang or Free Pascal format. The other lines, like the source code snippets
+// and linker's messages, are ignored. It returns empty slice when the format is not recognized, the raw messages should be
+// shown instead.
+func ParseDiagnostics(output string) []Diagnostic {
+	diagnostics := make([]Diagnostic, 0)
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimRight(line, "\r")
+		match := gccDiagnosticPattern.FindStringSubmatch(line)
+		if match == nil {
+			match = fpcDiagnosticPattern.FindStringSubmatch(line)
+		}
+		if match == nil {
+			continue
+		}
+		lineNumber, _ := strconv.Atoi(match[2])
+		column, _ := strconv.Atoi(match[3])
+		diagnostics = append(diagnostics, Diagnostic{
+			File:     match[1],
+			Line:     lineNumber,
+			Column:   column,
+			Severity: diagnosticSeverities[match[4]],
+			Message:  match[5],
+		})
+	}
+	return diagnostics
+}
@@ -0,0 +1,64 @@
+package core
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseDiagnosticsGCC(t *testing.T) {
+	output := "a.cpp: In function 'int main()':\n" +
+		"a.cpp:2:26: warning: division by zero [-Wdiv-by-zero]\n" +
+		"    2 | int main(){int y=1/0;\n" +
+		"      |                  ~^~\n" +
+		"a.cpp:3:10: error: 'z' was not declared in this scope\r\n" +
+		"In file included from a.cpp:1:\n" +
+		"lib.h:4: note: declared here\n" +
+		"/usr/bin/ld: a.o: in function `main':\n" +
+		"collect2: error: ld returned 1 exit status\n"
+	expected := []Diagnostic{
+		{File: "a.cpp", Line: 2, Column: 26, Severity: DiagnosticWarning, Message: "division by zero [-Wdiv-by-zero]"},
+		{File: "a.cpp", Line: 3, Column: 10, Severity: DiagnosticError, Message: "'z' was not declared in this scope"},
+		{File: "lib.h", Line: 4, Severity: DiagnosticNote, Message: "declared here"},
+		{File: "collect2", Severity: DiagnosticError, Message: "ld returned 1 exit status"},
+	}
+	if diagnostics := ParseDiagnostics(output); !reflect.DeepEqual(diagnostics, expected) {
+		t.Error("ParseDiagnostics should parse gcc messages, found:", diagnostics)
+	}
+}
+
+func TestParseDiagnosticsClang(t *testing.T) {
+	output := "/home/a.cpp:5:3: fatal error: 'bits/stdc++.h' file not found\n" +
+		"C:\\code\\a.cpp:1:1: remark: some remark\n"
+	expected := []Diagnostic{
+		{File: "/home/a.cpp", Line: 5, Column: 3, Severity: DiagnosticError, Message: "'bits/stdc++.h' file not found"},
+		{File: "C:\\code\\a.cpp", Line: 1, Column: 1, Severity: DiagnosticNote, Message: "some remark"},
+	}
+	if diagnostics := ParseDiagnostics(output); !reflect.DeepEqual(diagnostics, expected) {
+		t.Error("ParseDiagnostics should parse clang messages, found:", diagnostics)
+	}
+}
+
+func TestParseDiagnosticsFPC(t *testing.T) {
+	output := "Free Pascal Compiler version 3.2.2\n" +
+		"Compiling a.pas\n" +
+		"a.pas(4,3) Warning: Variable \"x\" does not seem to be initialized\n" +
+		"a.pas(7,10) Error: Identifier not found \"y\"\n" +
+		"a.pas(9) Fatal: There were 1 errors compiling module, stopping\n" +
+		"a.pas(2,5) Hint: Local variable \"z\" not used\n"
+	expected := []Diagnostic{
+		{File: "a.pas", Line: 4, Column: 3, Severity: DiagnosticWarning, Message: "Variable \"x\" does not seem to be initialized"},
+		{File: "a.pas", Line: 7, Column: 10, Severity: DiagnosticError, Message: "Identifier not found \"y\""},
+		{File: "a.pas", Line: 9, Severity: DiagnosticError, Message: "There were 1 errors compiling module, stopping"},
+		{File: "a.pas", Line: 2, Column: 5, Severity: DiagnosticNote, Message: "Local variable \"z\" not used"},
+	}
+	if diagnostics := ParseDiagnostics(output); !reflect.DeepEqual(diagnostics, expected) {
+		t.Error("ParseDiagnostics should parse fpc messages, found:", diagnostics)
+	}
+}
+
+func TestParseDiagnosticsUnknownFormat(t *testing.T) {
+	output := "Traceback (most recent call last):\n  File \"a.py\", line 1\nSyntaxError: invalid syntax\n"
+	if diagnostics := ParseDiagnostics(output); len(diagnostics) != 0 {
+		t.Error("ParseDiagnostics should return empty diagnostics for unknown format, found:", diagnostics)
+	}
+}
@@ -0,0 +1,177 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+
+	"github.com/jauhararifin/cptool/internal/logger"
+	"github.com/spf13/afero"
+)
+
+// LanguageCheck stores the result of checking a language's toolchain. Version contains the first line printed by the language's
+// version command. MissingExecutables contains the programs used by the language's commands that cannot be found, and
+// NonExecutableScripts contains the language's scripts that have no executable permission. ShadowedPaths contains the paths of
+// other definitions of the language that are hidden by the loaded one, ordered by their priority. The definitions that the loaded
+// one extends using its own name are inherited, so they are not shadowed.
+//
+// The language is probed by compiling and running a tiny program that prints "hello". Probed indicates whether the probe is
+// executed, the probe is skipped when cptool has no probe program for the language's extension or the language has missing
+// executables or non executable scripts. ProbeErr is ErrProbeCompilationFailed, ErrProbeExecutionFailed or ErrProbeWrongOutput when
+// the probe fails, and ProbeMessage contains the output of the failed step.
+type LanguageCheck struct {
+	Language             Language
+	Version              string
+	MissingExecutables   []string
+	NonExecutableScripts []string
+	ShadowedPaths        []string
+	Probed               bool
+	ProbeErr             error
+	ProbeMessage         string
+}
+
+// ErrProbeCompilationFailed indicates the probe program cannot be compiled.
+var ErrProbeCompilationFailed = errors.New("Probe compilation failed")
+
+// ErrProbeExecutionFailed indicates the compiled probe program cannot be run.
+var ErrProbeExecutionFailed = errors.New("Probe execution failed")
+
+// ErrProbeWrongOutput indicates the probe program doesn't print the expected output.
+var ErrProbeWrongOutput = errors.New("Probe printed wrong output")
+
+// ErrUnknownStackLimit returned when the stack size limit cannot be read on the platform.
+var ErrUnknownStackLimit = errors.New("Unknown stack size limit")
+
+// probePrograms contains the probe program of every known extension. Every probe prints "hello", and is saved as "Main" so it
+// is valid for languages that require the file name to match the public class.
+var probePrograms = map[string]string{
+	"c":    "#include <stdio.h>\nint main() { puts(\"hello\"); return 0; }\n",
+	"cpp":  "#include <iostream>\nint main() { std::cout << \"hello\" << std::endl; return 0; }\n",
+	"cc":   "#include <iostream>\nint main() { std::cout << \"hello\" << std::endl; return 0; }\n",
+	"pas":  "begin\n  writeln('hello');\nend.\n",
+	"py":   "print(\"hello\")\n",
+	"java": "public class Main {\n  public static void main(String[] args) {\n    System.out.println(\"hello\");\n  }\n}\n",
+	"kt":   "fun main() {\n  println(\"hello\")\n}\n",
+	"go":   "package main\n\nimport \"fmt\"\n\nfunc main() {\n\tfmt.Println(\"hello\")\n}\n",
+	"rs":   "fn main() {\n    println!(\"hello\");\n}\n",
+}
+
+// lookPath searches an executable in PATH, it can be replaced in tests.
+var lookPath = exec.LookPath
+
+// OK reports whether the language has no problem. A language without probe program is OK when its executables and scripts
+// are found.
+func (check LanguageCheck) OK() bool {
+	return len(check.MissingExecutables) == 0 && len(check.NonExecutableScripts) == 0 && check.ProbeErr == nil
+}
+
+// CheckLanguage checks whether the language's toolchain is installed and works. It looks for the executables of the language's
+// commands, checks the permission of its scripts, runs its version command and probes the language by compiling and running a
+// tiny program inside a temporary directory.
+func (cptool *CPTool) CheckLanguage(ctx context.Context, language Language) LanguageCheck {
+	check := LanguageCheck{Language: language}
+	definitions := cptool.languageDefinitions[language.Name]
+	active := 0
+	for active < len(definitions)-1 && definitions[active].language.Extends == language.Name {
+		active++
+	}
+	if active < len(definitions) {
+		for _, definition := range definitions[active+1:] {
+			check.ShadowedPaths = append(check.ShadowedPaths, definition.path)
+		}
+	}
+
+	for _, command := range [][]string{language.CompileCommand, language.DebugCommand, language.RunCommand, language.VersionCommand} {
+		if len(command) == 0 || strings.Contains(command[0], "{") || containsPath(check.MissingExecutables, command[0]) {
+			continue
+		}
+		if _, err := lookPath(command[0]); err != nil {
+			check.MissingExecutables = append(check.MissingExecutables, command[0])
+		}
+	}
+	for _, script := range []string{language.CompileScript, language.DebugScript, language.RunScript} {
+		if len(script) == 0 {
+			continue
+		}
+		if info, err := cptool.fs.Stat(script); err != nil || info.Mode().Perm()&0111 == 0 {
+			check.NonExecutableScripts = append(check.NonExecutableScripts, script)
+		}
+	}
+	if len(check.MissingExecutables) > 0 || len(check.NonExecutableScripts) > 0 {
+		return check
+	}
+
+	if len(language.VersionCommand) > 0 {
+		output, _ := cptool.exec.CommandContext(ctx, language.VersionCommand[0], language.VersionCommand[1:]...).CombinedOutput()
+		for _, line := range strings.Split(string(output), "\n") {
+			if line = strings.TrimSpace(line); len(line) > 0 {
+				check.Version = line
+				break
+			}
+		}
+	}
+
+	if program, ok := probePrograms[language.Extension]; ok {
+		check.Probed = true
+		check.ProbeMessage, check.ProbeErr = cptool.probeLanguage(ctx, language, program)
+	}
+	return check
+}
+
+// probeLanguage compiles and runs the probe program, it returns the output of the failed step and the error.
+func (cptool *CPTool) probeLanguage(ctx context.Context, language Language, program string) (string, error) {
+	directory, err := afero.TempDir(cptool.fs, "", "cptool-probe-")
+	if err != nil {
+		return err.Error(), ErrProbeCompilationFailed
+	}
+	defer cptool.fs.RemoveAll(directory)
+
+	sourcePath := path.Join(directory, "Main."+language.Extension)
+	if err := afero.WriteFile(cptool.fs, sourcePath, []byte(program), 0644); err != nil {
+		return err.Error(), ErrProbeCompilationFailed
+	}
+	values := commandValues{
+		source:           sourcePath,
+		target:           path.Join(directory, "program"),
+		name:             "Main",
+		class:            "Main",
+		workingDirectory: directory,
+	}
+	if language.DirectoryTarget {
+		cptool.fs.MkdirAll(values.target, os.ModePerm)
+	}
+
+	if language.hasCompileStep(false) {
+		commandPath, args := language.getCompileCommand(values, false)
+		if cptool.logger != nil {
+			cptool.logger.Println(logger.VERBOSE, "Compiling probe using command: ", commandPath, " ", strings.Join(args, " "))
+		}
+		cmd := cptool.exec.CommandContext(ctx, commandPath, args...)
+		cmd.SetDir(directory)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return string(output), ErrProbeCompilationFailed
+		}
+	}
+	if language.Interpreted {
+		values.target = sourcePath
+	}
+
+	commandPath, args := language.getRunCommand(values)
+	cmd := cptool.exec.CommandContext(ctx, commandPath, args...)
+	cmd.SetDir(directory)
+	stdout := new(bytes.Buffer)
+	stderr := new(bytes.Buffer)
+	cmd.SetStdout(stdout)
+	cmd.SetStderr(stderr)
+	if err := cmd.Run(); err != nil {
+		return strings.TrimSpace(stderr.String() + "\n" + err.Error()), ErrProbeExecutionFailed
+	}
+	if strings.TrimSpace(stdout.String()) != "hello" {
+		return stdout.String(), ErrProbeWrongOutput
+	}
+	return "", nil
+}
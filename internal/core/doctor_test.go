@@ -0,0 +1,141 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/jauhararifin/cptool/internal/executioner"
+)
+
+func prepareDoctorTest(t *testing.T, missing ...string) *CPTool {
+	cptool := newTest()
+	original := lookPath
+	lookPath = func(file string) (string, error) {
+		for _, name := range missing {
+			if name == file {
+				return "", errors.New("executable file not found")
+			}
+		}
+		return "/usr/bin/" + file, nil
+	}
+	t.Cleanup(func() { lookPath = original })
+	return cptool
+}
+
+func TestCheckLanguage(t *testing.T) {
+	cptool := prepareDoctorTest(t)
+	memexec := getCptoolMemExec(cptool)
+	var sourcePath string
+	memexec.CombinedOutputCallback = func(m *executioner.MemCmd) ([]byte, error) {
+		if reflect.DeepEqual(m.GetArgs(), []string{"gcc", "--version"}) {
+			return []byte("\ngcc 12.2.0\nCopyright\n"), nil
+		}
+		sourcePath = m.GetArgs()[len(m.GetArgs())-1]
+		return nil, nil
+	}
+	memexec.RunCallback = func(m *executioner.MemCmd) error {
+		m.GetStdout().Write([]byte("hello\n"))
+		return nil
+	}
+
+	language := Language{
+		Name:           "c",
+		Extension:      "c",
+		CompileCommand: []string{"gcc", "-o", "{target}", "{source}"},
+		RunCommand:     []string{"{target}"},
+		VersionCommand: []string{"gcc", "--version"},
+	}
+	check := cptool.CheckLanguage(context.Background(), language)
+	if !check.OK() || !check.Probed || check.Version != "gcc 12.2.0" {
+		t.Error("language check should succeed, found:", check)
+	}
+	if _, err := cptool.fs.Stat(sourcePath); err == nil {
+		t.Error("probe directory should be removed, found:", sourcePath)
+	}
+
+	memexec.RunCallback = func(m *executioner.MemCmd) error {
+		m.GetStdout().Write([]byte("bye\n"))
+		return nil
+	}
+	if check := cptool.CheckLanguage(context.Background(), language); check.OK() || check.ProbeErr != ErrProbeWrongOutput {
+		t.Error("language check should report wrong probe output, found:", check)
+	}
+
+	memexec.CombinedOutputCallback = func(m *executioner.MemCmd) ([]byte, error) {
+		return []byte("error: something"), errors.New("exit status 1")
+	}
+	check = cptool.CheckLanguage(context.Background(), language)
+	if check.ProbeErr != ErrProbeCompilationFailed || check.ProbeMessage != "error: something" {
+		t.Error("language check should report probe compilation error, found:", check)
+	}
+}
+
+func TestCheckLanguageWithMissingExecutables(t *testing.T) {
+	cptool := prepareDoctorTest(t, "fpc")
+	cptool.fs.Create("/langs/pas/run")
+	cptool.fs.Chmod("/langs/pas/run", 0644)
+	executed := false
+	getCptoolMemExec(cptool).CombinedOutputCallback = func(m *executioner.MemCmd) ([]byte, error) {
+		executed = true
+		return nil, nil
+	}
+
+	language := Language{
+		Name:           "pas",
+		Extension:      "pas",
+		CompileCommand: []string{"fpc", "-o{target}", "{source}"},
+		VersionCommand: []string{"fpc", "-iV"},
+		RunScript:      "/langs/pas/run",
+	}
+	check := cptool.CheckLanguage(context.Background(), language)
+	if check.OK() || check.Probed || executed {
+		t.Error("language with missing executable should not be probed, found:", check)
+	}
+	if !reflect.DeepEqual(check.MissingExecutables, []string{"fpc"}) {
+		t.Error("missing executable should be reported once, found:", check.MissingExecutables)
+	}
+	if !reflect.DeepEqual(check.NonExecutableScripts, []string{"/langs/pas/run"}) {
+		t.Error("non executable script should be reported, found:", check.NonExecutableScripts)
+	}
+
+	cptool.fs.Chmod("/langs/pas/run", os.ModePerm)
+	check = cptool.CheckLanguage(context.Background(), Language{Name: "none", Extension: "unknown", RunScript: "/langs/pas/run"})
+	if !check.OK() || check.Probed {
+		t.Error("language without probe program should not be probed, found:", check)
+	}
+}
+
+func TestCheckLanguageWithShadowedDefinitions(t *testing.T) {
+	cptool := prepareDoctorTest(t)
+	prepareLanguageDirectories(cptool, map[string]string{
+		"/etc/cptool/langs/lang/lang.conf":                 "interpreted=true\nrun=[\"{source}\"]\n",
+		"/home/test/.cptool/langs/lang/lang.conf":          "interpreted=true\nrun=[\"{source}\"]\n",
+		"/home/test/cptool/.cptool/langs/lang/lang.conf":   "interpreted=true\nrun=[\"{source}\"]\n",
+		"/home/test/cptool/.cptool/langs/single/lang.conf": "interpreted=true\nrun=[\"{source}\"]\n",
+	})
+	cptool.loadAllLanguages()
+
+	lang, _ := cptool.GetLanguageByName("lang")
+	check := cptool.CheckLanguage(context.Background(), lang)
+	expected := []string{"/home/test/.cptool/langs/lang", "/etc/cptool/langs/lang"}
+	if !reflect.DeepEqual(check.ShadowedPaths, expected) {
+		t.Error("shadowed definitions should be reported by their priority, found:", check.ShadowedPaths)
+	}
+	lang, _ = cptool.GetLanguageByName("single")
+	if check := cptool.CheckLanguage(context.Background(), lang); len(check.ShadowedPaths) != 0 {
+		t.Error("language without shadowed definitions should not report any, found:", check.ShadowedPaths)
+	}
+
+	prepareLanguageDirectories(cptool, map[string]string{
+		"/home/test/cptool/.cptool/langs/lang/lang.conf": "extends=\"lang\"\ncompile_flags=[\"-O2\"]\n",
+	})
+	cptool.loadAllLanguages()
+	lang, _ = cptool.GetLanguageByName("lang")
+	check = cptool.CheckLanguage(context.Background(), lang)
+	if !reflect.DeepEqual(check.ShadowedPaths, []string{"/etc/cptool/langs/lang"}) {
+		t.Error("definition extended by the language should not be reported as shadowed, found:", check.ShadowedPaths)
+	}
+}
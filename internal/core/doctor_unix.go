@@ -0,0 +1,22 @@
+//go:build !windows
+// +build !windows
+
+package core
+
+import (
+	"syscall"
+)
+
+// GetStackLimit returns the stack size limit in bytes that is inherited by the solutions. It returns false when the stack size
+// is unlimited. Solutions with deep recursion may crash when the limit is small.
+func GetStackLimit() (uint64, bool, error) {
+	var limit syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_STACK, &limit); err != nil {
+		return 0, false, err
+	}
+	// RLIM_INFINITY differs between platforms, but it is always larger than any real limit.
+	if limit.Cur >= 1<<62 {
+		return 0, false, nil
+	}
+	return uint64(limit.Cur), true, nil
+}
@@ -0,0 +1,7 @@
+package core
+
+// GetStackLimit returns ErrUnknownStackLimit, since the stack size of a windows program is set in its executable instead of
+// inherited from cptool.
+func GetStackLimit() (uint64, bool, error) {
+	return 0, false, ErrUnknownStackLimit
+}
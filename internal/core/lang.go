@@ -2,10 +2,14 @@ package core
 
 import (
 	"errors"
+	"fmt"
+	iofs "io/fs"
 	"os"
 	"path"
 	"path/filepath"
 	"sort"
+	"strings"
+	"time"
 
 	"github.com/BurntSushi/toml"
 	"github.com/jauhararifin/cptool/internal/logger"
@@ -64,6 +68,68 @@ import (
 //     ./$PROGRAM
 //     exit $?
 //
+// Instead of scripts, the commands can be declared in lang.conf as command templates. The command templates are executed directly
+// without bash, after replacing "{source}" with the path to the solution's source code and "{target}" with the path to the compiled
+// program. CompileCommand, RunCommand and DebugCommand contain the command templates, and have higher priority than the scripts.
+// Below is the example for c language:
+//
+//     verbose_name="C"
+//     extension="c"
+//     compile=["gcc", "-x", "c", "-Wall", "-O2", "-static", "-pipe", "-o", "{target}", "{source}", "-lm"]
+//     debug_compile=["gcc", "-x", "c", "-Wall", "-O2", "-static", "-pipe", "-o", "{target}", "{source}", "-lm", "-g"]
+//     run=["{target}"]
+//
+// A language can extend another language using "extends" in lang.conf. The language inherits its parent's extension, commands and
+// scripts, unless it defines its own. CompileFlags contains flags that appended to the compile command (and the debug compile command),
+// while DebugFlags contains flags that only appended to the debug compile command. The flags are inserted in place of "{flags}"
+// argument when the command template has one, otherwise they are appended at the end. Scripts receive the flags as additional
+// arguments after the source and target path. The flags of the parent are inherited, unless the language overrides the parent's
+// compile command. Below is the example of C++17 language that extends "cpp" language:
+//
+//     extends="cpp"
+//     verbose_name="C++17"
+//     compile_flags=["-std=c++17"]
+//
+// Interpreted languages have no compiled program, they are declared using "interpreted=true" in lang.conf. The compile command (or
+// compile script) of interpreted language is optional, and when exists it is used as a syntax check step. The source code is used
+// as the compiled program, so "{target}" in run command (and the argument of run script) is the path to the source code. Below is
+// the example for python language:
+//
+//     extension="py"
+//     interpreted=true
+//     compile=["python3", "-c", "import sys; compile(open(sys.argv[1]).read(), sys.argv[1], 'exec')", "{source}"]
+//     run=["python3", "{source}"]
+//
+// VersionCommand contains the command that prints the version of the language's toolchain, it is declared using "version" in
+// lang.conf and used by "cptool doctor", for example:
+//
+//     version=["gcc", "--version"]
+//
+// Judges usually give slower languages more time and memory. TimeFactor and TimeBonus adjust the time limit of the problem into
+// "time_limit * time_factor + time_bonus", and MemoryBonus (in megabytes) is added to the memory limit of the problem. They are
+// declared using "time_factor", "time_bonus" and "memory_bonus" in lang.conf, and inherited from the parent language. Below is the
+// example for java language:
+//
+//     time_factor=2.0
+//     time_bonus="1s"
+//     memory_bonus=256
+//
+// Some languages compile the solution into several files, like java that produces a directory of class files. These languages declare
+// "directory_target=true" in lang.conf, then the compiled target is a directory that is created before the compilation. The command
+// templates can also use "{name}" for the solution's name, "{class}" for the solution's main class name (the name of the public class
+// in the source code, or "Main") and "{workdir}" for the working directory. Below is the example for java language:
+//
+//     extension="java"
+//     directory_target=true
+//     compile=["javac", "-d", "{target}", "{source}"]
+//     run=["java", "-cp", "{target}", "{class}"]
+//
+// Some compilers leave intermediate files next to the compiled program, like the object file of fpc. CompileArtifacts contains the
+// path templates of these files, declared using "compile_artifacts" in lang.conf, and they are removed after every compilation. The
+// artifacts are inherited together with the parent's compile command. Below is the example for pascal language:
+//
+//     compile=["fpc", "-viwn", "-O2", "-Sg", "-XS", "-o{target}", "{source}"]
+//     compile_artifacts=["{target}.o"]
 type Language struct {
 	Name        string
 	Extension   string
@@ -73,6 +139,24 @@ type Language struct {
 	RunScript     string
 	DebugScript   string
 	Debuggable    bool
+
+	CompileCommand   []string
+	RunCommand       []string
+	DebugCommand     []string
+	CompileArtifacts []string
+
+	Extends      string
+	CompileFlags []string
+	DebugFlags   []string
+
+	Interpreted     bool
+	DirectoryTarget bool
+
+	VersionCommand []string
+
+	TimeFactor  float64
+	TimeBonus   time.Duration
+	MemoryBonus int
 }
 
 // ErrInvalidLanguageDirectory indicates directory is not a valid language definition.
@@ -84,6 +168,43 @@ var ErrInvalidLanguageConfigurationFile = errors.New("Invalid language configura
 // ErrNoSuchLanguage indicates no language found.
 var ErrNoSuchLanguage = errors.New("No such language")
 
+// ErrLanguageDirectoryExists indicates the language directory already exists.
+var ErrLanguageDirectoryExists = errors.New("Language directory already exists")
+
+// ErrLanguageInheritanceCycle indicates languages extend each other in a cycle.
+var ErrLanguageInheritanceCycle = errors.New("Language inheritance cycle")
+
+// ErrNoSuchParentLanguage indicates a language extends a language that doesn't exist.
+var ErrNoSuchParentLanguage = errors.New("No such parent language")
+
+// LanguageInheritanceError indicates a language cannot be loaded because of its inheritance chain. Chain contains the names of the
+// languages in the inheritance chain, starting from the loaded language. Err is ErrLanguageInheritanceCycle or ErrNoSuchParentLanguage.
+type LanguageInheritanceError struct {
+	Chain []string
+	Err   error
+}
+
+func (e *LanguageInheritanceError) Error() string {
+	return fmt.Sprintf("%v: %s", e.Err, strings.Join(e.Chain, " -> "))
+}
+
+// InvalidLanguageError indicates a language directory is rejected. Path contains the language directory, Reason explains why the
+// directory is rejected and Err is ErrInvalidLanguageDirectory or ErrInvalidLanguageConfigurationFile.
+type InvalidLanguageError struct {
+	Path   string
+	Reason string
+	Err    error
+}
+
+func (e *InvalidLanguageError) Error() string {
+	return fmt.Sprintf("%v: %s: %s", e.Err, e.Path, e.Reason)
+}
+
+// Unwrap returns ErrInvalidLanguageDirectory or ErrInvalidLanguageConfigurationFile.
+func (e *InvalidLanguageError) Unwrap() error {
+	return e.Err
+}
+
 // GetAllLanguages returns all known language as a pair of []Language, map[string]Language. The first element
 // of pair contains array of all known languages. The second element of pair contains map of Language of string
 // that map between language's name and itself.
@@ -107,43 +228,24 @@ func (cptool *CPTool) GetLanguageByName(name string) (Language, error) {
 }
 
 // GetDefaultLanguage returns default language. The default language is defined in "config" file in some of configuration path.
-// Can be "/etc/cptool/config", "~/.cptool/config", "$CPTOOL_HOME/config" or ".cptool/config" in your current working directory.
-// Below is example of config file that defines default language as C Plus Plus ("cpp" is the language's name, while "C Plus Plus"
-// is the language verbose name).
+// Can be "/etc/cptool/config", "~/.cptool/config", "$CPTOOL_HOME/config" or ".cptool/config" in your current working directory, or
+// $CPTOOL_DEFAULT_LANGUAGE environment variable. Below is example of config file that defines default language as C Plus Plus ("cpp"
+// is the language's name, while "C Plus Plus" is the language verbose name).
 //
 //     default_language=cpp
 //
-// When there is no valid config file, the default language is choosen between all known languages. When there is no known language,
-// then ErrNoSuchLanguage error returned.
+// The default language in problem configuration has higher priority than config file. When there is no default language configured,
+// the default language is choosen between all known languages. When there is no known language, then ErrNoSuchLanguage error returned.
 func (cptool *CPTool) GetDefaultLanguage() (Language, error) {
-	configurationPaths := cptool.GetConfigurationPaths()
-	for _, confPath := range configurationPaths {
-		userConfigPath := path.Join(confPath, "config")
-		if cptool.logger != nil {
-			cptool.logger.Println(logger.VERBOSE, "Searching default language config in: ", userConfigPath)
-		}
-		info, err := cptool.fs.Stat(userConfigPath)
-		if err != nil || info.IsDir() {
-			if cptool.logger != nil {
-				cptool.logger.Println(logger.VERBOSE, "Language config doesn't found in: ", userConfigPath)
-			}
+	for _, name := range []string{cptool.problem.DefaultLanguage, cptool.config.DefaultLanguage} {
+		if len(name) == 0 {
 			continue
 		}
-
-		if userConfigFile, err := cptool.fs.Open(userConfigPath); err == nil {
-			result := &struct {
-				DefaultLanguage string `toml:"default_language"`
-			}{}
-			if _, err = toml.DecodeReader(userConfigFile, &result); err == nil {
-				if len(result.DefaultLanguage) > 0 {
-					if defaultLanguage, err := cptool.GetLanguageByName(result.DefaultLanguage); err == nil {
-						if cptool.logger != nil {
-							cptool.logger.Println(logger.VERBOSE, "Use default language: ", defaultLanguage.Name)
-						}
-						return defaultLanguage, nil
-					}
-				}
+		if defaultLanguage, err := cptool.GetLanguageByName(name); err == nil {
+			if cptool.logger != nil {
+				cptool.logger.Println(logger.VERBOSE, "Use default language: ", defaultLanguage.Name)
 			}
+			return defaultLanguage, nil
 		}
 	}
 
@@ -154,9 +256,163 @@ func (cptool *CPTool) GetDefaultLanguage() (Language, error) {
 	return languages[0], nil
 }
 
+// builtinLanguagePrefix prefixes the name of a built-in language to describe where it is defined.
+const builtinLanguagePrefix = "builtin:"
+
 type languageConfFile struct {
-	VerboseName string `toml:"verbose_name"`
-	Extension   string `toml:"extension"`
+	Extends          string   `toml:"extends"`
+	VerboseName      string   `toml:"verbose_name"`
+	Extension        string   `toml:"extension"`
+	Compile          []string `toml:"compile"`
+	CompileArtifacts []string `toml:"compile_artifacts"`
+	Run              []string `toml:"run"`
+	DebugCompile     []string `toml:"debug_compile"`
+	CompileFlags     []string `toml:"compile_flags"`
+	DebugFlags       []string `toml:"debug_flags"`
+	Interpreted      *bool    `toml:"interpreted"`
+	DirectoryTarget  *bool    `toml:"directory_target"`
+	Version          []string `toml:"version"`
+	TimeFactor       *float64 `toml:"time_factor"`
+	TimeBonus        *string  `toml:"time_bonus"`
+	MemoryBonus      *int     `toml:"memory_bonus"`
+}
+
+// LanguageSource describes a definition of a language. Path contains the language directory, or "builtin:" followed by the
+// language name for built-in languages. Priority is the index of the languages path that contains the definition, the definition
+// with lower Priority shadows the others, and built-in languages have the highest Priority. Active indicates the definition is
+// the loaded one. Err contains the reason when the definition is rejected, or when the language cannot be resolved.
+type LanguageSource struct {
+	Name     string
+	Path     string
+	Priority int
+	Active   bool
+	Err      error
+}
+
+// languageDefinition is a language directory before its inheritance is resolved. The language contains only the values defined
+// in the directory itself.
+type languageDefinition struct {
+	language        Language
+	path            string
+	priority        int
+	hasExtension    bool
+	hasVerboseName  bool
+	hasCompile      bool
+	hasRun          bool
+	hasDebugCompile bool
+	hasInterpreted  bool
+	hasDirectory    bool
+	hasTimeFactor   bool
+	hasTimeBonus    bool
+	hasMemoryBonus  bool
+}
+
+// GetLimits returns the limits of a test case for the language. The time limit is adjusted into "timeLimit * TimeFactor +
+// TimeBonus" and MemoryBonus is added to the memory limit (in megabytes). Zero limit means unlimited, so it is not adjusted.
+// TimeFactor is considered 1 when it is zero.
+func (language Language) GetLimits(timeLimit time.Duration, memoryLimit int) (time.Duration, int) {
+	if timeLimit > 0 {
+		factor := language.TimeFactor
+		if factor <= 0 {
+			factor = 1
+		}
+		timeLimit = time.Duration(float64(timeLimit)*factor) + language.TimeBonus
+	}
+	if memoryLimit > 0 {
+		memoryLimit += language.MemoryBonus
+	}
+	return timeLimit, memoryLimit
+}
+
+// hasCompileStep reports whether the language has compile command or compile script (debug compile when debug is true).
+func (language Language) hasCompileStep(debug bool) bool {
+	if debug {
+		return len(language.DebugCommand) > 0 || len(language.DebugScript) > 0
+	}
+	return len(language.CompileCommand) > 0 || len(language.CompileScript) > 0
+}
+
+// commandValues contains the values of placeholders in command templates. source is the path to the solution's source code,
+// target is the path to the compiled program, name is the solution's name, class is the main class name of the solution and
+// workingDirectory is cptool's working directory.
+type commandValues struct {
+	source           string
+	target           string
+	name             string
+	class            string
+	workingDirectory string
+}
+
+func (values commandValues) replacer() *strings.Replacer {
+	return strings.NewReplacer(
+		"{source}", values.source,
+		"{target}", values.target,
+		"{name}", values.name,
+		"{class}", values.class,
+		"{workdir}", values.workingDirectory,
+	)
+}
+
+// getCompileCommand returns the program and its arguments for compiling source into target. The compile command template is
+// used when defined, otherwise the compile script (or debugcompile script when debug is true) is used.
+func (language Language) getCompileCommand(values commandValues, debug bool) (string, []string) {
+	flags := language.CompileFlags
+	if debug {
+		flags = append(append([]string{}, language.CompileFlags...), language.DebugFlags...)
+		if len(language.DebugCommand) > 0 {
+			return expandCommand(insertFlags(language.DebugCommand, flags), values.replacer())
+		}
+		return language.DebugScript, append([]string{values.source, values.target}, flags...)
+	}
+	if len(language.CompileCommand) > 0 {
+		return expandCommand(insertFlags(language.CompileCommand, flags), values.replacer())
+	}
+	return language.CompileScript, append([]string{values.source, values.target}, flags...)
+}
+
+// getRunCommand returns the program and its arguments for running the compiled program. The run command template is used when
+// defined, otherwise the run script is used.
+func (language Language) getRunCommand(values commandValues) (string, []string) {
+	if len(language.RunCommand) > 0 {
+		return expandCommand(language.RunCommand, values.replacer())
+	}
+	return language.RunScript, []string{values.target}
+}
+
+// insertFlags inserts flags in place of "{flags}" argument of the command template, or appends them when there is no "{flags}".
+func insertFlags(command []string, flags []string) []string {
+	result := make([]string, 0, len(command)+len(flags))
+	inserted := false
+	for _, arg := range command {
+		if arg == "{flags}" {
+			result = append(result, flags...)
+			inserted = true
+			continue
+		}
+		result = append(result, arg)
+	}
+	if !inserted {
+		result = append(result, flags...)
+	}
+	return result
+}
+
+// getCompileArtifacts returns the paths of the intermediate files left by compiling source into target.
+func (language Language) getCompileArtifacts(values commandValues) []string {
+	replacer := values.replacer()
+	artifacts := make([]string, 0, len(language.CompileArtifacts))
+	for _, artifact := range language.CompileArtifacts {
+		artifacts = append(artifacts, replacer.Replace(artifact))
+	}
+	return artifacts
+}
+
+func expandCommand(command []string, replacer *strings.Replacer) (string, []string) {
+	args := make([]string, 0, len(command)-1)
+	for _, arg := range command[1:] {
+		args = append(args, replacer.Replace(arg))
+	}
+	return replacer.Replace(command[0]), args
 }
 
 func (cptool *CPTool) getLanguagesPaths() []string {
@@ -168,82 +424,438 @@ func (cptool *CPTool) getLanguagesPaths() []string {
 	return langPaths
 }
 
+// getLanguageFromDirectory returns the language defined in a directory. When the language extends another language, the
+// parent is searched in the already loaded languages.
 func (cptool *CPTool) getLanguageFromDirectory(languagePath string) (Language, error) {
-	info, err := cptool.fs.Stat(languagePath)
+	definition, err := cptool.readLanguageDirectory(languagePath)
+	if err != nil {
+		return Language{}, err
+	}
+	if len(definition.language.Extends) == 0 {
+		return definition.resolve(Language{})
+	}
+	parent, ok := cptool.languages[definition.language.Extends]
+	if !ok {
+		return Language{}, &LanguageInheritanceError{
+			Chain: []string{definition.language.Name, definition.language.Extends},
+			Err:   ErrNoSuchParentLanguage,
+		}
+	}
+	return definition.resolve(parent)
+}
+
+// readLanguageDirectory reads lang.conf and the scripts in a language directory without resolving its parent. InvalidLanguageError
+// returned when the directory cannot be read.
+func (cptool *CPTool) readLanguageDirectory(languagePath string) (languageDefinition, error) {
+	return readLanguageDefinition(cptool.fs, languagePath)
+}
+
+func readLanguageDefinition(fs afero.Fs, languagePath string) (languageDefinition, error) {
+	info, err := fs.Stat(languagePath)
 	if err != nil || !info.IsDir() {
-		return Language{}, ErrInvalidLanguageDirectory
+		return languageDefinition{}, &InvalidLanguageError{Path: languagePath, Reason: "not a directory", Err: ErrInvalidLanguageDirectory}
 	}
 
-	language := Language{}
+	definition := languageDefinition{path: languagePath}
+	language := &definition.language
 	language.Name = info.Name()
-	language.VerboseName = language.Name
-	language.Extension = language.Name
 
 	configPath := path.Join(languagePath, "lang.conf")
-	info, err = cptool.fs.Stat(configPath)
+	info, err = fs.Stat(configPath)
 	if err == nil && !info.IsDir() {
-		configFile, _ := cptool.fs.Open(configPath)
+		configFile, _ := fs.Open(configPath)
+		defer configFile.Close()
 		languageConf := languageConfFile{}
 		if _, err = toml.DecodeReader(configFile, &languageConf); err != nil {
-			return Language{}, ErrInvalidLanguageConfigurationFile
+			return languageDefinition{}, &InvalidLanguageError{Path: languagePath, Reason: err.Error(), Err: ErrInvalidLanguageConfigurationFile}
+		}
+		language.Extends = languageConf.Extends
+		language.VerboseName = languageConf.VerboseName
+		language.Extension = languageConf.Extension
+		language.CompileCommand = languageConf.Compile
+		language.RunCommand = languageConf.Run
+		language.DebugCommand = languageConf.DebugCompile
+		language.CompileArtifacts = languageConf.CompileArtifacts
+		language.CompileFlags = languageConf.CompileFlags
+		language.DebugFlags = languageConf.DebugFlags
+		language.VersionCommand = languageConf.Version
+		if languageConf.Interpreted != nil {
+			language.Interpreted = *languageConf.Interpreted
+			definition.hasInterpreted = true
+		}
+		if languageConf.DirectoryTarget != nil {
+			language.DirectoryTarget = *languageConf.DirectoryTarget
+			definition.hasDirectory = true
+		}
+		if languageConf.TimeFactor != nil {
+			if *languageConf.TimeFactor <= 0 {
+				return languageDefinition{}, &InvalidLanguageError{
+					Path:   languagePath,
+					Reason: "time_factor must be positive",
+					Err:    ErrInvalidLanguageConfigurationFile,
+				}
+			}
+			language.TimeFactor = *languageConf.TimeFactor
+			definition.hasTimeFactor = true
 		}
-		if len(languageConf.VerboseName) > 0 {
-			language.VerboseName = languageConf.VerboseName
+		if languageConf.TimeBonus != nil {
+			if language.TimeBonus, err = time.ParseDuration(*languageConf.TimeBonus); err != nil || language.TimeBonus < 0 {
+				return languageDefinition{}, &InvalidLanguageError{
+					Path:   languagePath,
+					Reason: "time_bonus must be a non negative duration like \"1s\"",
+					Err:    ErrInvalidLanguageConfigurationFile,
+				}
+			}
+			definition.hasTimeBonus = true
 		}
-		if len(languageConf.Extension) > 0 {
-			language.Extension = languageConf.Extension
+		if languageConf.MemoryBonus != nil {
+			if *languageConf.MemoryBonus < 0 {
+				return languageDefinition{}, &InvalidLanguageError{
+					Path:   languagePath,
+					Reason: "memory_bonus must not be negative",
+					Err:    ErrInvalidLanguageConfigurationFile,
+				}
+			}
+			language.MemoryBonus = *languageConf.MemoryBonus
+			definition.hasMemoryBonus = true
 		}
 	}
+	definition.hasVerboseName = len(language.VerboseName) > 0
+	definition.hasExtension = len(language.Extension) > 0
 
-	language.CompileScript = path.Join(languagePath, "compile")
-	info, err = cptool.fs.Stat(language.CompileScript)
-	if err != nil {
-		return Language{}, err
+	scripts := []struct {
+		name    string
+		command []string
+		script  *string
+		has     *bool
+	}{
+		{"compile", language.CompileCommand, &language.CompileScript, &definition.hasCompile},
+		{"run", language.RunCommand, &language.RunScript, &definition.hasRun},
+		{"debugcompile", language.DebugCommand, &language.DebugScript, &definition.hasDebugCompile},
 	}
-	if info.IsDir() {
-		return Language{}, ErrInvalidLanguageDirectory
+	for _, script := range scripts {
+		if len(script.command) > 0 {
+			*script.has = true
+			continue
+		}
+		scriptPath := path.Join(languagePath, script.name)
+		if info, err := fs.Stat(scriptPath); err == nil {
+			if info.IsDir() {
+				return languageDefinition{}, &InvalidLanguageError{
+					Path:   languagePath,
+					Reason: script.name + " script is a directory",
+					Err:    ErrInvalidLanguageDirectory,
+				}
+			}
+			*script.script = scriptPath
+			*script.has = true
+		}
 	}
 
-	language.RunScript = path.Join(languagePath, "run")
-	info, err = cptool.fs.Stat(language.RunScript)
-	if err != nil {
-		return Language{}, err
+	return definition, nil
+}
+
+// resolve returns the language of the definition after inheriting from parent. Use empty Language as the parent when the
+// language doesn't extend any language. InvalidLanguageError returned when the resolved language has no way to compile or run
+// the solution.
+func (definition languageDefinition) resolve(parent Language) (Language, error) {
+	language := definition.language
+	if !definition.hasVerboseName {
+		language.VerboseName = language.Name
 	}
-	if info.IsDir() {
-		return Language{}, ErrInvalidLanguageDirectory
+	if !definition.hasExtension {
+		language.Extension = parent.Extension
+		if len(language.Extension) == 0 {
+			language.Extension = language.Name
+		}
 	}
 
-	DebugScript := path.Join(languagePath, "debugcompile")
-	if info, err = cptool.fs.Stat(DebugScript); err == nil && !info.IsDir() {
-		language.Debuggable = true
-		language.DebugScript = DebugScript
+	if !definition.hasInterpreted {
+		language.Interpreted = parent.Interpreted
+	}
+	if !definition.hasDirectory {
+		language.DirectoryTarget = parent.DirectoryTarget
+	}
+	if len(language.VersionCommand) == 0 {
+		language.VersionCommand = parent.VersionCommand
+	}
+	if !definition.hasTimeFactor {
+		language.TimeFactor = parent.TimeFactor
+	}
+	if !definition.hasTimeBonus {
+		language.TimeBonus = parent.TimeBonus
+	}
+	if !definition.hasMemoryBonus {
+		language.MemoryBonus = parent.MemoryBonus
+	}
+	if !definition.hasCompile {
+		language.CompileCommand = parent.CompileCommand
+		language.CompileScript = parent.CompileScript
+		language.CompileFlags = append(append([]string{}, parent.CompileFlags...), language.CompileFlags...)
+		if len(language.CompileArtifacts) == 0 {
+			language.CompileArtifacts = parent.CompileArtifacts
+		}
+	}
+	if !definition.hasRun {
+		language.RunCommand = parent.RunCommand
+		language.RunScript = parent.RunScript
+	}
+	if !definition.hasDebugCompile {
+		language.DebugCommand = parent.DebugCommand
+		language.DebugScript = parent.DebugScript
+		language.DebugFlags = append(append([]string{}, parent.DebugFlags...), language.DebugFlags...)
+	}
+	if len(language.CompileFlags) == 0 {
+		language.CompileFlags = nil
+	}
+	if len(language.DebugFlags) == 0 {
+		language.DebugFlags = nil
 	}
 
+	if !language.Interpreted && !language.hasCompileStep(false) {
+		return Language{}, &InvalidLanguageError{
+			Path:   definition.path,
+			Reason: "no compile command or compile script, and the language is not interpreted",
+			Err:    ErrInvalidLanguageDirectory,
+		}
+	}
+	if len(language.RunCommand) == 0 && len(language.RunScript) == 0 {
+		return Language{}, &InvalidLanguageError{Path: definition.path, Reason: "no run command or run script", Err: ErrInvalidLanguageDirectory}
+	}
+	language.Debuggable = len(language.DebugCommand) > 0 || len(language.DebugScript) > 0
 	return language, nil
 }
 
+// resolveLanguage resolves the inheritance chain of a language. The definitions contains every definition of a language name
+// ordered by their priority, and level is the index of the resolved definition. A language that extends its own name extends the
+// definition with lower priority, so a project can customize the user's language. LanguageInheritanceError returned when the
+// chain contains a cycle or a missing language.
+func resolveLanguage(name string, level int, definitions map[string][]languageDefinition, chain []string) (Language, error) {
+	for _, visited := range chain {
+		if visited == name && level == 0 {
+			return Language{}, &LanguageInheritanceError{Chain: append(chain, name), Err: ErrLanguageInheritanceCycle}
+		}
+	}
+	chain = append(chain, name)
+	if level >= len(definitions[name]) {
+		return Language{}, &LanguageInheritanceError{Chain: chain, Err: ErrNoSuchParentLanguage}
+	}
+	definition := definitions[name][level]
+	extends := definition.language.Extends
+	if len(extends) == 0 {
+		return definition.resolve(Language{})
+	}
+	parentLevel := 0
+	if extends == name {
+		parentLevel = level + 1
+	}
+	parent, err := resolveLanguage(extends, parentLevel, definitions, chain)
+	if err != nil {
+		return Language{}, err
+	}
+	return definition.resolve(parent)
+}
+
+// loadAllLanguages loads languages from every languages path and the built-in languages. When there are several languages with
+// the same name, the one in the configuration path with higher priority is used, the others can only be used as its parent. The
+// built-in languages have the lowest priority. The inheritance is resolved after all language directories are read, so a language
+// can extend a language from any configuration path. Languages with invalid inheritance are reported and skipped. Every found
+// definition, including the shadowed and rejected ones, is kept in the language registry.
 func (cptool *CPTool) loadAllLanguages() {
-	langPaths := cptool.getLanguagesPaths()
-	for _, path := range langPaths {
-		info, err := cptool.fs.Stat(path)
-		if err != nil || !info.IsDir() {
+	definitions := make(map[string][]languageDefinition)
+	names := make([]string, 0)
+	registry := make([]LanguageSource, 0)
+	addDefinition := func(definition languageDefinition, err error) {
+		name := definition.language.Name
+		if err != nil {
+			if cptool.logger != nil {
+				cptool.logger.Println(logger.VERBOSE, "Rejected language directory: ", err)
+			}
+			registry = append(registry, LanguageSource{Name: name, Path: definition.path, Priority: definition.priority, Err: err})
+			return
+		}
+		if _, ok := definitions[name]; !ok {
+			names = append(names, name)
+		}
+		definitions[name] = append(definitions[name], definition)
+	}
+
+	languagesPaths := cptool.getLanguagesPaths()
+	for priority, path := range languagesPaths {
+		for _, result := range readLanguagesDirectory(cptool.fs, path) {
+			result.definition.priority = priority
+			addDefinition(result.definition, result.err)
+		}
+	}
+	for _, result := range readLanguagesDirectory(cptool.getBuiltinLanguagesFs(), "/") {
+		result.definition.path = builtinLanguagePrefix + result.definition.language.Name
+		result.definition.priority = len(languagesPaths)
+		addDefinition(result.definition, result.err)
+	}
+
+	cptool.languageDefinitions = definitions
+	for _, name := range names {
+		lang, err := resolveLanguage(name, 0, definitions, nil)
+		for i, definition := range definitions[name] {
+			source := LanguageSource{Name: name, Path: definition.path, Priority: definition.priority, Active: i == 0 && err == nil}
+			if i == 0 {
+				source.Err = err
+			} else if cptool.logger != nil {
+				cptool.logger.Printf(logger.VERBOSE, "Language %s in %s is shadowed by %s\n", name, definition.path, definitions[name][0].path)
+			}
+			registry = append(registry, source)
+		}
+		if err != nil {
+			if _, ok := err.(*LanguageInheritanceError); ok && cptool.logger != nil {
+				cptool.logger.PrintError("Cannot load language ", name, ": ", err)
+			} else if cptool.logger != nil {
+				cptool.logger.Println(logger.VERBOSE, "Rejected language directory: ", err)
+			}
 			continue
 		}
+		cptool.languages[name] = lang
+		if cptool.logger != nil {
+			cptool.logger.Printf(logger.VERBOSE, "Found language: %s, in: %s\n", lang.Name, definitions[name][0].path)
+		}
+	}
 
-		afero.Walk(cptool.fs, path, func(langPath string, info os.FileInfo, err error) error {
-			if info.IsDir() && langPath != path {
-				if lang, err := cptool.getLanguageFromDirectory(langPath); err == nil {
-					if _, ok := cptool.languages[lang.Name]; ok {
-						return filepath.SkipDir
-					}
-					cptool.languages[lang.Name] = lang
-					if cptool.logger != nil {
-						cptool.logger.Printf(logger.VERBOSE, "Found language: %s, in: %s\n", lang.Name, langPath)
-					}
-				}
-				return filepath.SkipDir
+	sort.SliceStable(registry, func(i, j int) bool {
+		if registry[i].Name != registry[j].Name {
+			return registry[i].Name < registry[j].Name
+		}
+		return registry[i].Priority < registry[j].Priority
+	})
+	cptool.languageRegistry = registry
+}
+
+// GetLanguageSources returns every language definition found in the languages paths and the built-in languages, ordered by the
+// language name and their priority. It contains the active definitions, the definitions shadowed by a definition with higher
+// priority and the rejected definitions.
+func (cptool *CPTool) GetLanguageSources() []LanguageSource {
+	return cptool.languageRegistry
+}
+
+// GetLanguageSourcesByName returns every definition of a language, ordered by their priority.
+func (cptool *CPTool) GetLanguageSourcesByName(name string) []LanguageSource {
+	sources := make([]LanguageSource, 0)
+	for _, source := range cptool.languageRegistry {
+		if source.Name == name {
+			sources = append(sources, source)
+		}
+	}
+	return sources
+}
+
+// languageDirectoryResult is a language directory read by readLanguagesDirectory, err is not nil when the directory is rejected.
+type languageDirectoryResult struct {
+	definition languageDefinition
+	err        error
+}
+
+// readLanguagesDirectory reads every language directory inside a languages path. The rejected language directories are returned
+// with their error.
+func readLanguagesDirectory(fs afero.Fs, languagesPath string) []languageDirectoryResult {
+	results := make([]languageDirectoryResult, 0)
+	info, err := fs.Stat(languagesPath)
+	if err != nil || !info.IsDir() {
+		return results
+	}
+
+	afero.Walk(fs, languagesPath, func(langPath string, info os.FileInfo, err error) error {
+		if info.IsDir() && langPath != languagesPath {
+			definition, err := readLanguageDefinition(fs, langPath)
+			if err != nil {
+				definition = languageDefinition{language: Language{Name: info.Name()}, path: langPath}
 			}
-			return nil
-		})
+			results = append(results, languageDirectoryResult{definition: definition, err: err})
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	return results
+}
+
+// getBuiltinLanguagesFs returns the built-in language definitions as an in-memory filesystem, every language directory is
+// placed in the root directory. Built-in languages are declared using command templates, because their scripts cannot be
+// executed from the binary.
+func (cptool *CPTool) getBuiltinLanguagesFs() afero.Fs {
+	memFs := afero.NewMemMapFs()
+	if cptool.builtinLanguages == nil {
+		return memFs
+	}
+	iofs.WalkDir(cptool.builtinLanguages, ".", func(filePath string, entry iofs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return memFs.MkdirAll(path.Join("/", filePath), os.ModePerm)
+		}
+		content, err := iofs.ReadFile(cptool.builtinLanguages, filePath)
+		if err != nil {
+			return err
+		}
+		return afero.WriteFile(memFs, path.Join("/", filePath), content, 0644)
+	})
+	return memFs
+}
+
+// ExportBuiltinLanguage writes the definition of a built-in language into a new directory named after the language inside
+// directory, so the language can be customized. When directory is empty, the language is exported into the langs directory of
+// the project root, where it overrides the built-in language. The path of the created language directory is returned.
+// ErrNoSuchLanguage returned when there is no built-in language with such name and ErrLanguageDirectoryExists returned when
+// the language directory already exists.
+func (cptool *CPTool) ExportBuiltinLanguage(name string, directory string) (string, error) {
+	builtinFs := cptool.getBuiltinLanguagesFs()
+	sourcePath := path.Join("/", name)
+	if info, err := builtinFs.Stat(sourcePath); len(name) == 0 || strings.Contains(name, "/") || err != nil || !info.IsDir() {
+		return "", ErrNoSuchLanguage
+	}
+
+	if len(directory) == 0 {
+		directory = path.Join(cptool.GetProjectRoot(), ".cptool", "langs")
+	}
+	targetPath := path.Join(cptool.resolvePath(directory), name)
+	if _, err := cptool.fs.Stat(targetPath); err == nil {
+		return "", ErrLanguageDirectoryExists
+	}
+	if err := cptool.copyLanguageDirectory(builtinFs, sourcePath, targetPath); err != nil {
+		return "", err
+	}
+	if cptool.logger != nil {
+		cptool.logger.Println(logger.VERBOSE, "Exported language to: ", targetPath)
+	}
+	return targetPath, nil
+}
+
+// copyLanguageDirectory copies the files of a language directory in sourceFs into targetPath. Every file beside lang.conf is a
+// script, so it is made executable.
+func (cptool *CPTool) copyLanguageDirectory(sourceFs afero.Fs, sourcePath string, targetPath string) error {
+	if err := cptool.fs.MkdirAll(targetPath, os.ModePerm); err != nil {
+		return err
+	}
+	files, err := afero.ReadDir(sourceFs, sourcePath)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+		content, err := afero.ReadFile(sourceFs, path.Join(sourcePath, file.Name()))
+		if err != nil {
+			return err
+		}
+		var perm os.FileMode = 0755
+		if file.Name() == "lang.conf" {
+			perm = 0644
+		}
+		if err := afero.WriteFile(cptool.fs, path.Join(targetPath, file.Name()), content, perm); err != nil {
+			return err
+		}
+		// the file mode of WriteFile is only applied to new files.
+		if err := cptool.fs.Chmod(path.Join(targetPath, file.Name()), perm); err != nil {
+			return err
+		}
 	}
+	return nil
 }
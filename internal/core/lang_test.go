@@ -1,9 +1,16 @@
 package core
 
 import (
+	"errors"
 	"os"
 	"path"
+	"reflect"
 	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/jauhararifin/cptool/langs"
+	"github.com/spf13/afero"
 )
 
 func TestGetLanguageFromDirectory(t *testing.T) {
@@ -202,7 +209,7 @@ func TestLoadAllLanguage(t *testing.T) {
 	if !ok {
 		t.Error("cptool should contain language lang_a")
 	}
-	if lang != languageA {
+	if !reflect.DeepEqual(lang, languageA) {
 		t.Error("language lang_a of cptool should be", languageA, ", but found", lang)
 	}
 }
@@ -247,7 +254,7 @@ func TestLoadAllLanguagesWithMultipleLanguages(t *testing.T) {
 	if !ok {
 		t.Error("cptool should contain language lang_a")
 	}
-	if lang != languageA {
+	if !reflect.DeepEqual(lang, languageA) {
 		t.Error("language lang_a of cptool should be", languageA, ", but found", lang)
 	}
 
@@ -264,7 +271,7 @@ func TestLoadAllLanguagesWithMultipleLanguages(t *testing.T) {
 	if !ok {
 		t.Error("cptool should contain language lang_b")
 	}
-	if lang != languageB {
+	if !reflect.DeepEqual(lang, languageB) {
 		t.Error("language lang_a of cptool should be", languageB, ", but found", lang)
 	}
 
@@ -281,7 +288,7 @@ func TestLoadAllLanguagesWithMultipleLanguages(t *testing.T) {
 	if !ok {
 		t.Error("cptool should contain language lang_c")
 	}
-	if lang != languageC {
+	if !reflect.DeepEqual(lang, languageC) {
 		t.Error("language lang_c of cptool should be", languageC, ", but found", lang)
 	}
 }
@@ -337,7 +344,7 @@ func TestLoadAllLanguagesWithMultipleName(t *testing.T) {
 	if !ok {
 		t.Error("cptool should contain language lang_c")
 	}
-	if lang != languageC {
+	if !reflect.DeepEqual(lang, languageC) {
 		t.Error("language lang_c of cptool should be", languageC, ", but found", lang)
 	}
 }
@@ -378,9 +385,9 @@ func TestGetAllLanguages(t *testing.T) {
 	}
 	matchA, matchB, matchC := false, false, false
 	for _, lang := range langList {
-		matchA = matchA || lang == cptool.languages["lang_a"]
-		matchB = matchB || lang == cptool.languages["lang_b"]
-		matchC = matchC || lang == cptool.languages["lang_c"]
+		matchA = matchA || reflect.DeepEqual(lang, cptool.languages["lang_a"])
+		matchB = matchB || reflect.DeepEqual(lang, cptool.languages["lang_b"])
+		matchC = matchC || reflect.DeepEqual(lang, cptool.languages["lang_c"])
 	}
 	if !matchA || !matchB || !matchC {
 		t.Error("language list should contain exactly the value of language map")
@@ -403,7 +410,7 @@ func TestGetLanguageByName(t *testing.T) {
 	if err != nil {
 		t.Error("GetLanguageByName should return the language with the right name")
 	}
-	if lang != cptool.languages["lang_a"] {
+	if !reflect.DeepEqual(lang, cptool.languages["lang_a"]) {
 		t.Error("GetLanguageByName should return", lang)
 	}
 }
@@ -461,7 +468,7 @@ func TestGetDefaultLanguage(t *testing.T) {
 
 	defaultLang, _ := cptool.GetDefaultLanguage()
 	langs, _ := cptool.GetAllLanguages()
-	if defaultLang != langs[0] && defaultLang != langs[1] && defaultLang != langs[2] {
+	if !reflect.DeepEqual(defaultLang, langs[0]) && !reflect.DeepEqual(defaultLang, langs[1]) && !reflect.DeepEqual(defaultLang, langs[2]) {
 		t.Error("GetDefaultLanguage should return", langs[0], "or", langs[1], "or", langs[2])
 	}
 }
@@ -497,12 +504,13 @@ func TestGetDefaultLanguageWithConfiguration(t *testing.T) {
 	}
 	config, _ := cptool.fs.Create("/etc/cptool/config")
 	config.WriteString("default_language = \"lang_b\"\n")
+	cptool.loadConfig()
 
 	defaultLang, err := cptool.GetDefaultLanguage()
 	if err != nil {
 		t.Error(err)
 	}
-	if defaultLang != cptool.languages["lang_b"] {
+	if !reflect.DeepEqual(defaultLang, cptool.languages["lang_b"]) {
 		t.Error("GetDefaultLanguage should return language b")
 	}
 }
@@ -517,3 +525,360 @@ func TestGetDefaultLanguageWithoutKnownLanguage(t *testing.T) {
 		t.Error("GetDefaultLanguage should return ErrNoSuchLanguage error")
 	}
 }
+
+func TestGetLanguageFromDirectoryWithCommands(t *testing.T) {
+	cptool := newTest()
+	cptool.fs.MkdirAll("/etc/cptool/langs/lang_a", os.ModePerm)
+	file, _ := cptool.fs.Create("/etc/cptool/langs/lang_a/lang.conf")
+	file.WriteString("extension=\"a\"\ncompile=[\"/bin/compiler\", \"-o\", \"{target}\", \"{source}\"]\n" +
+		"debug_compile=[\"/bin/compiler\", \"-g\", \"-o\", \"{target}\", \"{source}\"]\nrun=[\"{target}\"]\n")
+
+	lang, err := cptool.getLanguageFromDirectory("/etc/cptool/langs/lang_a")
+	if err != nil {
+		t.Error(err)
+	}
+	expected := Language{
+		Name:           "lang_a",
+		Extension:      "a",
+		VerboseName:    "lang_a",
+		Debuggable:     true,
+		CompileCommand: []string{"/bin/compiler", "-o", "{target}", "{source}"},
+		RunCommand:     []string{"{target}"},
+		DebugCommand:   []string{"/bin/compiler", "-g", "-o", "{target}", "{source}"},
+	}
+	if !reflect.DeepEqual(lang, expected) {
+		t.Error("language should be", expected, ", but found", lang)
+	}
+}
+
+func TestGetLanguageFromDirectoryWithCompileCommandAndRunScript(t *testing.T) {
+	cptool := newTest()
+	cptool.fs.MkdirAll("/etc/cptool/langs/lang_a", os.ModePerm)
+	file, _ := cptool.fs.Create("/etc/cptool/langs/lang_a/lang.conf")
+	file.WriteString("compile=[\"/bin/compiler\", \"{source}\", \"{target}\"]\n")
+
+	if _, err := cptool.getLanguageFromDirectory("/etc/cptool/langs/lang_a"); err == nil {
+		t.Error("language without run command and run script should be invalid")
+	}
+
+	cptool.fs.Create("/etc/cptool/langs/lang_a/run")
+	lang, err := cptool.getLanguageFromDirectory("/etc/cptool/langs/lang_a")
+	if err != nil {
+		t.Error(err)
+	}
+	if lang.RunScript != "/etc/cptool/langs/lang_a/run" || len(lang.CompileScript) > 0 || lang.Debuggable {
+		t.Error("language should use compile command and run script, found:", lang)
+	}
+}
+
+func prepareLanguageDirectories(cptool *CPTool, files map[string]string) {
+	for filePath, content := range files {
+		cptool.fs.MkdirAll(path.Dir(filePath), os.ModePerm)
+		file, _ := cptool.fs.Create(filePath)
+		file.WriteString(content)
+		file.Close()
+	}
+}
+
+func TestLoadAllLanguagesWithInheritance(t *testing.T) {
+	cptool := newTest()
+	prepareLanguageDirectories(cptool, map[string]string{
+		"/etc/cptool/langs/cpp/lang.conf": "verbose_name=\"C++\"\nextension=\"cpp\"\n" +
+			"compile=[\"/bin/g++\", \"-O2\", \"{flags}\", \"-o\", \"{target}\", \"{source}\"]\ncompile_flags=[\"-Wall\"]\n" +
+			"compile_artifacts=[\"{target}.o\"]\n",
+		"/etc/cptool/langs/cpp/run":          "",
+		"/etc/cptool/langs/cpp/debugcompile": "",
+		path.Join(cptool.homeDirectory, ".cptool/langs/cpp17/lang.conf"): "extends=\"cpp\"\nverbose_name=\"C++17\"\n" +
+			"compile_flags=[\"-std=c++17\"]\ndebug_flags=[\"-g\"]\n",
+		path.Join(cptool.workingDirectory, ".cptool/langs/cc/lang.conf"): "extends=\"cpp17\"\nextension=\"cc\"\n",
+	})
+
+	cptool.loadAllLanguages()
+
+	expected := Language{
+		Name:             "cc",
+		Extension:        "cc",
+		VerboseName:      "cc",
+		CompileCommand:   []string{"/bin/g++", "-O2", "{flags}", "-o", "{target}", "{source}"},
+		CompileArtifacts: []string{"{target}.o"},
+		RunScript:        "/etc/cptool/langs/cpp/run",
+		DebugScript:      "/etc/cptool/langs/cpp/debugcompile",
+		Debuggable:       true,
+		Extends:          "cpp17",
+		CompileFlags:     []string{"-Wall", "-std=c++17"},
+		DebugFlags:       []string{"-g"},
+	}
+	lang, err := cptool.GetLanguageByName("cc")
+	if err != nil {
+		t.Error(err)
+	}
+	if !reflect.DeepEqual(lang, expected) {
+		t.Error("language cc should be", expected, ", but found", lang)
+	}
+
+	program, args := lang.getCompileCommand(commandValues{source: "/sol.cc", target: "/program"}, false)
+	if program != "/bin/g++" || !reflect.DeepEqual(args, []string{"-O2", "-Wall", "-std=c++17", "-o", "/program", "/sol.cc"}) {
+		t.Error("compile flags should be inserted in place of {flags}, found:", program, args)
+	}
+	program, args = lang.getCompileCommand(commandValues{source: "/sol.cc", target: "/program"}, true)
+	if program != expected.DebugScript || !reflect.DeepEqual(args, []string{"/sol.cc", "/program", "-Wall", "-std=c++17", "-g"}) {
+		t.Error("debug flags should be passed to debugcompile script, found:", program, args)
+	}
+}
+
+func TestLoadAllLanguagesWithOverriddenParent(t *testing.T) {
+	cptool := newTest()
+	prepareLanguageDirectories(cptool, map[string]string{
+		"/etc/cptool/langs/cpp/lang.conf":                                 "extension=\"cpp\"\ncompile_flags=[\"-O2\"]\n",
+		"/etc/cptool/langs/cpp/compile":                                   "",
+		"/etc/cptool/langs/cpp/run":                                       "",
+		path.Join(cptool.workingDirectory, ".cptool/langs/cpp/lang.conf"): "extends=\"cpp\"\ncompile_flags=[\"-DLOCAL\"]\n",
+	})
+
+	cptool.loadAllLanguages()
+
+	lang, err := cptool.GetLanguageByName("cpp")
+	if err != nil {
+		t.Error(err)
+	}
+	if lang.CompileScript != "/etc/cptool/langs/cpp/compile" || !reflect.DeepEqual(lang.CompileFlags, []string{"-O2", "-DLOCAL"}) {
+		t.Error("language that extends its own name should extend the shadowed language, found:", lang)
+	}
+}
+
+func TestLoadAllLanguagesWithInvalidInheritance(t *testing.T) {
+	cptool := newTest()
+	prepareLanguageDirectories(cptool, map[string]string{
+		"/etc/cptool/langs/lang_a/lang.conf": "extends=\"lang_b\"\n",
+		"/etc/cptool/langs/lang_b/lang.conf": "extends=\"lang_a\"\n",
+		"/etc/cptool/langs/lang_c/lang.conf": "extends=\"lang_d\"\n",
+		"/etc/cptool/langs/lang_e/lang.conf": "extends=\"lang_a\"\n",
+	})
+
+	cptool.loadAllLanguages()
+
+	if len(cptool.languages) != 0 {
+		t.Error("languages with invalid inheritance should not be loaded, found:", cptool.languages)
+	}
+
+	definitions := map[string][]languageDefinition{}
+	for _, name := range []string{"lang_a", "lang_b", "lang_c"} {
+		definition, _ := cptool.readLanguageDirectory(path.Join("/etc/cptool/langs", name))
+		definitions[name] = []languageDefinition{definition}
+	}
+	_, err := resolveLanguage("lang_a", 0, definitions, nil)
+	if inheritanceErr, ok := err.(*LanguageInheritanceError); !ok || inheritanceErr.Err != ErrLanguageInheritanceCycle {
+		t.Error("resolveLanguage should return ErrLanguageInheritanceCycle, found:", err)
+	} else if inheritanceErr.Error() != "Language inheritance cycle: lang_a -> lang_b -> lang_a" {
+		t.Error("error should contain the inheritance chain, found:", inheritanceErr.Error())
+	}
+	_, err = resolveLanguage("lang_c", 0, definitions, nil)
+	if inheritanceErr, ok := err.(*LanguageInheritanceError); !ok || inheritanceErr.Err != ErrNoSuchParentLanguage {
+		t.Error("resolveLanguage should return ErrNoSuchParentLanguage, found:", err)
+	}
+}
+
+func TestLoadAllLanguagesWithLimitAdjustments(t *testing.T) {
+	cptool := newTest()
+	prepareLanguageDirectories(cptool, map[string]string{
+		"/etc/cptool/langs/java/lang.conf": "extension=\"java\"\ntime_factor=2.0\ntime_bonus=\"500ms\"\nmemory_bonus=64\n" +
+			"compile=[\"javac\", \"{source}\"]\nrun=[\"java\", \"{class}\"]\n",
+		"/etc/cptool/langs/java8/lang.conf":  "extends=\"java\"\ntime_factor=3.0\n",
+		"/etc/cptool/langs/factor/lang.conf": "time_factor=0.0\ncompile=[\"cc\"]\nrun=[\"{target}\"]\n",
+		"/etc/cptool/langs/bonus/lang.conf":  "time_bonus=\"soon\"\ncompile=[\"cc\"]\nrun=[\"{target}\"]\n",
+		"/etc/cptool/langs/memory/lang.conf": "memory_bonus=-1\ncompile=[\"cc\"]\nrun=[\"{target}\"]\n",
+	})
+
+	cptool.loadAllLanguages()
+
+	lang, err := cptool.GetLanguageByName("java8")
+	if err != nil {
+		t.Error(err)
+	}
+	if lang.TimeFactor != 3 || lang.TimeBonus != 500*time.Millisecond || lang.MemoryBonus != 64 {
+		t.Error("limit adjustments should be inherited and overridden, found:", lang)
+	}
+	for _, name := range []string{"factor", "bonus", "memory"} {
+		if _, err := cptool.GetLanguageByName(name); err == nil {
+			t.Error("language with invalid limit adjustment should not be loaded:", name)
+		}
+	}
+}
+
+func TestGetLimits(t *testing.T) {
+	language := Language{TimeFactor: 2, TimeBonus: time.Second, MemoryBonus: 64}
+	if timeLimit, memoryLimit := language.GetLimits(time.Second, 256); timeLimit != 3*time.Second || memoryLimit != 320 {
+		t.Error("limits should be adjusted, found:", timeLimit, memoryLimit)
+	}
+	if timeLimit, memoryLimit := language.GetLimits(0, 0); timeLimit != 0 || memoryLimit != 0 {
+		t.Error("unlimited limits should not be adjusted, found:", timeLimit, memoryLimit)
+	}
+	if timeLimit, memoryLimit := (Language{}).GetLimits(time.Second, 256); timeLimit != time.Second || memoryLimit != 256 {
+		t.Error("limits should not be changed without adjustments, found:", timeLimit, memoryLimit)
+	}
+}
+
+func TestLoadAllLanguagesWithInterpretedLanguage(t *testing.T) {
+	cptool := newTest()
+	prepareLanguageDirectories(cptool, map[string]string{
+		"/etc/cptool/langs/python/lang.conf": "extension=\"py\"\ninterpreted=true\nrun=[\"python3\", \"{source}\"]\n",
+		"/etc/cptool/langs/pypy/lang.conf":   "extends=\"python\"\nrun=[\"pypy3\", \"{source}\"]\n",
+		"/etc/cptool/langs/broken/lang.conf": "run=[\"python3\", \"{source}\"]\n",
+	})
+
+	cptool.loadAllLanguages()
+
+	if lang, err := cptool.GetLanguageByName("python"); err != nil || !lang.Interpreted {
+		t.Error("interpreted language without compile script should be loaded, found:", lang, err)
+	}
+	if lang, err := cptool.GetLanguageByName("pypy"); err != nil || !lang.Interpreted || lang.Extension != "py" {
+		t.Error("interpreted should be inherited, found:", lang, err)
+	}
+	if _, err := cptool.GetLanguageByName("broken"); err == nil {
+		t.Error("compiled language without compile command should not be loaded")
+	}
+}
+
+func TestLoadAllLanguagesWithBuiltinLanguages(t *testing.T) {
+	cptool := newTest()
+	cptool.builtinLanguages = fstest.MapFS{
+		"c/lang.conf":   {Data: []byte("extension=\"c\"\ncompile=[\"gcc\", \"-o\", \"{target}\", \"{source}\"]\nrun=[\"{target}\"]\n")},
+		"cpp/lang.conf": {Data: []byte("extension=\"cpp\"\ncompile=[\"g++\", \"-o\", \"{target}\", \"{source}\"]\nrun=[\"{target}\"]\n")},
+	}
+	prepareLanguageDirectories(cptool, map[string]string{
+		"/etc/cptool/langs/cpp/lang.conf":   "extends=\"cpp\"\ncompile_flags=[\"-DLOCAL\"]\n",
+		"/etc/cptool/langs/c/lang.conf":     "extension=\"c\"\nrun=[\"{target}\"]\n",
+		"/etc/cptool/langs/c/compile":       "",
+		"/etc/cptool/langs/cpp17/lang.conf": "extends=\"cpp\"\ncompile_flags=[\"-std=c++17\"]\n",
+	})
+
+	cptool.loadAllLanguages()
+
+	if lang, err := cptool.GetLanguageByName("c"); err != nil || lang.CompileScript != "/etc/cptool/langs/c/compile" {
+		t.Error("language in configuration path should override built-in language, found:", lang, err)
+	}
+	lang, err := cptool.GetLanguageByName("cpp")
+	if err != nil || lang.CompileCommand[0] != "g++" || !reflect.DeepEqual(lang.CompileFlags, []string{"-DLOCAL"}) {
+		t.Error("language should extend the built-in language with the same name, found:", lang, err)
+	}
+	lang, err = cptool.GetLanguageByName("cpp17")
+	if err != nil || !reflect.DeepEqual(lang.CompileFlags, []string{"-DLOCAL", "-std=c++17"}) {
+		t.Error("language should extend the overridden built-in language, found:", lang, err)
+	}
+}
+
+func TestStockLanguages(t *testing.T) {
+	cptool := newTest()
+	cptool.builtinLanguages = langs.FS
+
+	cptool.loadAllLanguages()
+
+	for _, name := range []string{"c", "cpp", "cpp11", "java", "kotlin", "pas", "python3"} {
+		lang, err := cptool.GetLanguageByName(name)
+		if err != nil {
+			t.Error("stock language should be loaded:", name)
+		}
+		if len(lang.CompileScript) > 0 || len(lang.RunScript) > 0 || len(lang.DebugScript) > 0 {
+			t.Error("stock language should be declared using command templates:", name)
+		}
+	}
+}
+
+func TestExportBuiltinLanguage(t *testing.T) {
+	cptool := newTest()
+	cptool.builtinLanguages = fstest.MapFS{
+		"c/lang.conf": {Data: []byte("extension=\"c\"\n")},
+		"c/compile":   {Data: []byte("#!/bin/bash\n")},
+	}
+
+	languagePath, err := cptool.ExportBuiltinLanguage("c", "")
+	if err != nil {
+		t.Error(err)
+	}
+	if languagePath != path.Join(cptool.workingDirectory, ".cptool/langs/c") {
+		t.Error("language should be exported to project's langs directory, found:", languagePath)
+	}
+	if content, _ := afero.ReadFile(cptool.fs, path.Join(languagePath, "lang.conf")); string(content) != "extension=\"c\"\n" {
+		t.Error("lang.conf should be exported, found:", string(content))
+	}
+	if info, err := cptool.fs.Stat(path.Join(languagePath, "compile")); err != nil || info.Mode().Perm()&0100 == 0 {
+		t.Error("exported script should be executable")
+	}
+
+	if _, err := cptool.ExportBuiltinLanguage("c", ""); err != ErrLanguageDirectoryExists {
+		t.Error("exporting to existing directory should return ErrLanguageDirectoryExists, found:", err)
+	}
+	if _, err := cptool.ExportBuiltinLanguage("go", "/tmp/langs"); err != ErrNoSuchLanguage {
+		t.Error("exporting unknown language should return ErrNoSuchLanguage, found:", err)
+	}
+}
+
+func TestGetLanguageSources(t *testing.T) {
+	cptool := newTest()
+	cptool.builtinLanguages = fstest.MapFS{
+		"cpp/lang.conf": {Data: []byte("extension=\"cpp\"\ncompile=[\"g++\", \"{source}\"]\nrun=[\"{target}\"]\n")},
+	}
+	prepareLanguageDirectories(cptool, map[string]string{
+		path.Join(cptool.workingDirectory, ".cptool/langs/cpp/lang.conf"): "extends=\"cpp\"\ncompile_flags=[\"-DLOCAL\"]\n",
+		"/home/test/.cptool/langs/cpp/lang.conf":                          "extension=\n",
+		"/etc/cptool/langs/cpp/lang.conf":                                 "extension=\"cpp\"\ncompile=[\"clang++\", \"{source}\"]\nrun=[\"{target}\"]\n",
+		"/etc/cptool/langs/broken/lang.conf":                              "extension=\"x\"\n",
+	})
+
+	cptool.loadAllLanguages()
+
+	expected := []LanguageSource{
+		{Name: "broken", Path: "/etc/cptool/langs/broken", Priority: 2},
+		{Name: "cpp", Path: path.Join(cptool.workingDirectory, ".cptool/langs/cpp"), Priority: 0, Active: true},
+		{Name: "cpp", Path: "/home/test/.cptool/langs/cpp", Priority: 1},
+		{Name: "cpp", Path: "/etc/cptool/langs/cpp", Priority: 2},
+		{Name: "cpp", Path: "builtin:cpp", Priority: 3},
+	}
+	sources := cptool.GetLanguageSources()
+	if len(sources) != len(expected) {
+		t.Error("registry should contain every definition, found:", sources)
+		return
+	}
+	for i, source := range sources {
+		err := source.Err
+		source.Err = nil
+		if !reflect.DeepEqual(source, expected[i]) {
+			t.Error("language source should be", expected[i], ", but found", source)
+		}
+		if rejected := i == 0 || i == 2; rejected != (err != nil) {
+			t.Error("only rejected definitions should have error, found:", sources[i])
+		}
+	}
+
+	invalidErr, ok := sources[0].Err.(*InvalidLanguageError)
+	if !ok || invalidErr.Err != ErrInvalidLanguageDirectory || invalidErr.Path != "/etc/cptool/langs/broken" {
+		t.Error("definition without compile command should be rejected, found:", sources[0].Err)
+	}
+	if _, ok := sources[2].Err.(*InvalidLanguageError); !ok || !errors.Is(sources[2].Err, ErrInvalidLanguageConfigurationFile) {
+		t.Error("rejected definition should explain the error, found:", sources[2].Err)
+	}
+	if lang, _ := cptool.GetLanguageByName("cpp"); len(lang.CompileCommand) == 0 || lang.CompileCommand[0] != "clang++" {
+		t.Error("language should extend the shadowed definition that is not rejected, found:", lang)
+	}
+	if sources := cptool.GetLanguageSourcesByName("cpp"); len(sources) != 4 || !sources[0].Active {
+		t.Error("language sources should be filtered by name, found:", sources)
+	}
+}
+
+func TestGetLanguageSourcesWithInvalidInheritance(t *testing.T) {
+	cptool := newTest()
+	prepareLanguageDirectories(cptool, map[string]string{
+		"/etc/cptool/langs/lang_a/lang.conf": "extends=\"lang_b\"\n",
+	})
+
+	cptool.loadAllLanguages()
+
+	sources := cptool.GetLanguageSourcesByName("lang_a")
+	if len(sources) != 1 || sources[0].Active {
+		t.Error("language with invalid inheritance should not be active, found:", sources)
+		return
+	}
+	if inheritanceErr, ok := sources[0].Err.(*LanguageInheritanceError); !ok || inheritanceErr.Err != ErrNoSuchParentLanguage {
+		t.Error("language source should contain the inheritance error, found:", sources[0].Err)
+	}
+}
@@ -0,0 +1,141 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/jauhararifin/cptool/internal/config"
+	"github.com/jauhararifin/cptool/internal/logger"
+	"github.com/spf13/afero"
+)
+
+// ErrBuiltinLanguage indicates the language is built into cptool, so its definition cannot be changed. Use ExportBuiltinLanguage
+// to customize it.
+var ErrBuiltinLanguage = errors.New("Language is built into cptool")
+
+// ErrInvalidLanguageName indicates the language name cannot be used as a directory name.
+var ErrInvalidLanguageName = errors.New("Invalid language name")
+
+const compileScriptTemplate = `#!/bin/bash
+
+SOURCE=$1
+DEST=$2
+
+echo "Edit $0 to compile $SOURCE into $DEST" >&2
+
+exit 1
+`
+
+const runScriptTemplate = `#!/bin/bash
+
+PROGRAM=$1
+
+"$PROGRAM"
+
+exit $?
+`
+
+// GetLanguagesPath returns the langs directory of a configuration layer. The layers are the same as configuration file's layers:
+// "project", "cptool_home", "user" and "system". ErrNoSuchLayer returned when there is no layer with such name.
+func (cptool *CPTool) GetLanguagesPath(layerName string) (string, error) {
+	for _, layer := range cptool.getConfigLayerCandidates() {
+		if layer.Name == layerName {
+			return path.Join(path.Dir(layer.Path), "langs"), nil
+		}
+	}
+	return "", config.ErrNoSuchLayer
+}
+
+// GetLanguageDirectory returns the directory of the loaded language's definition. Built-in languages have no directory, so
+// ErrBuiltinLanguage returned for them. ErrNoSuchLanguage returned when no language with such name is loaded.
+func (cptool *CPTool) GetLanguageDirectory(name string) (string, error) {
+	if _, ok := cptool.languages[name]; !ok || len(cptool.languageDefinitions[name]) == 0 {
+		return "", ErrNoSuchLanguage
+	}
+	directory := cptool.languageDefinitions[name][0].path
+	if strings.HasPrefix(directory, builtinLanguagePrefix) {
+		return "", ErrBuiltinLanguage
+	}
+	return directory, nil
+}
+
+// AddLanguage creates a new language directory named name in the langs directory of a configuration layer. When from is empty,
+// the language is created with lang.conf and template compile and run scripts that should be edited. Otherwise, the definition
+// of the loaded language named from is copied, including built-in languages. The scripts are made executable and the created
+// directory is validated, it is removed when the language is not valid. ErrLanguageDirectoryExists returned when the language
+// directory already exists in the layer.
+func (cptool *CPTool) AddLanguage(layerName string, name string, from string) (Language, error) {
+	if len(name) == 0 || strings.ContainsAny(name, "/\\") || strings.HasPrefix(name, ".") {
+		return Language{}, ErrInvalidLanguageName
+	}
+	langsPath, err := cptool.GetLanguagesPath(layerName)
+	if err != nil {
+		return Language{}, err
+	}
+	languagePath := path.Join(langsPath, name)
+	if _, err := cptool.fs.Stat(languagePath); err == nil {
+		return Language{}, ErrLanguageDirectoryExists
+	}
+
+	if len(from) > 0 {
+		if _, ok := cptool.languages[from]; !ok || len(cptool.languageDefinitions[from]) == 0 {
+			return Language{}, ErrNoSuchLanguage
+		}
+		sourceFs, sourcePath := cptool.fs, cptool.languageDefinitions[from][0].path
+		if strings.HasPrefix(sourcePath, builtinLanguagePrefix) {
+			sourceFs, sourcePath = cptool.getBuiltinLanguagesFs(), path.Join("/", from)
+		}
+		err = cptool.copyLanguageDirectory(sourceFs, sourcePath, languagePath)
+	} else {
+		templateFs := afero.NewMemMapFs()
+		afero.WriteFile(templateFs, "/lang.conf", []byte(fmt.Sprintf("verbose_name=%q\nextension=%q\n", name, name)), 0644)
+		afero.WriteFile(templateFs, "/compile", []byte(compileScriptTemplate), 0755)
+		afero.WriteFile(templateFs, "/run", []byte(runScriptTemplate), 0755)
+		err = cptool.copyLanguageDirectory(templateFs, "/", languagePath)
+	}
+	if err != nil {
+		cptool.fs.RemoveAll(languagePath)
+		return Language{}, err
+	}
+
+	language, err := cptool.getLanguageFromDirectory(languagePath)
+	if err != nil {
+		cptool.fs.RemoveAll(languagePath)
+		return Language{}, err
+	}
+	if cptool.logger != nil {
+		cptool.logger.Println(logger.VERBOSE, "Language created in: ", languagePath)
+	}
+	return language, nil
+}
+
+// RemoveLanguage removes the language directory named name from the langs directory of a configuration layer, and returns the
+// path of the removed directory. ErrNoSuchLanguage returned when the layer has no such language directory.
+func (cptool *CPTool) RemoveLanguage(layerName string, name string) (string, error) {
+	if len(name) == 0 || strings.ContainsAny(name, "/\\") || strings.HasPrefix(name, ".") {
+		return "", ErrInvalidLanguageName
+	}
+	langsPath, err := cptool.GetLanguagesPath(layerName)
+	if err != nil {
+		return "", err
+	}
+	languagePath := path.Join(langsPath, name)
+	if info, err := cptool.fs.Stat(languagePath); err != nil || !info.IsDir() {
+		return "", ErrNoSuchLanguage
+	}
+	if err := cptool.fs.RemoveAll(languagePath); err != nil {
+		return "", err
+	}
+	if cptool.logger != nil {
+		cptool.logger.Println(logger.VERBOSE, "Language removed from: ", languagePath)
+	}
+	return languagePath, nil
+}
+
+// ValidateLanguageDirectory checks whether a directory contains a valid language definition. The language's parent is searched
+// in the loaded languages.
+func (cptool *CPTool) ValidateLanguageDirectory(languagePath string) (Language, error) {
+	return cptool.getLanguageFromDirectory(languagePath)
+}
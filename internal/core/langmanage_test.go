@@ -0,0 +1,136 @@
+package core
+
+import (
+	"path"
+	"testing"
+	"testing/fstest"
+
+	"github.com/jauhararifin/cptool/internal/config"
+	"github.com/spf13/afero"
+)
+
+func TestGetLanguagesPath(t *testing.T) {
+	cptool := newTest()
+
+	if langsPath, err := cptool.GetLanguagesPath("project"); err != nil || langsPath != path.Join(cptool.workingDirectory, ".cptool/langs") {
+		t.Error("project languages path should be in the project root, found:", langsPath, err)
+	}
+	if langsPath, err := cptool.GetLanguagesPath("system"); err != nil || langsPath != "/etc/cptool/langs" {
+		t.Error("system languages path should be /etc/cptool/langs, found:", langsPath, err)
+	}
+	if _, err := cptool.GetLanguagesPath("unknown"); err != config.ErrNoSuchLayer {
+		t.Error("unknown layer should return ErrNoSuchLayer, found:", err)
+	}
+}
+
+func TestAddLanguage(t *testing.T) {
+	cptool := newTest()
+
+	language, err := cptool.AddLanguage("user", "sh", "")
+	if err != nil {
+		t.Error(err)
+	}
+	languagePath := path.Join(cptool.homeDirectory, ".cptool/langs/sh")
+	if language.Name != "sh" || language.CompileScript != path.Join(languagePath, "compile") {
+		t.Error("language should be created with template scripts, found:", language)
+	}
+	for _, script := range []string{"compile", "run"} {
+		if info, err := cptool.fs.Stat(path.Join(languagePath, script)); err != nil || info.Mode().Perm()&0100 == 0 {
+			t.Error("generated script should be executable:", script)
+		}
+	}
+
+	if _, err := cptool.AddLanguage("user", "sh", ""); err != ErrLanguageDirectoryExists {
+		t.Error("adding existing language should return ErrLanguageDirectoryExists, found:", err)
+	}
+	if _, err := cptool.AddLanguage("user", "../sh", ""); err != ErrInvalidLanguageName {
+		t.Error("adding language with path should return ErrInvalidLanguageName, found:", err)
+	}
+	if _, err := cptool.AddLanguage("user", "other", "missing"); err != ErrNoSuchLanguage {
+		t.Error("copying unknown language should return ErrNoSuchLanguage, found:", err)
+	}
+}
+
+func TestAddLanguageFromExistingLanguage(t *testing.T) {
+	cptool := newTest()
+	cptool.builtinLanguages = fstest.MapFS{
+		"cpp/lang.conf": {Data: []byte("extension=\"cpp\"\ncompile=[\"g++\", \"{source}\"]\nrun=[\"{target}\"]\n")},
+	}
+	prepareLanguageDirectories(cptool, map[string]string{
+		"/etc/cptool/langs/pas/lang.conf": "extension=\"pas\"\n",
+		"/etc/cptool/langs/pas/compile":   "#!/bin/bash\n",
+		"/etc/cptool/langs/pas/run":       "#!/bin/bash\n",
+	})
+	cptool.fs.Chmod("/etc/cptool/langs/pas/compile", 0644)
+	cptool.loadAllLanguages()
+
+	language, err := cptool.AddLanguage("project", "mypas", "pas")
+	if err != nil {
+		t.Error(err)
+	}
+	languagePath := path.Join(cptool.workingDirectory, ".cptool/langs/mypas")
+	if language.Extension != "pas" || language.CompileScript != path.Join(languagePath, "compile") {
+		t.Error("language should be copied from existing language, found:", language)
+	}
+	if info, err := cptool.fs.Stat(path.Join(languagePath, "compile")); err != nil || info.Mode().Perm()&0100 == 0 {
+		t.Error("copied script should be executable")
+	}
+
+	language, err = cptool.AddLanguage("project", "mycpp", "cpp")
+	if err != nil || language.Extension != "cpp" || language.CompileCommand[0] != "g++" {
+		t.Error("language should be copied from built-in language, found:", language, err)
+	}
+}
+
+func TestAddInvalidLanguage(t *testing.T) {
+	cptool := newTest()
+	prepareLanguageDirectories(cptool, map[string]string{
+		"/etc/cptool/langs/broken/lang.conf": "extends=\"missing\"\n",
+	})
+	cptool.languageDefinitions = map[string][]languageDefinition{"broken": {{path: "/etc/cptool/langs/broken"}}}
+	cptool.languages["broken"] = Language{Name: "broken"}
+
+	if _, err := cptool.AddLanguage("user", "copy", "broken"); err == nil {
+		t.Error("adding invalid language should return error")
+	}
+	if _, err := cptool.fs.Stat(path.Join(cptool.homeDirectory, ".cptool/langs/copy")); err == nil {
+		t.Error("invalid language directory should be removed")
+	}
+}
+
+func TestRemoveLanguage(t *testing.T) {
+	cptool := newTest()
+	cptool.AddLanguage("system", "sh", "")
+
+	languagePath, err := cptool.RemoveLanguage("system", "sh")
+	if err != nil || languagePath != "/etc/cptool/langs/sh" {
+		t.Error("language should be removed, found:", languagePath, err)
+	}
+	if exists, _ := afero.DirExists(cptool.fs, languagePath); exists {
+		t.Error("language directory should be removed")
+	}
+	if _, err := cptool.RemoveLanguage("system", "sh"); err != ErrNoSuchLanguage {
+		t.Error("removing missing language should return ErrNoSuchLanguage, found:", err)
+	}
+}
+
+func TestGetLanguageDirectory(t *testing.T) {
+	cptool := newTest()
+	cptool.builtinLanguages = fstest.MapFS{
+		"py/lang.conf": {Data: []byte("interpreted=true\nrun=[\"python3\", \"{source}\"]\n")},
+	}
+	prepareLanguageDirectories(cptool, map[string]string{
+		"/etc/cptool/langs/rb/lang.conf": "interpreted=true\nrun=[\"ruby\", \"{source}\"]\n",
+	})
+	cptool.loadAllLanguages()
+
+	if directory, err := cptool.GetLanguageDirectory("rb"); err != nil || directory != "/etc/cptool/langs/rb" {
+		t.Error("language directory should be returned, found:", directory, err)
+	}
+	if _, err := cptool.GetLanguageDirectory("py"); err != ErrBuiltinLanguage {
+		t.Error("built-in language should return ErrBuiltinLanguage, found:", err)
+	}
+	if _, err := cptool.GetLanguageDirectory("go"); err != ErrNoSuchLanguage {
+		t.Error("unknown language should return ErrNoSuchLanguage, found:", err)
+	}
+}
@@ -1,64 +1,189 @@
 package core
 
 import (
+	"io"
+	iofs "io/fs"
 	"os"
 	"os/user"
+	"time"
 
+	"github.com/jauhararifin/cptool/internal/config"
 	"github.com/jauhararifin/cptool/internal/executioner"
 	"github.com/jauhararifin/cptool/internal/logger"
+	"github.com/jauhararifin/cptool/langs"
 	"github.com/spf13/afero"
 )
 
 // CPTool stores information about this tool. The information includes version, all known languages, and configuration directories.
 type CPTool struct {
-	languages map[string]Language
+	languages           map[string]Language
+	languageDefinitions map[string][]languageDefinition
+	languageRegistry    []LanguageSource
+	builtinLanguages    iofs.FS
 
 	exec executioner.Exec
 
 	fs                  afero.Fs
 	workingDirectory    string
+	projectRoot         string
 	cptoolHomeDirectory string
 	homeDirectory       string
+	environ             []string
+	stderr              io.Writer
 
 	logger *logger.Logger
+	clock  func() time.Time
+
+	config         config.Config
+	configProvided bool
+	problem        ProblemConfig
+}
+
+// Option configures a cptool instance created by New.
+type Option func(cptool *CPTool)
+
+// WithExec sets the executioner used to run compilers, solutions, checkers and interactors. By default, the programs are run
+// as the operating system's processes.
+func WithExec(exec executioner.Exec) Option {
+	return func(cptool *CPTool) {
+		cptool.exec = exec
+	}
+}
+
+// WithFs sets the filesystem used to read and write every file. By default, the operating system's filesystem is used.
+func WithFs(fs afero.Fs) Option {
+	return func(cptool *CPTool) {
+		cptool.fs = fs
+	}
+}
+
+// WithWorkingDirectory sets the working directory, the project root is searched from it. By default, the process's working
+// directory is used.
+func WithWorkingDirectory(directory string) Option {
+	return func(cptool *CPTool) {
+		cptool.workingDirectory = directory
+	}
+}
+
+// WithHomeDirectory sets the user's home directory, ~/.cptool inside it is one of the configuration paths. By default, the
+// current user's home directory is used.
+func WithHomeDirectory(directory string) Option {
+	return func(cptool *CPTool) {
+		cptool.homeDirectory = directory
+	}
 }
 
-// New create new cptool instance. This instance contains working directory, cptool home directory, user home directory, and logger
-func New(exec executioner.Exec, log *logger.Logger) (*CPTool, error) {
-	cwd, err := os.Getwd()
-	if err != nil {
-		return nil, err
+// WithCptoolHomeDirectory sets the cptool home directory. By default, $CPTOOL_HOME is used.
+func WithCptoolHomeDirectory(directory string) Option {
+	return func(cptool *CPTool) {
+		cptool.cptoolHomeDirectory = directory
 	}
+}
+
+// WithEnviron sets the environment variables, in "key=value" form, that override the configuration. By default, the process's
+// environment variables are used.
+func WithEnviron(environ []string) Option {
+	return func(cptool *CPTool) {
+		cptool.environ = environ
+	}
+}
 
-	user, err := user.Current()
-	if err != nil {
-		return nil, err
+// WithStderr sets where the stderr of solutions, checkers and interactors is written when testing. By default, the process's
+// stderr is used.
+func WithStderr(stderr io.Writer) Option {
+	return func(cptool *CPTool) {
+		cptool.stderr = stderr
 	}
+}
 
-	if exec == nil {
-		exec = executioner.NewOSExec()
+// WithLogger sets the logger. By default, the logs are printed to stderr in INFO level.
+func WithLogger(log *logger.Logger) Option {
+	return func(cptool *CPTool) {
+		cptool.logger = log
 	}
+}
 
-	if log == nil {
-		log = logger.New(os.Stderr, logger.INFO)
+// WithClock sets the function that returns current time, it is used to measure durations and to fill the date of templates.
+// By default, time.Now is used.
+func WithClock(now func() time.Time) Option {
+	return func(cptool *CPTool) {
+		cptool.clock = now
 	}
+}
 
-	return &CPTool{
-		languages: make(map[string]Language),
+// WithConfig sets the global configuration. The configuration files are not loaded when cptool bootstrapped, so the
+// configuration is used as is. By default, the configuration is loaded from the configuration files.
+func WithConfig(conf config.Config) Option {
+	return func(cptool *CPTool) {
+		cptool.config = conf
+		cptool.configProvided = true
+	}
+}
 
-		exec: executioner.NewOSExec(),
+// New create new cptool instance. This instance contains working directory, cptool home directory, user home directory, and logger.
+// The defaults are taken from the running process and can be replaced using options, the current user is only looked up when
+// home directory is not provided.
+func New(opts ...Option) (*CPTool, error) {
+	cptool := &CPTool{
+		languages:        make(map[string]Language),
+		builtinLanguages: langs.FS,
 
-		fs:                  afero.NewOsFs(),
-		workingDirectory:    cwd,
 		cptoolHomeDirectory: os.Getenv("CPTOOL_HOME"),
-		homeDirectory:       user.HomeDir,
+		environ:             os.Environ(),
+		stderr:              os.Stderr,
+
+		config: config.Default(),
+	}
+	for _, opt := range opts {
+		opt(cptool)
+	}
+
+	if cptool.exec == nil {
+		cptool.exec = executioner.NewOSExec()
+	}
+	if cptool.fs == nil {
+		cptool.fs = afero.NewOsFs()
+	}
+	if len(cptool.workingDirectory) == 0 {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+		cptool.workingDirectory = cwd
+	}
+	if len(cptool.homeDirectory) == 0 {
+		user, err := user.Current()
+		if err != nil {
+			return nil, err
+		}
+		cptool.homeDirectory = user.HomeDir
+	}
+	if cptool.logger == nil {
+		cptool.logger = logger.New(os.Stderr, logger.INFO)
+	}
+	if cptool.clock == nil {
+		cptool.clock = time.Now
+	}
+
+	cptool.projectRoot = cptool.findProjectRoot()
+	return cptool, nil
+}
 
-		logger: log,
-	}, nil
+// Copy returns a shallow copy of cptool. The copy shares the loaded languages, but setting its configuration or problem
+// configuration doesn't affect the original, so it can be used to test a solution using different settings concurrently.
+func (cptool *CPTool) Copy() *CPTool {
+	copied := *cptool
+	return &copied
 }
 
-// Bootstrap will bootstrap cptool. The bootstrap process will load all language from known directories.
+// Bootstrap will bootstrap cptool. The bootstrap process will load global configuration, load all language from known directories
+// and load problem configuration in current working directory. An error is returned when a configuration file is malformed.
 func (cptool *CPTool) Bootstrap() error {
+	if !cptool.configProvided {
+		if err := cptool.loadConfig(); err != nil {
+			return err
+		}
+	}
 	cptool.loadAllLanguages()
-	return nil
+	return cptool.loadProblemConfig()
 }
@@ -5,7 +5,9 @@ import (
 	"os"
 	"os/user"
 	"testing"
+	"time"
 
+	"github.com/jauhararifin/cptool/internal/config"
 	"github.com/jauhararifin/cptool/internal/executioner"
 	"github.com/jauhararifin/cptool/internal/logger"
 	"github.com/spf13/afero"
@@ -21,13 +23,15 @@ func newTest() *CPTool {
 		workingDirectory:    "/home/test/cptool",
 		cptoolHomeDirectory: "/home/test/.cptool",
 		homeDirectory:       "/home/test/",
+		stderr:              os.Stderr,
 
 		logger: logger.New(new(bytes.Buffer), 100),
+		clock:  time.Now,
 	}
 }
 
 func newDefault() (*CPTool, error) {
-	return New(nil, nil)
+	return New()
 }
 
 func getCptoolMemExec(cptool *CPTool) *executioner.MemExec {
@@ -60,3 +64,51 @@ func TestBootstrap(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestNewWithOptions(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	fs.MkdirAll("/project/.cptool", 0755)
+	fs.MkdirAll("/project/problem", 0755)
+	afero.WriteFile(fs, "/project/.cptool/config", []byte("jobs = 2\n"), 0644)
+	exec := executioner.NewMemExec()
+	now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	conf := config.Default()
+	conf.Jobs = 4
+
+	cptool, err := New(
+		WithExec(exec),
+		WithFs(fs),
+		WithWorkingDirectory("/project/problem"),
+		WithHomeDirectory("/home/test"),
+		WithCptoolHomeDirectory("/opt/cptool"),
+		WithEnviron([]string{}),
+		WithLogger(logger.New(new(bytes.Buffer), logger.INFO)),
+		WithClock(func() time.Time { return now }),
+		WithConfig(conf),
+	)
+	if err != nil {
+		t.Error(err)
+	}
+	if cptool.exec != exec || cptool.fs != fs {
+		t.Error("New should use the provided exec and filesystem")
+	}
+	if cptool.workingDirectory != "/project/problem" || cptool.homeDirectory != "/home/test" ||
+		cptool.cptoolHomeDirectory != "/opt/cptool" {
+		t.Error("New should use the provided directories, found:", cptool.workingDirectory, cptool.homeDirectory,
+			cptool.cptoolHomeDirectory)
+	}
+	if cptool.GetProjectRoot() != "/project" {
+		t.Error("project root should be searched in the provided filesystem, found:", cptool.GetProjectRoot())
+	}
+	if !cptool.clock().Equal(now) {
+		t.Error("New should use the provided clock")
+	}
+
+	cptool.builtinLanguages = nil
+	if err := cptool.Bootstrap(); err != nil {
+		t.Error(err)
+	}
+	if cptool.GetConfig().Jobs != 4 {
+		t.Error("provided configuration should not be replaced by configuration files, found:", cptool.GetConfig().Jobs)
+	}
+}
@@ -0,0 +1,163 @@
+package core
+
+import (
+	"errors"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/jauhararifin/cptool/internal/logger"
+)
+
+// ProblemConfig stores configuration of the problem in current working directory. Name is the problem's name, like the one
+// imported from Competitive Companion. TimeLimit and MemoryLimit (in megabytes) are the limits of a single test case, zero means
+// unlimited. Checker contains path to a program that checks solution's output, it is executed with three arguments: input file,
+// solution's output file and expected output file, and should exit with zero status when the output is accepted. When Checker is
+// empty, the output must be exactly equal to the expected output. TestPattern contains glob pattern of test cases' input file
+// relative to current working directory like "tests/*.in". DefaultLanguage overrides default language in "config" file.
+// Interactor contains path to a program that interacts with the solution, its stdout is connected to solution's stdin and its
+// stdin is connected to solution's stdout. Interactor is executed with three arguments: input file, output file and expected
+// output file, and should exit with zero status when the solution is accepted.
+type ProblemConfig struct {
+	Name            string
+	TimeLimit       time.Duration
+	MemoryLimit     int
+	Checker         string
+	TestPattern     string
+	DefaultLanguage string
+	Interactor      string
+}
+
+// ErrInvalidProblemConfigurationFile indicates problem configuration file has invalid format.
+var ErrInvalidProblemConfigurationFile = errors.New("Invalid problem configuration file")
+
+type problemConfigFile struct {
+	Name            string `toml:"name,omitempty"`
+	URL             string `toml:"url,omitempty"`
+	TimeLimit       string `toml:"time_limit,omitempty"`
+	MemoryLimit     int    `toml:"memory_limit,omitempty"`
+	Checker         string `toml:"checker,omitempty"`
+	TestPattern     string `toml:"test_pattern,omitempty"`
+	DefaultLanguage string `toml:"default_language,omitempty"`
+	Interactor      string `toml:"interactor,omitempty"`
+}
+
+// GetProblemConfig returns configuration of the problem in current working directory. The configuration is loaded from
+// "problem.toml" or ".cptool/problem.toml" in current working directory when cptool bootstrapped. Below is example of
+// problem configuration file:
+//
+//     time_limit = "2s"
+//     memory_limit = 256
+//     checker = "checker"
+//     test_pattern = "tests/*.in"
+//     default_language = "cpp11"
+//
+func (cptool *CPTool) GetProblemConfig() ProblemConfig {
+	return cptool.problem
+}
+
+// SetProblemConfig sets configuration of the problem in current working directory. This can be used to override problem
+// configuration that loaded from problem configuration file.
+func (cptool *CPTool) SetProblemConfig(config ProblemConfig) {
+	cptool.problem = config
+}
+
+// GetSolutionProblemConfig returns configuration of the problem that a solution belongs to. When the solution is outside current
+// working directory and its directory has "problem.toml" or ".cptool/problem.toml", the configuration is loaded from that file, so
+// "cptool test problems/a/sol.cpp problems/a/" uses the limits of problems/a. Otherwise it returns GetProblemConfig.
+// ErrInvalidProblemConfigurationFile returned when the solution's problem configuration file is malformed.
+func (cptool *CPTool) GetSolutionProblemConfig(solution Solution) (ProblemConfig, error) {
+	directory := path.Dir(solution.Path)
+	if filepath.Clean(directory) == filepath.Clean(cptool.workingDirectory) {
+		return cptool.problem, nil
+	}
+	config, found, err := cptool.readProblemConfig(directory)
+	if err != nil || !found {
+		return cptool.problem, err
+	}
+	return config, nil
+}
+
+func (cptool *CPTool) getProblemConfigPaths(directory string) []string {
+	return []string{
+		path.Join(directory, "problem.toml"),
+		path.Join(directory, ".cptool", "problem.toml"),
+	}
+}
+
+func (cptool *CPTool) loadProblemConfig() error {
+	config, found, err := cptool.readProblemConfig(cptool.workingDirectory)
+	if err != nil {
+		return err
+	}
+	if found {
+		cptool.problem = config
+	}
+	return nil
+}
+
+// readProblemConfig reads the problem configuration file in a directory, it returns false when there is no configuration file.
+// The checker, interactor and test pattern are relative to the directory.
+func (cptool *CPTool) readProblemConfig(directory string) (ProblemConfig, bool, error) {
+	for _, configPath := range cptool.getProblemConfigPaths(directory) {
+		info, err := cptool.fs.Stat(configPath)
+		if err != nil || info.IsDir() {
+			continue
+		}
+		if cptool.logger != nil {
+			cptool.logger.Println(logger.VERBOSE, "Using problem config: ", configPath)
+		}
+
+		configFile, err := cptool.fs.Open(configPath)
+		if err != nil {
+			return ProblemConfig{}, false, err
+		}
+		defer configFile.Close()
+
+		problemConf := problemConfigFile{}
+		if _, err = toml.DecodeReader(configFile, &problemConf); err != nil {
+			return ProblemConfig{}, false, ErrInvalidProblemConfigurationFile
+		}
+
+		config := ProblemConfig{
+			Name:            problemConf.Name,
+			MemoryLimit:     problemConf.MemoryLimit,
+			Checker:         resolveProblemPath(directory, problemConf.Checker),
+			TestPattern:     problemConf.TestPattern,
+			DefaultLanguage: problemConf.DefaultLanguage,
+			Interactor:      resolveProblemPath(directory, problemConf.Interactor),
+		}
+		// test patterns in current working directory stay relative, so the test case names are the same as before.
+		if filepath.Clean(directory) != filepath.Clean(cptool.workingDirectory) {
+			config.TestPattern = resolveProblemPath(directory, config.TestPattern)
+		}
+		if len(problemConf.TimeLimit) > 0 {
+			if config.TimeLimit, err = time.ParseDuration(problemConf.TimeLimit); err != nil {
+				return ProblemConfig{}, false, ErrInvalidProblemConfigurationFile
+			}
+		}
+		return config, true, nil
+	}
+	return ProblemConfig{}, false, nil
+}
+
+func resolveProblemPath(directory string, programPath string) string {
+	if len(programPath) == 0 || filepath.IsAbs(programPath) {
+		return programPath
+	}
+	return path.Join(directory, programPath)
+}
+
+func (cptool *CPTool) writeProblemConfig(directory string, config problemConfigFile) error {
+	if err := cptool.fs.MkdirAll(directory, os.ModePerm); err != nil {
+		return err
+	}
+	file, err := cptool.fs.Create(path.Join(directory, "problem.toml"))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return toml.NewEncoder(file).Encode(config)
+}
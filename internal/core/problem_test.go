@@ -0,0 +1,132 @@
+package core
+
+import (
+	"path"
+	"testing"
+	"time"
+)
+
+func TestLoadProblemConfig(t *testing.T) {
+	cptool := newTest()
+	config, _ := cptool.fs.Create(path.Join(cptool.workingDirectory, "problem.toml"))
+	config.WriteString("name = \"A. Sum\"\ntime_limit = \"1500ms\"\nmemory_limit = 256\nchecker = \"checker\"\n" +
+		"test_pattern = \"tests/*.in\"\ndefault_language = \"lang_b\"\ninteractor = \"/bin/interactor\"\n")
+
+	err := cptool.loadProblemConfig()
+	if err != nil {
+		t.Error(err)
+	}
+	expected := ProblemConfig{
+		Name:            "A. Sum",
+		TimeLimit:       1500 * time.Millisecond,
+		MemoryLimit:     256,
+		Checker:         path.Join(cptool.workingDirectory, "checker"),
+		TestPattern:     "tests/*.in",
+		DefaultLanguage: "lang_b",
+		Interactor:      "/bin/interactor",
+	}
+	if cptool.GetProblemConfig() != expected {
+		t.Error("problem config should be", expected, ", but found", cptool.GetProblemConfig())
+	}
+}
+
+func TestLoadProblemConfigInCptoolDirectory(t *testing.T) {
+	cptool := newTest()
+	config, _ := cptool.fs.Create(path.Join(cptool.workingDirectory, ".cptool/problem.toml"))
+	config.WriteString("time_limit = \"2s\"\n")
+
+	err := cptool.loadProblemConfig()
+	if err != nil {
+		t.Error(err)
+	}
+	if cptool.GetProblemConfig().TimeLimit != 2*time.Second {
+		t.Error("problem config should be loaded from .cptool directory")
+	}
+}
+
+func TestLoadProblemConfigWithoutFile(t *testing.T) {
+	cptool := newTest()
+	err := cptool.loadProblemConfig()
+	if err != nil {
+		t.Error(err)
+	}
+	if cptool.GetProblemConfig() != (ProblemConfig{}) {
+		t.Error("problem config should be empty")
+	}
+}
+
+func TestLoadProblemConfigWithInvalidFile(t *testing.T) {
+	cptool := newTest()
+	config, _ := cptool.fs.Create(path.Join(cptool.workingDirectory, "problem.toml"))
+	config.WriteString("abcdefg")
+	if err := cptool.loadProblemConfig(); err != ErrInvalidProblemConfigurationFile {
+		t.Error("loadProblemConfig should return ErrInvalidProblemConfigurationFile error")
+	}
+
+	config, _ = cptool.fs.Create(path.Join(cptool.workingDirectory, "problem.toml"))
+	config.WriteString("time_limit = \"two seconds\"\n")
+	if err := cptool.loadProblemConfig(); err != ErrInvalidProblemConfigurationFile {
+		t.Error("loadProblemConfig should return ErrInvalidProblemConfigurationFile error on invalid time limit")
+	}
+}
+
+func TestGetSolutionProblemConfig(t *testing.T) {
+	cptool := newTest()
+	language := Language{Name: "lang", Extension: "lang"}
+	files := map[string]string{
+		"problem.toml":        "time_limit = \"1s\"\n",
+		"a/problem.toml":      "time_limit = \"2s\"\nchecker = \"checker\"\ntest_pattern = \"tests/*.in\"\n",
+		"a/sol.lang":          "",
+		"b/.cptool/problem.x": "",
+		"b/sol.lang":          "",
+		"c/problem.toml":      "abcdefg",
+		"c/sol.lang":          "",
+		"sol.lang":            "",
+	}
+	for name, content := range files {
+		file, _ := cptool.fs.Create(path.Join(cptool.workingDirectory, name))
+		file.WriteString(content)
+	}
+	cptool.loadProblemConfig()
+
+	solution, _ := cptool.GetSolution("a/sol", language)
+	config, err := cptool.GetSolutionProblemConfig(solution)
+	expected := ProblemConfig{
+		TimeLimit:   2 * time.Second,
+		Checker:     path.Join(cptool.workingDirectory, "a/checker"),
+		TestPattern: path.Join(cptool.workingDirectory, "a/tests/*.in"),
+	}
+	if err != nil || config != expected {
+		t.Error("problem config should be", expected, ", but found", config, err)
+	}
+
+	for _, name := range []string{"b/sol", "sol"} {
+		solution, _ = cptool.GetSolution(name, language)
+		if config, err := cptool.GetSolutionProblemConfig(solution); err != nil || config.TimeLimit != time.Second {
+			t.Error("problem config of", name, "should be loaded from current working directory, found:", config, err)
+		}
+	}
+
+	solution, _ = cptool.GetSolution("c/sol", language)
+	if _, err := cptool.GetSolutionProblemConfig(solution); err != ErrInvalidProblemConfigurationFile {
+		t.Error("GetSolutionProblemConfig should return ErrInvalidProblemConfigurationFile error, found:", err)
+	}
+}
+
+func TestGetDefaultLanguageWithProblemConfig(t *testing.T) {
+	cptool := newTest()
+	cptool.languages["lang_a"] = Language{Name: "lang_a", Extension: "a"}
+	cptool.languages["lang_b"] = Language{Name: "lang_b", Extension: "b"}
+	config, _ := cptool.fs.Create("/etc/cptool/config")
+	config.WriteString("default_language = \"lang_a\"\n")
+	cptool.loadConfig()
+	cptool.SetProblemConfig(ProblemConfig{DefaultLanguage: "lang_b"})
+
+	defaultLang, err := cptool.GetDefaultLanguage()
+	if err != nil {
+		t.Error(err)
+	}
+	if defaultLang.Name != "lang_b" {
+		t.Error("GetDefaultLanguage should return problem's default language")
+	}
+}
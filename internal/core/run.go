@@ -8,13 +8,15 @@ import (
 	"github.com/jauhararifin/cptool/internal/logger"
 )
 
-// ExecutionResult stores execution result
+// ExecutionResult stores execution result. Memory contains the maximum memory usage of the program in bytes, it is zero when
+// the memory usage cannot be measured.
 type ExecutionResult struct {
 	CompilationResult
 	Duration time.Duration
+	Memory   uint64
 }
 
-// Run will run solution. This method will execute the solution using the run script that defined in language.
+// Run will run solution. This method will execute the solution using the run command or run script that defined in language.
 // Before the execution begin, this method will compile the solution first by calling Compile method. When there is
 // no error occured, this method return ExecutionResult that contains CompilationResult and execution duration.
 func (cptool *CPTool) Run(
@@ -24,8 +26,6 @@ func (cptool *CPTool) Run(
 	stdout io.Writer,
 	stderr io.Writer,
 ) (ExecutionResult, error) {
-	language := solution.Language
-	targetPath := cptool.getCompiledTarget(solution, false)
 	compilationResult, err := cptool.Compile(ctx, solution, false)
 	if err != nil {
 		if cptool.logger != nil {
@@ -36,15 +36,29 @@ func (cptool *CPTool) Run(
 	if cptool.logger != nil {
 		cptool.logger.PrintInfo("Program compiled succeffully, running program now")
 	}
+	return cptool.Execute(ctx, solution, compilationResult, stdin, stdout, stderr)
+}
 
-	cmd := cptool.exec.CommandContext(ctx, language.RunScript, targetPath)
+// Execute runs the solution that is already compiled, compilationResult is the result of compiling the solution by Compile
+// method. The solution is not compiled again, so the program in compilationResult's TargetPath is executed even when the
+// solution has changed since it was compiled.
+func (cptool *CPTool) Execute(
+	ctx context.Context,
+	solution Solution,
+	compilationResult CompilationResult,
+	stdin io.Reader,
+	stdout io.Writer,
+	stderr io.Writer,
+) (ExecutionResult, error) {
+	commandPath, args := solution.Language.getRunCommand(cptool.getCommandValues(solution, compilationResult.TargetPath))
+	cmd := cptool.exec.CommandContext(ctx, commandPath, args...)
 	cmd.SetStdin(stdin)
 	cmd.SetStdout(stdout)
 	cmd.SetStderr(stderr)
 
-	start := time.Now()
-	err = cmd.Run()
-	duration := time.Since(start)
+	start := cptool.clock()
+	err := cmd.Run()
+	duration := cptool.clock().Sub(start)
 
 	if err != nil {
 		if cptool.logger != nil {
@@ -55,6 +69,7 @@ func (cptool *CPTool) Run(
 	return ExecutionResult{
 		CompilationResult: compilationResult,
 		Duration:          duration,
+		Memory:            getMaxMemory(cmd),
 	}, err
 }
 
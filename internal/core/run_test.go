@@ -36,6 +36,55 @@ func TestRun(t *testing.T) {
 	}
 }
 
+func TestRunWithCommand(t *testing.T) {
+	cptool := newTest()
+	memexec := getCptoolMemExec(cptool)
+	var runPath string
+	memexec.RunCallback = func(m *executioner.MemCmd) error {
+		runPath = m.GetPath()
+		return nil
+	}
+
+	language := compileTestLanguage
+	language.RunCommand = []string{"{target}"}
+	solution := Solution{
+		Name:        "sol",
+		Language:    language,
+		Path:        "/sol.lang",
+		LastUpdated: time.Now(),
+	}
+	result, err := cptool.Run(context.Background(), solution, nil, nil, nil)
+	if err != nil {
+		t.Error(err)
+	}
+	if runPath != result.TargetPath {
+		t.Error("run command should execute the compiled program, found:", runPath)
+	}
+}
+
+func TestRunInterpretedLanguage(t *testing.T) {
+	cptool := newTest()
+	memexec := getCptoolMemExec(cptool)
+	var args []string
+	memexec.RunCallback = func(m *executioner.MemCmd) error {
+		args = m.GetArgs()
+		return nil
+	}
+
+	solution := Solution{
+		Name:        "sol",
+		Language:    Language{Name: "script", Extension: "py", Interpreted: true, RunCommand: []string{"/bin/python", "{target}"}},
+		Path:        "/sol.py",
+		LastUpdated: time.Now(),
+	}
+	if _, err := cptool.Run(context.Background(), solution, nil, nil, nil); err != nil {
+		t.Error(err)
+	}
+	if len(args) != 2 || args[1] != "/sol.py" {
+		t.Error("run command should execute the source code, found:", args)
+	}
+}
+
 func TestRunWithErrorCompilation(t *testing.T) {
 	cptool := newTest()
 	memexec := getCptoolMemExec(cptool)
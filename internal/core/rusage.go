@@ -0,0 +1,29 @@
+//go:build !windows
+// +build !windows
+
+package core
+
+import (
+	"runtime"
+	"syscall"
+
+	"github.com/jauhararifin/cptool/internal/executioner"
+)
+
+// getMaxMemory returns the maximum resident set size of an exited command in bytes. Zero is returned when the command hasn't
+// exited or the resource usage is not available.
+func getMaxMemory(cmd executioner.Cmd) uint64 {
+	state := cmd.GetProcessState()
+	if state == nil {
+		return 0
+	}
+	usage, ok := state.SysUsage().(*syscall.Rusage)
+	if !ok || usage == nil {
+		return 0
+	}
+	// darwin reports maxrss in bytes, while linux reports it in kilobytes.
+	if runtime.GOOS == "darwin" {
+		return uint64(usage.Maxrss)
+	}
+	return uint64(usage.Maxrss) * 1024
+}
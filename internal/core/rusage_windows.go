@@ -0,0 +1,10 @@
+package core
+
+import (
+	"github.com/jauhararifin/cptool/internal/executioner"
+)
+
+// getMaxMemory returns zero, since windows doesn't report the maximum resident set size of an exited command.
+func getMaxMemory(cmd executioner.Cmd) uint64 {
+	return 0
+}
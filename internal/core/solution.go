@@ -3,7 +3,11 @@ package core
 import (
 	"errors"
 	"path"
+	"sort"
+	"strings"
 	"time"
+
+	"github.com/jauhararifin/cptool/internal/logger"
 )
 
 // Solution store information about solution includes solution's name, language, location, and last updated.
@@ -17,21 +21,112 @@ type Solution struct {
 // ErrNoSuchSolution indicates that no solution exists
 var ErrNoSuchSolution = errors.New("No such solution exists")
 
+// AmbiguousSolutionError indicates that several solution files with the same name exist, so the solution's language cannot be
+// determined. Paths contains the path of every solution file.
+type AmbiguousSolutionError struct {
+	Paths []string
+}
+
+func (e *AmbiguousSolutionError) Error() string {
+	return "Several solution files found: " + strings.Join(e.Paths, ", ")
+}
+
 // GetSolution returns solution object with specific name and language. Solution is a single file that contains
 // your source code that written in known programming language. A file named "example.cpp" in current working
 // directory can be considered as a solution named "example", with language "cpp" (because cpp's extension is "cpp").
+// The name can also be a path relative to current working directory (like "problems/A/example") or an absolute path,
+// with or without the language's extension. A name that already has the extension is tried as is first, so "sol.cpp"
+// is used even when "sol.cpp.cpp" exists. The Name of returned solution is the path relative to current working directory
+// without extension, or only the file name without extension when the solution is outside current working directory.
 // If no solution with the specified name and language exists, then this method will return an ErrNoSuchSolution error.
 func (cptool *CPTool) GetSolution(name string, language Language) (Solution, error) {
-	solutionPath := path.Join(cptool.workingDirectory, name+"."+language.Extension)
-	info, err := cptool.fs.Stat(solutionPath)
-	if err != nil || info.IsDir() {
+	basePath := cptool.resolvePath(name)
+	candidates := []string{basePath + "." + language.Extension}
+	if strings.HasSuffix(basePath, "."+language.Extension) {
+		candidates = []string{basePath, basePath + "." + language.Extension}
+	}
+	for _, solutionPath := range candidates {
+		info, err := cptool.fs.Stat(solutionPath)
+		if err != nil || info.IsDir() {
+			continue
+		}
+		solutionName := path.Base(solutionPath)
+		if relativePath, ok := cptool.getRelativePath(solutionPath); ok {
+			solutionName = relativePath
+		}
+		return Solution{
+			Name:        strings.TrimSuffix(solutionName, "."+language.Extension),
+			Language:    language,
+			Path:        solutionPath,
+			LastUpdated: info.ModTime(),
+		}, nil
+	}
+	return Solution{}, ErrNoSuchSolution
+}
+
+// FindSolution returns solution with specific name, the language is detected from the solution file's extension. Every file named
+// "NAME.EXTENSION" is a candidate when a known language has EXTENSION as its extension. The name can be a path relative to current
+// working directory or an absolute path, and can be the solution file's path itself (like "problems/A/sol.cpp"). When several
+// languages have the same extension, the language is chosen using "preferred_languages" setting in config, then the default language,
+// and then the language with smallest name. ErrNoSuchSolution returned when there is no candidate, and AmbiguousSolutionError returned
+// when there are several candidate files.
+func (cptool *CPTool) FindSolution(name string) (Solution, error) {
+	basePath := cptool.resolvePath(name)
+	languages, _ := cptool.GetAllLanguages()
+
+	if info, err := cptool.fs.Stat(basePath); err == nil && !info.IsDir() {
+		candidates := make([]Language, 0)
+		for _, language := range languages {
+			if strings.HasSuffix(basePath, "."+language.Extension) {
+				candidates = append(candidates, language)
+			}
+		}
+		if len(candidates) > 0 {
+			return cptool.GetSolution(basePath, cptool.chooseLanguage(candidates))
+		}
+	}
+
+	candidates := make(map[string][]Language)
+	paths := make([]string, 0)
+	for _, language := range languages {
+		solutionPath := basePath + "." + language.Extension
+		info, err := cptool.fs.Stat(solutionPath)
+		if err != nil || info.IsDir() {
+			continue
+		}
+		if _, ok := candidates[solutionPath]; !ok {
+			paths = append(paths, solutionPath)
+		}
+		candidates[solutionPath] = append(candidates[solutionPath], language)
+	}
+
+	if len(paths) == 0 {
 		return Solution{}, ErrNoSuchSolution
 	}
+	if len(paths) > 1 {
+		sort.Strings(paths)
+		return Solution{}, &AmbiguousSolutionError{Paths: paths}
+	}
 
-	return Solution{
-		Name:        name,
-		Language:    language,
-		Path:        solutionPath,
-		LastUpdated: info.ModTime(),
-	}, nil
+	language := cptool.chooseLanguage(candidates[paths[0]])
+	if cptool.logger != nil {
+		cptool.logger.Printf(logger.VERBOSE, "Detected language %s for solution: %s\n", language.Name, paths[0])
+	}
+	return cptool.GetSolution(paths[0], language)
+}
+
+// chooseLanguage chooses a language between candidates that have the same extension. The candidates must be sorted by their name.
+func (cptool *CPTool) chooseLanguage(candidates []Language) Language {
+	preferred := append([]string{}, cptool.config.PreferredLanguages...)
+	if defaultLanguage, err := cptool.GetDefaultLanguage(); err == nil {
+		preferred = append(preferred, defaultLanguage.Name)
+	}
+	for _, name := range preferred {
+		for _, language := range candidates {
+			if language.Name == name {
+				return language
+			}
+		}
+	}
+	return candidates[0]
 }
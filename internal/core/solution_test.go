@@ -3,6 +3,7 @@ package core
 import (
 	"os"
 	"path"
+	"reflect"
 	"testing"
 )
 
@@ -21,7 +22,7 @@ func TestGetSolution(t *testing.T) {
 	if solution.Name != "a" {
 		t.Error("solution name should be a")
 	}
-	if solution.Language != language {
+	if !reflect.DeepEqual(solution.Language, language) {
 		t.Error("language solution does not match")
 	}
 }
@@ -42,7 +43,7 @@ func TestGetSolutionInsideDirectory(t *testing.T) {
 	if solution.Name != "some/dir/a" {
 		t.Error("solution name should be some/dir/a")
 	}
-	if solution.Language != language {
+	if !reflect.DeepEqual(solution.Language, language) {
 		t.Error("language solution does not match")
 	}
 }
@@ -76,3 +77,125 @@ func TestGetSolutionIsDirectory(t *testing.T) {
 		t.Error("should return no such solution error when solution does not exists")
 	}
 }
+
+func prepareFindSolutionTest(cptool *CPTool, files ...string) {
+	cptool.languages["cpp"] = Language{Name: "cpp", Extension: "cpp"}
+	cptool.languages["cpp11"] = Language{Name: "cpp11", Extension: "cpp"}
+	cptool.languages["cpp17"] = Language{Name: "cpp17", Extension: "cpp"}
+	cptool.languages["python3"] = Language{Name: "python3", Extension: "py"}
+	for _, file := range files {
+		cptool.fs.Create(path.Join(cptool.workingDirectory, file))
+	}
+}
+
+func TestFindSolution(t *testing.T) {
+	cptool := newTest()
+	prepareFindSolutionTest(cptool, "sol.py", "sol.sample_1.in", "other.cpp")
+
+	solution, err := cptool.FindSolution("sol")
+	if err != nil {
+		t.Error(err)
+	}
+	if solution.Language.Name != "python3" || solution.Path != path.Join(cptool.workingDirectory, "sol.py") {
+		t.Error("FindSolution should detect language from extension, found:", solution)
+	}
+
+	if _, err := cptool.FindSolution("missing"); err != ErrNoSuchSolution {
+		t.Error("FindSolution should return ErrNoSuchSolution, found:", err)
+	}
+}
+
+func TestFindSolutionWithSameExtension(t *testing.T) {
+	cptool := newTest()
+	prepareFindSolutionTest(cptool, "sol.cpp")
+
+	solution, _ := cptool.FindSolution("sol")
+	if solution.Language.Name != "cpp" {
+		t.Error("FindSolution should choose the first language, found:", solution.Language.Name)
+	}
+
+	cptool.config.DefaultLanguage = "cpp11"
+	solution, _ = cptool.FindSolution("sol")
+	if solution.Language.Name != "cpp11" {
+		t.Error("FindSolution should choose the default language, found:", solution.Language.Name)
+	}
+
+	cptool.config.PreferredLanguages = []string{"python3", "cpp17"}
+	solution, _ = cptool.FindSolution("sol")
+	if solution.Language.Name != "cpp17" {
+		t.Error("FindSolution should choose the preferred language, found:", solution.Language.Name)
+	}
+}
+
+func TestFindSolutionWithSeveralFiles(t *testing.T) {
+	cptool := newTest()
+	prepareFindSolutionTest(cptool, "sol.cpp", "sol.py")
+
+	_, err := cptool.FindSolution("sol")
+	ambiguousErr, ok := err.(*AmbiguousSolutionError)
+	if !ok {
+		t.Error("FindSolution should return AmbiguousSolutionError, found:", err)
+		return
+	}
+	expected := []string{path.Join(cptool.workingDirectory, "sol.cpp"), path.Join(cptool.workingDirectory, "sol.py")}
+	if !reflect.DeepEqual(ambiguousErr.Paths, expected) {
+		t.Error("AmbiguousSolutionError should contain every solution file, found:", ambiguousErr.Paths)
+	}
+}
+
+func TestGetSolutionByPath(t *testing.T) {
+	cptool := newTest()
+	language := Language{Name: "cpp", Extension: "cpp"}
+	cptool.fs.Create(path.Join(cptool.workingDirectory, "problems/A/sol.cpp"))
+	cptool.fs.Create("/contest/B/sol.cpp")
+
+	solution, err := cptool.GetSolution("problems/A/sol.cpp", language)
+	if err != nil {
+		t.Error(err)
+	}
+	if solution.Name != "problems/A/sol" || solution.Path != path.Join(cptool.workingDirectory, "problems/A/sol.cpp") {
+		t.Error("GetSolution should accept solution path with extension, found:", solution)
+	}
+
+	solution, err = cptool.GetSolution("/contest/B/sol", language)
+	if err != nil {
+		t.Error(err)
+	}
+	if solution.Name != "sol" || solution.Path != "/contest/B/sol.cpp" {
+		t.Error("GetSolution should accept absolute path, found:", solution)
+	}
+
+	prepareFindSolutionTest(cptool, "problems/A/sol.cpp.cpp")
+	solution, err = cptool.GetSolution("problems/A/sol.cpp", language)
+	if err != nil {
+		t.Error(err)
+	}
+	if solution.Path != path.Join(cptool.workingDirectory, "problems/A/sol.cpp") {
+		t.Error("GetSolution should prefer the file named exactly, found:", solution)
+	}
+	solution, err = cptool.FindSolution("problems/A/sol.cpp")
+	if err != nil || solution.Path != path.Join(cptool.workingDirectory, "problems/A/sol.cpp") {
+		t.Error("FindSolution should prefer the file named exactly, found:", solution, err)
+	}
+}
+
+func TestFindSolutionByPath(t *testing.T) {
+	cptool := newTest()
+	prepareFindSolutionTest(cptool, "problems/A/sol.py", "problems/B/sol.py")
+
+	solution, err := cptool.FindSolution("problems/A/sol.py")
+	if err != nil {
+		t.Error(err)
+	}
+	if solution.Name != "problems/A/sol" || solution.Language.Name != "python3" {
+		t.Error("FindSolution should detect language from solution path, found:", solution)
+	}
+
+	solution, err = cptool.FindSolution("problems/B/sol")
+	if err != nil {
+		t.Error(err)
+	}
+	if solution.Path != path.Join(cptool.workingDirectory, "problems/B/sol.py") {
+		t.Error("FindSolution should search solution in the directory, found:", solution)
+	}
+}
@@ -0,0 +1,136 @@
+package core
+
+import (
+	"errors"
+	"os"
+	"path"
+	"strconv"
+	"text/template"
+
+	"github.com/jauhararifin/cptool/internal/logger"
+	"github.com/spf13/afero"
+)
+
+// TemplateData contains values that can be used as placeholders in solution template. Templates are parsed using go's
+// text/template package, so the placeholders are written like "{{.Name}}". Name contains the new solution's name, Language
+// contains the language's verbose name, Author contains "author" setting from config and Date contains the creation date
+// formatted as "2006-01-02".
+type TemplateData struct {
+	Name     string
+	Language string
+	Author   string
+	Date     string
+}
+
+// ErrSolutionAlreadyExists indicates that the solution file is already exists
+var ErrSolutionAlreadyExists = errors.New("Solution already exists")
+
+// ErrInvalidTemplate indicates the template file is not a valid template
+var ErrInvalidTemplate = errors.New("Invalid template file")
+
+// GetTemplatesPaths returns all paths to the directories that considered contain solution templates. Templates directory
+// lives beside langs directory in every configuration paths. The "templates" directory in config has the highest priority.
+func (cptool *CPTool) GetTemplatesPaths() []string {
+	paths := make([]string, 0)
+	if len(cptool.config.Templates) > 0 {
+		paths = append(paths, cptool.config.Templates)
+	}
+	for _, confPath := range cptool.GetConfigurationPaths() {
+		paths = append(paths, path.Join(confPath, "templates"))
+	}
+	return paths
+}
+
+// GetTemplate returns path to the template file of a language. A template of language named "cpp" with "cpp" extension is
+// a file named "cpp.cpp" or "cpp" inside templates directory. The template in configuration path with higher priority is
+// choosen when there are more than one templates found. Empty string returned when no template found.
+func (cptool *CPTool) GetTemplate(language Language) string {
+	for _, templatesPath := range cptool.GetTemplatesPaths() {
+		for _, filename := range []string{language.Name + "." + language.Extension, language.Name} {
+			templatePath := path.Join(templatesPath, filename)
+			if cptool.logger != nil {
+				cptool.logger.Println(logger.VERBOSE, "Searching template in: ", templatePath)
+			}
+			info, err := cptool.fs.Stat(templatePath)
+			if err == nil && !info.IsDir() {
+				return templatePath
+			}
+		}
+	}
+	return ""
+}
+
+// CreateSolution creates new solution file in current working directory. The solution file's content is generated from
+// language's template. If the language doesn't have template, an empty solution file is created. ErrSolutionAlreadyExists
+// returned when the solution file already exists.
+func (cptool *CPTool) CreateSolution(name string, language Language) (Solution, error) {
+	solutionPath := path.Join(cptool.workingDirectory, name+"."+language.Extension)
+	if _, err := cptool.fs.Stat(solutionPath); err == nil {
+		return Solution{}, ErrSolutionAlreadyExists
+	}
+
+	var tmpl *template.Template
+	if templatePath := cptool.GetTemplate(language); len(templatePath) > 0 {
+		if cptool.logger != nil {
+			cptool.logger.Println(logger.VERBOSE, "Using template: ", templatePath)
+		}
+		content, err := afero.ReadFile(cptool.fs, templatePath)
+		if err != nil {
+			return Solution{}, err
+		}
+		tmpl, err = template.New(path.Base(templatePath)).Parse(string(content))
+		if err != nil {
+			return Solution{}, ErrInvalidTemplate
+		}
+	}
+
+	if err := cptool.fs.MkdirAll(path.Dir(solutionPath), os.ModePerm); err != nil {
+		return Solution{}, err
+	}
+	file, err := cptool.fs.Create(solutionPath)
+	if err != nil {
+		return Solution{}, err
+	}
+	defer file.Close()
+
+	if tmpl != nil {
+		data := TemplateData{
+			Name:     name,
+			Language: language.VerboseName,
+			Author:   cptool.config.Author,
+			Date:     cptool.clock().Format("2006-01-02"),
+		}
+		if err := tmpl.Execute(file, data); err != nil {
+			return Solution{}, err
+		}
+	}
+
+	return cptool.GetSolution(name, language)
+}
+
+// CreateSampleTestCases creates empty sample test case files for a solution. The test cases are named "NAME.sample_1",
+// "NAME.sample_2", until "NAME.sample_COUNT", so they can be tested using "NAME.sample" as test case prefix. Existing
+// test case files are left untouched.
+func (cptool *CPTool) CreateSampleTestCases(name string, count int) ([]TestCase, error) {
+	testCases := make([]TestCase, 0, count)
+	for i := 1; i <= count; i++ {
+		testName := name + ".sample_" + strconv.Itoa(i)
+		testCase := TestCase{
+			Name:       testName,
+			InputPath:  path.Join(cptool.workingDirectory, testName+".in"),
+			OutputPath: path.Join(cptool.workingDirectory, testName+".out"),
+		}
+		for _, filePath := range []string{testCase.InputPath, testCase.OutputPath} {
+			if _, err := cptool.fs.Stat(filePath); err == nil {
+				continue
+			}
+			file, err := cptool.fs.Create(filePath)
+			if err != nil {
+				return testCases, err
+			}
+			file.Close()
+		}
+		testCases = append(testCases, testCase)
+	}
+	return testCases, nil
+}
@@ -0,0 +1,117 @@
+package core
+
+import (
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+var templateTestLanguage = Language{
+	Name:        "lang_a",
+	Extension:   "a",
+	VerboseName: "Language A",
+}
+
+func TestGetTemplate(t *testing.T) {
+	cptool := newTest()
+	cptool.fs.MkdirAll("/etc/cptool/templates", os.ModePerm)
+	cptool.fs.Create("/etc/cptool/templates/lang_a")
+	cptool.fs.MkdirAll(path.Join(cptool.homeDirectory, ".cptool/templates"), os.ModePerm)
+	cptool.fs.Create(path.Join(cptool.homeDirectory, ".cptool/templates/lang_a.a"))
+
+	templatePath := cptool.GetTemplate(templateTestLanguage)
+	if templatePath != path.Join(cptool.homeDirectory, ".cptool/templates/lang_a.a") {
+		t.Error("GetTemplate should return template with highest priority, found:", templatePath)
+	}
+}
+
+func TestGetTemplateWithoutTemplate(t *testing.T) {
+	cptool := newTest()
+	if templatePath := cptool.GetTemplate(templateTestLanguage); templatePath != "" {
+		t.Error("GetTemplate should return empty string, found:", templatePath)
+	}
+}
+
+func TestCreateSolution(t *testing.T) {
+	cptool := newTest()
+	cptool.fs.MkdirAll("/etc/cptool/templates", os.ModePerm)
+	template, _ := cptool.fs.Create("/etc/cptool/templates/lang_a")
+	template.WriteString("// {{.Name}} in {{.Language}} by {{.Author}} at {{.Date}}\n")
+	config, _ := cptool.fs.Create("/etc/cptool/config")
+	config.WriteString("author = \"someone\"\n")
+	cptool.loadConfig()
+
+	solution, err := cptool.CreateSolution("sol", templateTestLanguage)
+	if err != nil {
+		t.Error(err)
+	}
+	if solution.Path != path.Join(cptool.workingDirectory, "sol.a") {
+		t.Error("CreateSolution should create solution in working directory, found:", solution.Path)
+	}
+	content, _ := afero.ReadFile(cptool.fs, solution.Path)
+	expected := "// sol in Language A by someone at " + time.Now().Format("2006-01-02") + "\n"
+	if string(content) != expected {
+		t.Error("CreateSolution should fill the template placeholders, found:", string(content))
+	}
+}
+
+func TestCreateSolutionWithoutTemplate(t *testing.T) {
+	cptool := newTest()
+	solution, err := cptool.CreateSolution("sol", templateTestLanguage)
+	if err != nil {
+		t.Error(err)
+	}
+	content, err := afero.ReadFile(cptool.fs, solution.Path)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(content) > 0 {
+		t.Error("CreateSolution should create empty solution")
+	}
+}
+
+func TestCreateSolutionAlreadyExists(t *testing.T) {
+	cptool := newTest()
+	cptool.fs.Create(path.Join(cptool.workingDirectory, "sol.a"))
+	_, err := cptool.CreateSolution("sol", templateTestLanguage)
+	if err != ErrSolutionAlreadyExists {
+		t.Error("CreateSolution should return ErrSolutionAlreadyExists error")
+	}
+}
+
+func TestCreateSolutionWithInvalidTemplate(t *testing.T) {
+	cptool := newTest()
+	cptool.fs.MkdirAll("/etc/cptool/templates", os.ModePerm)
+	template, _ := cptool.fs.Create("/etc/cptool/templates/lang_a")
+	template.WriteString("{{.Name")
+	_, err := cptool.CreateSolution("sol", templateTestLanguage)
+	if err != ErrInvalidTemplate {
+		t.Error("CreateSolution should return ErrInvalidTemplate error")
+	}
+}
+
+func TestCreateSampleTestCases(t *testing.T) {
+	cptool := newTest()
+	existing, _ := cptool.fs.Create(path.Join(cptool.workingDirectory, "sol.sample_1.in"))
+	existing.WriteString("1 2")
+	existing.Close()
+
+	testCases, err := cptool.CreateSampleTestCases("sol", 2)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(testCases) != 2 {
+		t.Error("CreateSampleTestCases should create 2 test cases")
+	}
+	found := cptool.getAllTestCaseWithPrefix("sol.sample")
+	if len(found) != 2 {
+		t.Error("created sample test cases should be found using solution's sample prefix")
+	}
+	content, _ := afero.ReadFile(cptool.fs, path.Join(cptool.workingDirectory, "sol.sample_1.in"))
+	if string(content) != "1 2" {
+		t.Error("CreateSampleTestCases should not overwrite existing test case")
+	}
+}
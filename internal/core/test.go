@@ -2,13 +2,16 @@ package core
 
 import (
 	"context"
+	"io"
 	"os"
+	"os/exec"
 	"path"
 	"path/filepath"
+	"sync"
 	"time"
 
 	"github.com/jauhararifin/cptool/internal/logger"
-	"github.com/udhos/equalfile"
+	"github.com/spf13/afero"
 )
 
 const (
@@ -20,39 +23,97 @@ const (
 
 	// TestCaseSuccess indicates the testcase is success
 	TestCaseSuccess = iota
+
+	// TestCaseTimeLimitExceeded indicates the solution runs longer than the time limit
+	TestCaseTimeLimitExceeded = iota
+
+	// TestCaseMemoryLimitExceeded indicates the solution uses more memory than the memory limit
+	TestCaseMemoryLimitExceeded = iota
 )
 
+const (
+	// TestEventCompileStarted indicates the solution is being compiled
+	TestEventCompileStarted = iota
+
+	// TestEventCompileFinished indicates the compilation is finished, Compilation and Err contain its result
+	TestEventCompileFinished = iota
+
+	// TestEventTestStarted indicates a test case is being tested
+	TestEventTestStarted = iota
+
+	// TestEventTestFinished indicates a test case is tested, Result contains its result
+	TestEventTestFinished = iota
+
+	// TestEventAborted indicates the context is done before every test case is tested, Err contains the context's error
+	TestEventAborted = iota
+)
+
+// TestEvent describes the progress of testing a solution. Type is one of "TestEventCompileStarted", "TestEventCompileFinished",
+// "TestEventTestStarted", "TestEventTestFinished" and "TestEventAborted", and only the fields that are relevant to the type are set.
+type TestEvent struct {
+	Type        int
+	Compilation CompilationResult
+	TestCase    TestCase
+	Result      TestCaseResult
+	Err         error
+}
+
+// TestObserver receives the events of testing a solution as they happen. The events are delivered one at a time even when the
+// test cases are tested in parallel, so the observer doesn't need to be safe for concurrent use.
+type TestObserver func(event TestEvent)
+
 // TestCaseResult stores the result of testing a single test case. This contains information about the result of a test, the duration,
-// and the test case. This also contains error if there is an error when testing the test case. The result of testing a test case can
-// be classified into three category: "TestCaseSkipped", "TestCaseFailed", "TestCaseSuccess". Skipped means that there is an error
-// (maybe IO error or something), that made the test skipped. When skipped, the Err property will set to error that made the test skipped.
-// Failed means that the test run successfully but the solution's output is differ with expected output. Success means that test run
-// successfully and gives output as expected.
+// the memory usage (in bytes) and the test case. This also contains error if there is an error when testing the test case. The result of
+// testing a test case can be classified into five category: "TestCaseSkipped", "TestCaseFailed", "TestCaseSuccess",
+// "TestCaseTimeLimitExceeded" and "TestCaseMemoryLimitExceeded". Skipped means that there is an error (maybe IO error or something), that
+// made the test skipped. When skipped, the Err property will set to error that made the test skipped. Failed means that the test run
+// successfully but the solution's output is differ with expected output. Success means that test run successfully and gives output as
+// expected. TimeLimitExceeded and MemoryLimitExceeded mean the solution exceeds the limits in problem configuration. ActualOutputPath
+// contains the path of the solution's saved output, it is empty when the output is not saved.
 type TestCaseResult struct {
-	Testcase TestCase
-	Duration time.Duration
-	Status   int
-	Err      error
+	Testcase         TestCase
+	Duration         time.Duration
+	Memory           uint64
+	Status           int
+	Err              error
+	ActualOutputPath string
 }
 
 // TestResult store test results of several test case. When testing using many testcase, this struct will returned after all test have been
 // done. TestCaseResults's contain result of every single test case that tested. Duration contains durations of testing all test cases.
-// UnsuccessfullTestsCount contains the number of unsuccessfull test case
+// UnsuccessfullTestsCount contains the number of unsuccessfull test case. TimeLimit and MemoryLimit (in megabytes) are the limits of
+// every test case after adjusted for the solution's language, zero means unlimited. Compilation contains the result of compiling
+// the solution, its ErrorMessage contains the compiler's message when the compilation failed.
 type TestResult struct {
+	Compilation             CompilationResult
 	TestCaseResults         []TestCaseResult
 	Duration                time.Duration
 	UnsuccessfullTestsCount uint
+	TimeLimit               time.Duration
+	MemoryLimit             int
 }
 
 // Test will run solution using some testcases. A test case is a pair of text file that defines input and expected output of a test case.
 // A file named "example.in" and "example.out" in current working directory considered as a test case named "example". This method will
-// tests the given solution using all test cases with Name attribute that stars with `testPrefix`.
+// tests the given solution using all test cases with Name attribute that stars with `testPrefix`. The test cases are tested in parallel
+// using "jobs" workers from config, but the results are always ordered as the test cases. The progress is reported to observer as
+// TestEvent when observer is not nil, so the results can be shown before every test case is tested.
 func (cptool *CPTool) Test(
 	ctx context.Context,
 	solution Solution,
 	testPrefix string,
+	observer TestObserver,
+) (TestResult, error) {
+	return cptool.TestTestCases(ctx, solution, cptool.getAllTestCaseWithPrefix(testPrefix), observer)
+}
+
+// TestTestCases tests the solution using the given test cases, like Test.
+func (cptool *CPTool) TestTestCases(
+	ctx context.Context,
+	solution Solution,
+	testCases []TestCase,
+	observer TestObserver,
 ) (TestResult, error) {
-	testCases := cptool.getAllTestCaseWithPrefix(testPrefix)
 	if cptool.logger != nil {
 		for _, tc := range testCases {
 			cptool.logger.Println(logger.VERBOSE, "Test case found:", tc.Name)
@@ -61,46 +122,97 @@ func (cptool *CPTool) Test(
 		}
 	}
 
+	var observerLock sync.Mutex
+	notify := func(event TestEvent) {
+		if observer != nil {
+			observerLock.Lock()
+			defer observerLock.Unlock()
+			observer(event)
+		}
+	}
+
 	results := TestResult{}
-	startTime := time.Now()
-	for _, testCase := range testCases {
+	results.TimeLimit, results.MemoryLimit = solution.Language.GetLimits(cptool.problem.TimeLimit, cptool.problem.MemoryLimit)
+	startTime := cptool.clock()
+	notify(TestEvent{Type: TestEventCompileStarted})
+	compilationResult, err := cptool.Compile(ctx, solution, false)
+	compilationResult.Duration = cptool.clock().Sub(startTime)
+	results.Compilation = compilationResult
+	notify(TestEvent{Type: TestEventCompileFinished, Compilation: compilationResult, Err: err})
+	if err != nil {
 		if cptool.logger != nil {
-			cptool.logger.Println(logger.VERBOSE, "Testing program using test case:", testCase.Name)
+			cptool.logger.Println(logger.VERBOSE, "Program compilation error")
 		}
-		success, duration, err := cptool.runSingleTest(ctx, solution, testCase)
-		if err != nil {
-			if cptool.logger != nil {
-				cptool.logger.Println(logger.VERBOSE, "Program execution return an error")
+		return results, err
+	}
+
+	jobs := cptool.config.Jobs
+	if jobs < 1 {
+		jobs = 1
+	}
+	testCaseResults := make([]TestCaseResult, len(testCases))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < jobs; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range indexes {
+				notify(TestEvent{Type: TestEventTestStarted, TestCase: testCases[index]})
+				testCaseResults[index] = cptool.testSingleTestCase(ctx, solution, testCases[index])
+				notify(TestEvent{Type: TestEventTestFinished, TestCase: testCases[index], Result: testCaseResults[index]})
 			}
-			results.TestCaseResults = append(results.TestCaseResults, TestCaseResult{
-				Testcase: testCase,
-				Status:   TestCaseSkipped,
-				Err:      err,
-			})
+		}()
+	}
+	for i := range testCases {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		notify(TestEvent{Type: TestEventAborted, Err: ctx.Err()})
+	}
+	for _, result := range testCaseResults {
+		if result.Status != TestCaseSuccess {
 			results.UnsuccessfullTestsCount++
-		} else {
-			status := TestCaseFailed
-			if success {
-				status = TestCaseSuccess
-			}
-			if cptool.logger != nil {
-				if success {
-					cptool.logger.Println(logger.VERBOSE, "Test case passed")
-				} else {
-					cptool.logger.Println(logger.VERBOSE, "Test case failed")
-				}
-			}
-			results.TestCaseResults = append(results.TestCaseResults, TestCaseResult{
-				Testcase: testCase,
-				Status:   status,
-				Duration: duration,
-			})
 		}
+		results.TestCaseResults = append(results.TestCaseResults, result)
 	}
-	results.Duration = time.Since(startTime)
+	results.Duration = cptool.clock().Sub(startTime)
 	return results, nil
 }
 
+// testSingleTestCase tests a solution using a single test case. Error when running the test case makes the test case skipped.
+func (cptool *CPTool) testSingleTestCase(ctx context.Context, solution Solution, testCase TestCase) TestCaseResult {
+	if cptool.logger != nil {
+		cptool.logger.Println(logger.VERBOSE, "Testing program using test case:", testCase.Name)
+	}
+	result, err := cptool.runSingleTest(ctx, solution, testCase)
+	if err != nil {
+		if cptool.logger != nil {
+			cptool.logger.Println(logger.VERBOSE, "Program execution return an error")
+		}
+		return TestCaseResult{
+			Testcase: testCase,
+			Status:   TestCaseSkipped,
+			Err:      err,
+		}
+	}
+	outputFilePath := cptool.getOutputTarget(solution, testCase)
+	if ok, _ := afero.Exists(cptool.fs, outputFilePath); ok {
+		result.ActualOutputPath = outputFilePath
+	}
+	if cptool.logger != nil {
+		if result.Status == TestCaseSuccess {
+			cptool.logger.Println(logger.VERBOSE, "Test case passed:", testCase.Name)
+		} else {
+			cptool.logger.Println(logger.VERBOSE, "Test case failed:", testCase.Name)
+		}
+	}
+	return result
+}
+
 // TestByName will test solution using some test cases. This method will search the language and solution by its name and then
 // call Test method. This method will return an error if the language or solution with it's name doesn't exist.
 func (cptool *CPTool) TestByName(
@@ -125,57 +237,263 @@ func (cptool *CPTool) TestByName(
 		return TestResult{}, err
 	}
 
-	return cptool.Test(ctx, solution, testPrefix)
+	return cptool.Test(ctx, solution, testPrefix, nil)
+}
+
+// SetKeepOutputs sets whether the output of every tested test case should be saved in output directory. By default, the
+// output is compared with the expected output while the program is running, and only saved when the test case failed. This
+// overrides "keep_outputs" setting in config.
+func (cptool *CPTool) SetKeepOutputs(keepOutputs bool) {
+	cptool.config.KeepOutputs = keepOutputs
 }
 
 // GetOutputRootDir returns directory of all tested solution's output.
 func (cptool *CPTool) GetOutputRootDir() string {
-	return path.Join(cptool.workingDirectory, ".cptool/outputs")
+	return path.Join(cptool.GetProjectRoot(), ".cptool/outputs")
 }
 
 func (cptool *CPTool) getOutputTarget(solution Solution, testCase TestCase) string {
-	return path.Join(cptool.GetOutputRootDir(), solution.Name, solution.Language.Name, testCase.Name)
+	return path.Join(cptool.GetOutputRootDir(), cptool.getSolutionNamespace(solution), solution.Name, solution.Language.Name, testCase.Name)
 }
 
-func (cptool *CPTool) runSingleTest(ctx context.Context, solution Solution, testCase TestCase) (bool, time.Duration, error) {
+func (cptool *CPTool) runSingleTest(ctx context.Context, solution Solution, testCase TestCase) (TestCaseResult, error) {
+	problem := cptool.problem
+	if len(problem.Checker) == 0 {
+		problem.Checker = cptool.config.Checker
+	}
 	outputFilePath := cptool.getOutputTarget(solution, testCase)
 	if err := cptool.fs.MkdirAll(filepath.Dir(outputFilePath), os.ModePerm); err != nil {
-		return false, 0, err
+		return TestCaseResult{}, err
+	}
+	if !cptool.config.KeepOutputs {
+		if err := cptool.fs.Remove(outputFilePath); err != nil && !os.IsNotExist(err) {
+			return TestCaseResult{}, err
+		}
 	}
-	outputFile, err := cptool.fs.Create(outputFilePath)
-	if outputFile != nil {
-		defer outputFile.Close()
+
+	testCtx := ctx
+	if timeLimit, _ := solution.Language.GetLimits(problem.TimeLimit, problem.MemoryLimit); timeLimit > 0 {
+		var cancel context.CancelFunc
+		testCtx, cancel = context.WithTimeout(ctx, timeLimit)
+		defer cancel()
 	}
-	if err != nil {
-		return false, 0, err
+
+	if len(problem.Interactor) > 0 {
+		return cptool.runInteractiveTest(ctx, testCtx, solution, testCase, outputFilePath)
 	}
+
 	inputFile, err := cptool.fs.Open(testCase.InputPath)
 	if inputFile != nil {
 		defer inputFile.Close()
 	}
 	if err != nil {
-		return false, 0, err
+		return TestCaseResult{}, err
+	}
+	expectedOutputFile, err := cptool.fs.Open(testCase.OutputPath)
+	if expectedOutputFile != nil {
+		defer expectedOutputFile.Close()
 	}
-	startTime := time.Now()
-	_, err = cptool.Run(ctx, solution, inputFile, outputFile, os.Stderr)
-	duration := time.Since(startTime)
 	if err != nil {
-		return false, 0, err
+		return TestCaseResult{}, err
+	}
+
+	var outputFile afero.File
+	defer func() {
+		if outputFile != nil {
+			outputFile.Close()
+		}
+	}()
+	if cptool.config.KeepOutputs || len(problem.Checker) > 0 {
+		outputFile, err = cptool.fs.Create(outputFilePath)
+		if err != nil {
+			return TestCaseResult{}, err
+		}
+	}
+	checker := newOutputChecker(expectedOutputFile, func(compared int64) (io.Writer, error) {
+		if outputFile != nil {
+			return nil, nil
+		}
+		file, err := cptool.createMismatchedOutput(outputFilePath, testCase, compared)
+		if err != nil {
+			return nil, err
+		}
+		outputFile = file
+		return file, nil
+	})
+	var stdout io.Writer = checker
+	if len(problem.Checker) > 0 {
+		stdout = outputFile
+	} else if outputFile != nil {
+		stdout = io.MultiWriter(outputFile, checker)
+	}
+
+	startTime := cptool.clock()
+	execution, err := cptool.Run(testCtx, solution, inputFile, stdout, cptool.stderr)
+	result := cptool.checkLimits(ctx, testCtx, solution.Language, testCase, execution, cptool.clock().Sub(startTime), err)
+	if result.Status == TestCaseTimeLimitExceeded || result.Status == TestCaseMemoryLimitExceeded {
+		return result, nil
 	}
-	_, err = outputFile.Seek(0, 0)
 	if err != nil {
-		return false, 0, err
+		return TestCaseResult{}, err
+	}
+
+	var same bool
+	if len(problem.Checker) > 0 {
+		same, err = cptool.runChecker(ctx, problem.Checker, testCase, outputFilePath)
+		if err == nil && same && !cptool.config.KeepOutputs {
+			outputFile.Close()
+			outputFile = nil
+			err = cptool.fs.Remove(outputFilePath)
+		}
+	} else {
+		same, err = checker.Finish()
+	}
+	if err != nil {
+		return TestCaseResult{}, err
+	}
+	if same {
+		result.Status = TestCaseSuccess
+	}
+	return result, nil
+}
+
+// checkLimits returns the result of a test case based on its execution. The result status is TestCaseTimeLimitExceeded when the
+// test context is timed out or the execution duration exceeds the time limit, TestCaseMemoryLimitExceeded when the memory usage
+// exceeds the memory limit, and TestCaseFailed otherwise. The limits are adjusted for the language.
+func (cptool *CPTool) checkLimits(
+	ctx context.Context,
+	testCtx context.Context,
+	language Language,
+	testCase TestCase,
+	execution ExecutionResult,
+	elapsed time.Duration,
+	err error,
+) TestCaseResult {
+	timeLimit, memoryLimit := language.GetLimits(cptool.problem.TimeLimit, cptool.problem.MemoryLimit)
+	result := TestCaseResult{
+		Testcase: testCase,
+		Duration: execution.Duration,
+		Memory:   execution.Memory,
+		Status:   TestCaseFailed,
+	}
+	if timeLimit > 0 && ctx.Err() == nil && testCtx.Err() == context.DeadlineExceeded {
+		result.Duration = elapsed
+		result.Status = TestCaseTimeLimitExceeded
+	} else if err == nil && timeLimit > 0 && execution.Duration > timeLimit {
+		result.Status = TestCaseTimeLimitExceeded
+	} else if err == nil && memoryLimit > 0 && execution.Memory > uint64(memoryLimit)*1024*1024 {
+		result.Status = TestCaseMemoryLimitExceeded
+	}
+	return result
+}
+
+// runChecker executes checker with three arguments: input file, solution's output file and expected output file. The output
+// is accepted when the checker exits with zero status.
+func (cptool *CPTool) runChecker(ctx context.Context, checker string, testCase TestCase, outputFilePath string) (bool, error) {
+	if cptool.logger != nil {
+		cptool.logger.Println(logger.VERBOSE, "Checking output using checker: ", checker)
+	}
+	cmd := cptool.exec.CommandContext(ctx, checker, testCase.InputPath, outputFilePath, testCase.OutputPath)
+	cmd.SetStdout(cptool.stderr)
+	cmd.SetStderr(cptool.stderr)
+	err := cmd.Run()
+	if _, ok := err.(*exec.ExitError); ok {
+		return false, nil
 	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// runInteractiveTest runs the solution together with problem's interactor. The interactor's stdout is connected to solution's
+// stdin and the solution's stdout is connected to interactor's stdin. The interactor is executed with three arguments: input
+// file, output file and expected output file. The solution is accepted when the interactor exits with zero status.
+func (cptool *CPTool) runInteractiveTest(
+	ctx context.Context,
+	testCtx context.Context,
+	solution Solution,
+	testCase TestCase,
+	outputFilePath string,
+) (TestCaseResult, error) {
+	if cptool.logger != nil {
+		cptool.logger.Println(logger.VERBOSE, "Interacting using interactor: ", cptool.problem.Interactor)
+	}
+	solutionInput, interactorOutput, err := os.Pipe()
+	if err != nil {
+		return TestCaseResult{}, err
+	}
+	interactorInput, solutionOutput, err := os.Pipe()
+	if err != nil {
+		solutionInput.Close()
+		interactorOutput.Close()
+		return TestCaseResult{}, err
+	}
+
+	cmd := cptool.exec.CommandContext(
+		testCtx,
+		cptool.problem.Interactor,
+		testCase.InputPath,
+		outputFilePath,
+		testCase.OutputPath,
+	)
+	cmd.SetStdin(interactorInput)
+	cmd.SetStdout(interactorOutput)
+	cmd.SetStderr(cptool.stderr)
+	err = cmd.Start()
+	// the interactor has its own copy of the pipes, closing them here makes the solution receive EOF when interactor exits.
+	interactorInput.Close()
+	interactorOutput.Close()
+	if err != nil {
+		solutionInput.Close()
+		solutionOutput.Close()
+		return TestCaseResult{}, err
+	}
+	interactorDone := make(chan error, 1)
+	go func() {
+		interactorDone <- cmd.Wait()
+	}()
+
+	startTime := cptool.clock()
+	execution, err := cptool.Run(testCtx, solution, solutionInput, solutionOutput, cptool.stderr)
+	elapsed := cptool.clock().Sub(startTime)
+	solutionInput.Close()
+	solutionOutput.Close()
+	interactorErr := <-interactorDone
+
+	result := cptool.checkLimits(ctx, testCtx, solution.Language, testCase, execution, elapsed, err)
+	if result.Status == TestCaseTimeLimitExceeded || result.Status == TestCaseMemoryLimitExceeded {
+		return result, nil
+	}
+	if err != nil {
+		return TestCaseResult{}, err
+	}
+	if _, ok := interactorErr.(*exec.ExitError); ok {
+		return result, nil
+	}
+	if interactorErr != nil {
+		return TestCaseResult{}, interactorErr
+	}
+	result.Status = TestCaseSuccess
+	return result, nil
+}
+
+// createMismatchedOutput creates the output file of a failed test case. Only the output after the first mismatch is streamed
+// by the checker, so the first compared bytes (which are equal to the expected output) are copied from the expected output file.
+func (cptool *CPTool) createMismatchedOutput(outputFilePath string, testCase TestCase, compared int64) (afero.File, error) {
 	expectedOutputFile, err := cptool.fs.Open(testCase.OutputPath)
-	if expectedOutputFile != nil {
-		defer expectedOutputFile.Close()
+	if err != nil {
+		return nil, err
 	}
+	defer expectedOutputFile.Close()
+
+	file, err := cptool.fs.Create(outputFilePath)
 	if err != nil {
-		return false, 0, err
+		return nil, err
 	}
-	same, _ := equalfile.CompareReader(outputFile, expectedOutputFile)
-	if !same {
-		return false, duration, nil
+	if _, err := io.CopyN(file, expectedOutputFile, compared); err != nil {
+		file.Close()
+		return nil, err
 	}
-	return true, duration, nil
+	return file, nil
 }
@@ -3,11 +3,14 @@ package core
 import (
 	"context"
 	"errors"
+	"os/exec"
 	"path"
+	"reflect"
 	"testing"
 	"time"
 
 	"github.com/jauhararifin/cptool/internal/executioner"
+	"github.com/spf13/afero"
 )
 
 func TestTest(t *testing.T) {
@@ -24,12 +27,50 @@ func TestTest(t *testing.T) {
 		LastUpdated: time.Now(),
 	}
 
-	_, err := cptool.Test(context.Background(), solution, "test")
+	_, err := cptool.Test(context.Background(), solution, "test", nil)
 	if err != nil {
 		t.Error(err)
 	}
 }
 
+func TestTestWithObserver(t *testing.T) {
+	cptool := newTest()
+	solution, _ := prepareTestCase(cptool, "input", "expected_output", "expected_output")
+	cptool.fs.Create(solution.Path)
+	conf := cptool.GetConfig()
+	conf.Jobs = 2
+	cptool.SetConfig(conf)
+
+	events := make([]TestEvent, 0)
+	result, err := cptool.Test(context.Background(), solution, "tc", func(event TestEvent) {
+		events = append(events, event)
+	})
+	if err != nil {
+		t.Error(err)
+	}
+	types := make([]int, 0)
+	for _, event := range events {
+		types = append(types, event.Type)
+	}
+	expected := []int{TestEventCompileStarted, TestEventCompileFinished, TestEventTestStarted, TestEventTestFinished}
+	if !reflect.DeepEqual(types, expected) {
+		t.Error("observer should receive compile and test events, found:", types)
+	}
+	if events[3].TestCase.Name != "tc1" || events[3].Result.Status != result.TestCaseResults[0].Status {
+		t.Error("test finished event should contain the test case's result, found:", events[3])
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	events = events[:0]
+	cptool.Test(ctx, solution, "tc", func(event TestEvent) {
+		events = append(events, event)
+	})
+	if len(events) == 0 || events[len(events)-1].Type != TestEventAborted || events[len(events)-1].Err != context.Canceled {
+		t.Error("observer should receive aborted event when the context is done, found:", events)
+	}
+}
+
 func prepareTestCase(cptool *CPTool, inputStr, expectedOutputStr, outputStr string) (Solution, TestCase) {
 	solution := Solution{
 		Name:        "solution",
@@ -68,7 +109,8 @@ func TestRunSingleTestCase(t *testing.T) {
 	cptool := newTest()
 	solution, testCase := prepareTestCase(cptool, "input", "expected_output", "expected_output")
 	cptool.languages[solution.Language.Name] = solution.Language
-	success, _, err := cptool.runSingleTest(context.Background(), solution, testCase)
+	result, err := cptool.runSingleTest(context.Background(), solution, testCase)
+	success := result.Status == TestCaseSuccess
 	if err != nil {
 		t.Error(err)
 	}
@@ -81,7 +123,8 @@ func TestRunSingleTestCaseFailed(t *testing.T) {
 	cptool := newTest()
 	solution, testCase := prepareTestCase(cptool, "input", "expected_output", "some_different_output_with_exptected_output")
 	cptool.languages[solution.Language.Name] = solution.Language
-	success, _, err := cptool.runSingleTest(context.Background(), solution, testCase)
+	result, err := cptool.runSingleTest(context.Background(), solution, testCase)
+	success := result.Status == TestCaseSuccess
 	if err != nil {
 		t.Error(err)
 	}
@@ -90,6 +133,73 @@ func TestRunSingleTestCaseFailed(t *testing.T) {
 	}
 }
 
+func TestRunSingleTestCaseOutputNotSaved(t *testing.T) {
+	cptool := newTest()
+	solution, testCase := prepareTestCase(cptool, "input", "expected_output", "expected_output")
+	cptool.languages[solution.Language.Name] = solution.Language
+	cptool.runSingleTest(context.Background(), solution, testCase)
+	if ok, _ := afero.Exists(cptool.fs, cptool.getOutputTarget(solution, testCase)); ok {
+		t.Error("RunSingleTestCase should not save output of successfull test case")
+	}
+}
+
+func TestRunSingleTestCaseFailedOutputSaved(t *testing.T) {
+	cptool := newTest()
+	solution, testCase := prepareTestCase(cptool, "input", "expected_output", "expected_different_output")
+	cptool.languages[solution.Language.Name] = solution.Language
+	cptool.runSingleTest(context.Background(), solution, testCase)
+	output, err := afero.ReadFile(cptool.fs, cptool.getOutputTarget(solution, testCase))
+	if err != nil {
+		t.Error(err)
+	}
+	if string(output) != "expected_different_output" {
+		t.Error("RunSingleTestCase should save the whole output of failed test case, found:", string(output))
+	}
+}
+
+func TestRunSingleTestCaseWithKeepOutputs(t *testing.T) {
+	cptool := newTest()
+	cptool.SetKeepOutputs(true)
+	solution, testCase := prepareTestCase(cptool, "input", "expected_output", "expected_output")
+	cptool.languages[solution.Language.Name] = solution.Language
+	result, err := cptool.runSingleTest(context.Background(), solution, testCase)
+	success := result.Status == TestCaseSuccess
+	if err != nil {
+		t.Error(err)
+	}
+	if !success {
+		t.Error("RunSingleTestCase should returns success")
+	}
+	output, err := afero.ReadFile(cptool.fs, cptool.getOutputTarget(solution, testCase))
+	if err != nil {
+		t.Error(err)
+	}
+	if string(output) != "expected_output" {
+		t.Error("RunSingleTestCase should save output when keepOutputs is set, found:", string(output))
+	}
+}
+
+func TestTestSingleTestCaseActualOutputPath(t *testing.T) {
+	cptool := newTest()
+	solution, testCase := prepareTestCase(cptool, "input", "expected_output", "wrong_output")
+	cptool.languages[solution.Language.Name] = solution.Language
+	result := cptool.testSingleTestCase(context.Background(), solution, testCase)
+	if result.Status != TestCaseFailed {
+		t.Error("testSingleTestCase should returns failed, found:", result.Status)
+	}
+	if result.ActualOutputPath != cptool.getOutputTarget(solution, testCase) {
+		t.Error("testSingleTestCase should returns the path of saved output, found:", result.ActualOutputPath)
+	}
+
+	cptool = newTest()
+	solution, testCase = prepareTestCase(cptool, "input", "expected_output", "expected_output")
+	cptool.languages[solution.Language.Name] = solution.Language
+	result = cptool.testSingleTestCase(context.Background(), solution, testCase)
+	if len(result.ActualOutputPath) > 0 {
+		t.Error("testSingleTestCase shouldn't returns output path when the output is not saved, found:", result.ActualOutputPath)
+	}
+}
+
 func TestRunSingleTestCaseSkippedDueToRuntimeError(t *testing.T) {
 	cptool := newTest()
 	solution, testCase := prepareTestCase(cptool, "input", "expected_output", "expected_output")
@@ -98,7 +208,8 @@ func TestRunSingleTestCaseSkippedDueToRuntimeError(t *testing.T) {
 	memexec.RunCallback = func(m *executioner.MemCmd) error {
 		return errors.New("some error")
 	}
-	success, _, err := cptool.runSingleTest(context.Background(), solution, testCase)
+	result, err := cptool.runSingleTest(context.Background(), solution, testCase)
+	success := result.Status == TestCaseSuccess
 	if err == nil {
 		t.Error("RunSingleTestCase should returns error")
 	}
@@ -112,7 +223,8 @@ func TestRunSingleTestCaseSkippedDueToMissingInput(t *testing.T) {
 	solution, testCase := prepareTestCase(cptool, "input", "expected_output", "expected_output")
 	testCase.InputPath = "/tmp/wow"
 	cptool.languages[solution.Language.Name] = solution.Language
-	success, _, err := cptool.runSingleTest(context.Background(), solution, testCase)
+	result, err := cptool.runSingleTest(context.Background(), solution, testCase)
+	success := result.Status == TestCaseSuccess
 	if err == nil {
 		t.Error("RunSingleTestCase should returns error")
 	}
@@ -126,7 +238,8 @@ func TestRunSingleTestCaseSkippedDueToMissingOutput(t *testing.T) {
 	solution, testCase := prepareTestCase(cptool, "input", "expected_output", "expected_output")
 	testCase.OutputPath = "/tmp/wow"
 	cptool.languages[solution.Language.Name] = solution.Language
-	success, _, err := cptool.runSingleTest(context.Background(), solution, testCase)
+	result, err := cptool.runSingleTest(context.Background(), solution, testCase)
+	success := result.Status == TestCaseSuccess
 	if err == nil {
 		t.Error("RunSingleTestCase should returns error")
 	}
@@ -135,6 +248,168 @@ func TestRunSingleTestCaseSkippedDueToMissingOutput(t *testing.T) {
 	}
 }
 
+func TestRunSingleTestCaseTimeLimitExceeded(t *testing.T) {
+	cptool := newTest()
+	solution, testCase := prepareTestCase(cptool, "input", "expected_output", "expected_output")
+	cptool.languages[solution.Language.Name] = solution.Language
+	cptool.SetProblemConfig(ProblemConfig{TimeLimit: 10 * time.Millisecond})
+	memexec := getCptoolMemExec(cptool)
+	memexec.RunCallback = func(m *executioner.MemCmd) error {
+		<-m.Context.Done()
+		return m.Context.Err()
+	}
+	result, err := cptool.runSingleTest(context.Background(), solution, testCase)
+	if err != nil {
+		t.Error(err)
+	}
+	if result.Status != TestCaseTimeLimitExceeded {
+		t.Error("RunSingleTestCase should returns time limit exceeded")
+	}
+}
+
+func TestCheckLimitsMemoryLimitExceeded(t *testing.T) {
+	cptool := newTest()
+	cptool.SetProblemConfig(ProblemConfig{MemoryLimit: 1})
+	ctx := context.Background()
+	result := cptool.checkLimits(ctx, ctx, Language{}, TestCase{}, ExecutionResult{Memory: 2 * 1024 * 1024}, 0, nil)
+	if result.Status != TestCaseMemoryLimitExceeded {
+		t.Error("checkLimits should returns memory limit exceeded")
+	}
+	result = cptool.checkLimits(ctx, ctx, Language{}, TestCase{}, ExecutionResult{Memory: 1024 * 1024}, 0, nil)
+	if result.Status != TestCaseFailed {
+		t.Error("checkLimits should returns failed when limits are not exceeded")
+	}
+}
+
+func TestCheckLimitsWithLanguageBonus(t *testing.T) {
+	cptool := newTest()
+	cptool.SetProblemConfig(ProblemConfig{MemoryLimit: 1})
+	ctx := context.Background()
+	language := Language{MemoryBonus: 2}
+	result := cptool.checkLimits(ctx, ctx, language, TestCase{}, ExecutionResult{Memory: 2 * 1024 * 1024}, 0, nil)
+	if result.Status != TestCaseFailed {
+		t.Error("checkLimits should add memory bonus of the language to the memory limit")
+	}
+	result = cptool.checkLimits(ctx, ctx, language, TestCase{}, ExecutionResult{Memory: 4 * 1024 * 1024}, 0, nil)
+	if result.Status != TestCaseMemoryLimitExceeded {
+		t.Error("checkLimits should returns memory limit exceeded when adjusted memory limit is exceeded")
+	}
+}
+
+func TestRunSingleTestCaseWithTimeFactor(t *testing.T) {
+	cptool := newTest()
+	solution, testCase := prepareTestCase(cptool, "input", "expected_output", "expected_output")
+	solution.Language.TimeFactor = 5
+	cptool.languages[solution.Language.Name] = solution.Language
+	cptool.SetProblemConfig(ProblemConfig{TimeLimit: 10 * time.Millisecond})
+	memexec := getCptoolMemExec(cptool)
+	memexec.RunCallback = func(m *executioner.MemCmd) error {
+		select {
+		case <-m.Context.Done():
+			return m.Context.Err()
+		case <-time.After(20 * time.Millisecond):
+		}
+		_, err := m.Stdout.Write([]byte("expected_output"))
+		return err
+	}
+	result, err := cptool.runSingleTest(context.Background(), solution, testCase)
+	if err != nil {
+		t.Error(err)
+	}
+	if result.Status != TestCaseSuccess {
+		t.Error("RunSingleTestCase should use the time limit adjusted by the language, found:", result.Status)
+	}
+}
+
+func TestRunSingleTestCaseWithChecker(t *testing.T) {
+	cptool := newTest()
+	solution, testCase := prepareTestCase(cptool, "input", "expected_output", "accepted_output")
+	cptool.languages[solution.Language.Name] = solution.Language
+	cptool.SetProblemConfig(ProblemConfig{Checker: "/checker"})
+	memexec := getCptoolMemExec(cptool)
+	memexec.RunCallback = func(m *executioner.MemCmd) error {
+		if m.GetPath() == "/checker" {
+			args := m.GetArgs()
+			output, _ := afero.ReadFile(cptool.fs, args[2])
+			if args[1] != testCase.InputPath || args[3] != testCase.OutputPath {
+				t.Error("checker should receive input file, output file and expected output file")
+			}
+			if string(output) != "accepted_output" {
+				return &exec.ExitError{}
+			}
+			return nil
+		}
+		_, err := m.Stdout.Write([]byte("accepted_output"))
+		return err
+	}
+	result, err := cptool.runSingleTest(context.Background(), solution, testCase)
+	if err != nil {
+		t.Error(err)
+	}
+	if result.Status != TestCaseSuccess {
+		t.Error("RunSingleTestCase should returns success when checker accepts the output")
+	}
+	if ok, _ := afero.Exists(cptool.fs, cptool.getOutputTarget(solution, testCase)); ok {
+		t.Error("RunSingleTestCase should not save output of successfull test case")
+	}
+
+	memexec.RunCallback = func(m *executioner.MemCmd) error {
+		if m.GetPath() == "/checker" {
+			return &exec.ExitError{}
+		}
+		return nil
+	}
+	result, err = cptool.runSingleTest(context.Background(), solution, testCase)
+	if err != nil {
+		t.Error(err)
+	}
+	if result.Status != TestCaseFailed {
+		t.Error("RunSingleTestCase should returns failed when checker rejects the output")
+	}
+}
+
+func TestRunSingleTestCaseWithInteractor(t *testing.T) {
+	cptool := newTest()
+	solution, testCase := prepareTestCase(cptool, "input", "expected_output", "expected_output")
+	cptool.languages[solution.Language.Name] = solution.Language
+	cptool.SetProblemConfig(ProblemConfig{Interactor: "/interactor"})
+	memexec := getCptoolMemExec(cptool)
+	interactorStarted := false
+	memexec.RunCallback = func(m *executioner.MemCmd) error {
+		return nil
+	}
+	memexec.StartCallback = func(m *executioner.MemCmd) error {
+		if m.GetPath() == "/interactor" {
+			interactorStarted = true
+		}
+		return nil
+	}
+	result, err := cptool.runSingleTest(context.Background(), solution, testCase)
+	if err != nil {
+		t.Error(err)
+	}
+	if !interactorStarted {
+		t.Error("RunSingleTestCase should start the interactor")
+	}
+	if result.Status != TestCaseSuccess {
+		t.Error("RunSingleTestCase should returns success when interactor accepts the solution")
+	}
+
+	memexec.WaitCallback = func(m *executioner.MemCmd) error {
+		if m.GetPath() == "/interactor" {
+			return &exec.ExitError{}
+		}
+		return nil
+	}
+	result, err = cptool.runSingleTest(context.Background(), solution, testCase)
+	if err != nil {
+		t.Error(err)
+	}
+	if result.Status != TestCaseFailed {
+		t.Error("RunSingleTestCase should returns failed when interactor rejects the solution")
+	}
+}
+
 func TestTestByName(t *testing.T) {
 	cptool := newTest()
 	solution, testCase := prepareTestCase(cptool, "input", "expected_output", "expected_output")
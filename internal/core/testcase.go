@@ -5,6 +5,7 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/spf13/afero"
@@ -32,30 +33,48 @@ func (cptool *CPTool) getTestCaseByName(testcaseName string) (TestCase, error) {
 	return TestCase{}, ErrNoSuchTestCase
 }
 
+// GetTestCases returns all test cases whose name starts with testcasePrefix. The test cases are searched using "test_pattern" of the
+// problem configuration when it is set, otherwise every "NAME.in" file that has "NAME.out" pair in the directory of the prefix
+// (current working directory when the prefix has no directory) is a test case.
+func (cptool *CPTool) GetTestCases(testcasePrefix string) []TestCase {
+	return cptool.getAllTestCaseWithPrefix(testcasePrefix)
+}
+
 func (cptool *CPTool) getAllTestCaseWithPrefix(testcasePrefix string) []TestCase {
+	if len(cptool.problem.TestPattern) > 0 {
+		return cptool.getAllTestCaseWithPattern(cptool.problem.TestPattern, testcasePrefix)
+	}
+
 	testCases := make([]TestCase, 0)
-	cwd := filepath.Clean(cptool.workingDirectory)
-	afero.Walk(cptool.fs, cwd, func(testPath string, info os.FileInfo, err error) error {
-		if info.IsDir() && testPath != cwd {
-			return filepath.SkipDir
-		}
-		relativePath := filepath.Clean(testPath)[len(cwd):]
-		if len(relativePath) == 0 {
+	directory := cptool.workingDirectory
+	prefix := testcasePrefix
+	if i := strings.LastIndex(testcasePrefix, "/"); i >= 0 {
+		directory = cptool.resolvePath(testcasePrefix[:i+1])
+		prefix = testcasePrefix[i+1:]
+	}
+	directory = filepath.Clean(directory)
+	afero.Walk(cptool.fs, directory, func(testPath string, info os.FileInfo, err error) error {
+		if err != nil {
 			return nil
 		}
-		if relativePath[0] == '/' {
-			relativePath = relativePath[1:]
+		if info.IsDir() && testPath != directory {
+			return filepath.SkipDir
 		}
-		if strings.HasPrefix(relativePath, testcasePrefix) && filepath.Ext(testPath) == ".in" {
-			testName := relativePath[:len(relativePath)-3]
-			outputFilePath := path.Join(cptool.workingDirectory, testName+".out")
+		fileName := path.Base(testPath)
+		if !info.IsDir() && strings.HasPrefix(fileName, prefix) && filepath.Ext(testPath) == ".in" {
+			inputPath := filepath.Clean(testPath)
+			outputFilePath := strings.TrimSuffix(inputPath, ".in") + ".out"
 			info, err := cptool.fs.Stat(outputFilePath)
 			if err != nil || info.IsDir() {
 				return nil
 			}
+			testName := strings.TrimSuffix(fileName, ".in")
+			if relativePath, ok := cptool.getRelativePath(inputPath); ok {
+				testName = strings.TrimSuffix(relativePath, ".in")
+			}
 			testCases = append(testCases, TestCase{
 				Name:       testName,
-				InputPath:  testPath,
+				InputPath:  inputPath,
 				OutputPath: outputFilePath,
 			})
 		}
@@ -63,3 +82,37 @@ func (cptool *CPTool) getAllTestCaseWithPrefix(testcasePrefix string) []TestCase
 	})
 	return testCases
 }
+
+// getAllTestCaseWithPattern returns all test cases whose input file matches the glob pattern. The pattern is relative to current
+// working directory, and the expected output file of every test case is the input file with ".out" extension instead of ".in".
+func (cptool *CPTool) getAllTestCaseWithPattern(pattern string, testcasePrefix string) []TestCase {
+	testCases := make([]TestCase, 0)
+	cwd := filepath.Clean(cptool.workingDirectory)
+	if !filepath.IsAbs(pattern) {
+		pattern = path.Join(cwd, pattern)
+	}
+	matches, err := afero.Glob(cptool.fs, pattern)
+	if err != nil {
+		return testCases
+	}
+	sort.Strings(matches)
+	for _, inputPath := range matches {
+		if filepath.Ext(inputPath) != ".in" {
+			continue
+		}
+		relativePath, err := filepath.Rel(cwd, inputPath)
+		if err != nil || !strings.HasPrefix(relativePath, testcasePrefix) {
+			continue
+		}
+		outputFilePath := strings.TrimSuffix(inputPath, ".in") + ".out"
+		if info, err := cptool.fs.Stat(outputFilePath); err != nil || info.IsDir() {
+			continue
+		}
+		testCases = append(testCases, TestCase{
+			Name:       strings.TrimSuffix(relativePath, ".in"),
+			InputPath:  inputPath,
+			OutputPath: outputFilePath,
+		})
+	}
+	return testCases
+}
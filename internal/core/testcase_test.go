@@ -80,3 +80,53 @@ func TestGetTestCasesByNameWithMissingTestCase(t *testing.T) {
 		t.Error("getTestCaseByName should return ErrNoSuchTestCase error")
 	}
 }
+
+func TestGetTestCasesWithPattern(t *testing.T) {
+	cptool := newTest()
+	cptool.fs.Create(path.Join(cptool.workingDirectory, "tests/1.in"))
+	cptool.fs.Create(path.Join(cptool.workingDirectory, "tests/1.out"))
+	cptool.fs.Create(path.Join(cptool.workingDirectory, "tests/2.in"))
+	cptool.fs.Create(path.Join(cptool.workingDirectory, "test.3.in"))
+	cptool.fs.Create(path.Join(cptool.workingDirectory, "test.3.out"))
+	cptool.SetProblemConfig(ProblemConfig{TestPattern: "tests/*.in"})
+
+	testcases := cptool.getAllTestCaseWithPrefix("")
+	if len(testcases) != 1 {
+		t.Error("getAllTestCaseWithPrefix should return 1 testcase")
+	}
+	tc1 := TestCase{
+		Name:       "tests/1",
+		InputPath:  path.Join(cptool.workingDirectory, "tests/1.in"),
+		OutputPath: path.Join(cptool.workingDirectory, "tests/1.out"),
+	}
+	if len(testcases) > 0 && testcases[0] != tc1 {
+		t.Error("getAllTestCaseWithPrefix should return ", tc1)
+	}
+	if len(cptool.getAllTestCaseWithPrefix("other")) != 0 {
+		t.Error("getAllTestCaseWithPrefix should filter test cases by prefix")
+	}
+}
+
+func TestGetTestCasesWithPrefixInDirectory(t *testing.T) {
+	cptool := newTest()
+	cptool.fs.Create(path.Join(cptool.workingDirectory, "problems/A/sol.1.in"))
+	cptool.fs.Create(path.Join(cptool.workingDirectory, "problems/A/sol.1.out"))
+	cptool.fs.Create(path.Join(cptool.workingDirectory, "problems/A/other.1.in"))
+	cptool.fs.Create(path.Join(cptool.workingDirectory, "problems/A/other.1.out"))
+	cptool.fs.Create(path.Join(cptool.workingDirectory, "sol.1.in"))
+	cptool.fs.Create(path.Join(cptool.workingDirectory, "sol.1.out"))
+
+	testcases := cptool.getAllTestCaseWithPrefix("problems/A/sol")
+	expected := TestCase{
+		Name:       "problems/A/sol.1",
+		InputPath:  path.Join(cptool.workingDirectory, "problems/A/sol.1.in"),
+		OutputPath: path.Join(cptool.workingDirectory, "problems/A/sol.1.out"),
+	}
+	if len(testcases) != 1 || testcases[0] != expected {
+		t.Error("getAllTestCaseWithPrefix should return", expected, ", but found", testcases)
+	}
+
+	if testcases := cptool.getAllTestCaseWithPrefix("problems/A/"); len(testcases) != 2 {
+		t.Error("getAllTestCaseWithPrefix should return every test case in the directory, found:", testcases)
+	}
+}
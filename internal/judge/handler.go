@@ -0,0 +1,386 @@
+package judge
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jauhararifin/cptool/pkg/cptool"
+)
+
+// teamCookie remembers the team name of the browser, so it doesn't have to be typed on every submission.
+const teamCookie = "cptool_team"
+
+const layoutTemplate = `{{define "layout"}}<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+{{if .Refresh}}<meta http-equiv="refresh" content="2">{{end}}
+<title>{{.Title}} - cptool judge</title>
+<style>
+body { font-family: sans-serif; margin: 2em auto; max-width: 60em; }
+nav a { margin-right: 1em; }
+table { border-collapse: collapse; width: 100%; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.6em; text-align: left; }
+pre { background: #f4f4f4; padding: 1em; overflow: auto; }
+textarea { width: 100%; font-family: monospace; }
+.accepted { color: #080; }
+.rejected { color: #c00; }
+.pending { color: #888; }
+.error { color: #c00; }
+</style>
+</head>
+<body>
+<nav><a href="/">Scoreboard</a><a href="/submit">Submit</a><a href="/submissions">Submissions</a></nav>
+<h1>{{.Title}}</h1>
+{{template "content" .}}
+</body>
+</html>
+{{end}}`
+
+const scoreboardTemplate = `{{define "content"}}
+<table>
+<tr><th>Rank</th><th>Team</th><th>Solved</th><th>Penalty</th>{{range .Problems}}<th title="{{.Name}}">{{.ID}}</th>{{end}}</tr>
+{{$problems := .Problems}}
+{{range .Scoreboard}}{{$row := .}}
+<tr><td>{{.Rank}}</td><td>{{.Team}}</td><td>{{.Solved}}</td><td>{{.Penalty}}</td>
+{{range $problems}}{{$score := index $row.Scores .ID}}{{with formatScore $score}}<td class="{{scoreClass $score}}">{{.}}</td>{{else}}<td></td>{{end}}{{end}}
+</tr>
+{{else}}
+<tr><td colspan="{{len .Problems | add 4}}">No submissions yet</td></tr>
+{{end}}
+</table>
+<h2>Problems</h2>
+<table>
+<tr><th>Problem</th><th>Name</th><th>Time limit</th><th>Memory limit</th><th>Tests</th></tr>
+{{range .Problems}}
+<tr><td>{{.ID}}</td><td>{{.Name}}</td><td>{{formatLimit .TimeLimit}}</td><td>{{if .MemoryLimit}}{{.MemoryLimit}} MB{{else}}unlimited{{end}}</td><td>{{.TestCount}}</td></tr>
+{{end}}
+</table>
+{{end}}`
+
+const submitTemplate = `{{define "content"}}
+{{if .Error}}<p class="error">{{.Error}}</p>{{end}}
+<form method="post" action="/submit" enctype="multipart/form-data">
+<p><label>Team <input name="team" value="{{.Team}}" maxlength="64" required></label></p>
+<p><label>Problem <select name="problem">{{range .Problems}}<option value="{{.ID}}">{{.ID}} - {{.Name}}</option>{{end}}</select></label></p>
+<p><label>Language <select name="language">{{range .Languages}}<option value="{{.Name}}">{{.VerboseName}}</option>{{end}}</select></label></p>
+<p><label>Source file <input type="file" name="file"></label></p>
+<p><label>Or paste the source code<br><textarea name="source" rows="20"></textarea></label></p>
+<p><button type="submit">Submit</button></p>
+</form>
+{{end}}`
+
+const submissionsTemplate = `{{define "content"}}
+<table>
+<tr><th>#</th><th>Time</th><th>Team</th><th>Problem</th><th>Language</th><th>Verdict</th></tr>
+{{range .Submissions}}
+<tr><td><a href="/submissions/{{.ID}}">{{.ID}}</a></td><td>{{formatTime .SubmittedAt}}</td><td>{{.Team}}</td><td>{{.ProblemID}}</td>
+<td>{{.Language}}</td><td class="{{statusClass .Status}}">{{formatStatus .}}</td></tr>
+{{else}}
+<tr><td colspan="6">No submissions yet</td></tr>
+{{end}}
+</table>
+{{end}}`
+
+const submissionTemplate = `{{define "content"}}
+{{with .Submission}}
+<table>
+<tr><th>Team</th><td>{{.Team}}</td></tr>
+<tr><th>Problem</th><td>{{.ProblemID}}</td></tr>
+<tr><th>Language</th><td>{{.Language}}</td></tr>
+<tr><th>Submitted at</th><td>{{formatTime .SubmittedAt}}</td></tr>
+<tr><th>Verdict</th><td class="{{statusClass .Status}}">{{formatStatus .}}</td></tr>
+{{if .Status.Finished}}{{if .Results}}<tr><th>Time</th><td>{{formatDuration .Duration}}</td></tr>
+<tr><th>Memory</th><td>{{formatMemory .Memory}}</td></tr>{{end}}{{end}}
+</table>
+{{if .Error}}<p class="error">{{.Error}}</p>{{end}}
+{{if .CompilationOutput}}<h2>Compilation output</h2><pre>{{.CompilationOutput}}</pre>{{end}}
+{{if .Results}}
+<h2>Test cases</h2>
+<table>
+<tr><th>#</th><th>Verdict</th><th>Time</th><th>Memory</th></tr>
+{{range $i, $result := .Results}}
+<tr><td>{{add $i 1}}</td><td class="{{verdictClass .Verdict}}">{{.Verdict}}</td><td>{{formatDuration .Duration}}</td><td>{{formatMemory .Memory}}</td></tr>
+{{end}}
+</table>
+{{end}}
+{{end}}
+{{end}}`
+
+var templateFuncs = template.FuncMap{
+	"add": func(a int, b int) int {
+		return a + b
+	},
+	"formatTime": func(t time.Time) string {
+		return t.Format("15:04:05")
+	},
+	"formatDuration": func(duration time.Duration) string {
+		return fmt.Sprintf("%.3f s", duration.Seconds())
+	},
+	"formatMemory": func(memory uint64) string {
+		return fmt.Sprintf("%d KB", memory/1024)
+	},
+	"formatLimit": func(limit time.Duration) string {
+		if limit == 0 {
+			return "unlimited"
+		}
+		return fmt.Sprint(limit.Seconds(), " s")
+	},
+	"formatStatus": formatStatus,
+	"formatScore":  formatScore,
+	"statusClass":  statusClass,
+	"verdictClass": func(verdict cptool.Verdict) string {
+		if verdict == cptool.Accepted {
+			return "accepted"
+		}
+		return "rejected"
+	},
+	"scoreClass": func(score ProblemScore) string {
+		if score.Solved {
+			return "accepted"
+		}
+		if score.Pending {
+			return "pending"
+		}
+		return "rejected"
+	},
+}
+
+var templates = map[string]*template.Template{
+	"scoreboard":  parsePage(scoreboardTemplate),
+	"submit":      parsePage(submitTemplate),
+	"submissions": parsePage(submissionsTemplate),
+	"submission":  parsePage(submissionTemplate),
+}
+
+func parsePage(content string) *template.Template {
+	page := template.Must(template.New("").Funcs(templateFuncs).Parse(layoutTemplate))
+	return template.Must(page.Parse(content))
+}
+
+// formatStatus returns the submission's verdict, like "Wrong Answer on test 3".
+func formatStatus(submission Submission) string {
+	if submission.FailedTest > 0 {
+		return fmt.Sprint(submission.Status, " on test ", submission.FailedTest)
+	}
+	return submission.Status.String()
+}
+
+// formatScore returns the scoreboard's cell of a problem, like "+1 (42)" for a problem solved at minute 42 after one rejected
+// attempt, or "-2" for a problem that is not solved after two rejected attempts.
+func formatScore(score ProblemScore) string {
+	result := ""
+	switch {
+	case score.Solved && score.Attempts == 0:
+		result = fmt.Sprint("+ (", score.SolvedAt, ")")
+	case score.Solved:
+		result = fmt.Sprint("+", score.Attempts, " (", score.SolvedAt, ")")
+	case score.Attempts > 0:
+		result = fmt.Sprint("-", score.Attempts)
+	}
+	if !score.Solved && score.Pending {
+		result = strings.TrimSpace(result + " ?")
+	}
+	return result
+}
+
+func statusClass(status Status) string {
+	switch {
+	case status == Accepted:
+		return "accepted"
+	case !status.Finished():
+		return "pending"
+	}
+	return "rejected"
+}
+
+type page struct {
+	Title   string
+	Refresh bool
+
+	Problems    []Problem
+	Languages   []cptool.Language
+	Scoreboard  []ScoreboardRow
+	Submissions []Submission
+	Submission  Submission
+	Team        string
+	Error       string
+}
+
+// HandlerOption configures the handler created by NewHandler.
+type HandlerOption func(*handlerOptions)
+
+type handlerOptions struct {
+	loopbackOnly bool
+}
+
+// WithLoopbackOnly makes the handler reject requests to a host other than a loopback address or "localhost". It should be used
+// when the judge listens on a loopback address, so web pages can't reach the judge by rebinding their domain name to 127.0.0.1.
+func WithLoopbackOnly() HandlerOption {
+	return func(options *handlerOptions) {
+		options.loopbackOnly = true
+	}
+}
+
+// NewHandler creates http handler that serves the judge's pages: the scoreboard at "/", the submission form at "/submit", every
+// submission at "/submissions" and the verdict of a submission at "/submissions/ID". The pages of submissions that are not judged
+// yet are refreshed automatically. Submissions whose Origin or Referer header comes from another host are rejected, so other web
+// pages can't submit to the judge on behalf of the browser.
+func NewHandler(judge *Judge, opts ...HandlerOption) http.Handler {
+	options := handlerOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		scoreboard := judge.Scoreboard()
+		refresh := false
+		for _, row := range scoreboard {
+			for _, score := range row.Scores {
+				refresh = refresh || score.Pending
+			}
+		}
+		render(w, http.StatusOK, "scoreboard", page{
+			Title:      "Scoreboard",
+			Refresh:    refresh,
+			Problems:   judge.Problems(),
+			Scoreboard: scoreboard,
+		})
+	})
+	mux.HandleFunc("/submit", func(w http.ResponseWriter, r *http.Request) {
+		handleSubmit(judge, w, r)
+	})
+	mux.HandleFunc("/submissions", func(w http.ResponseWriter, r *http.Request) {
+		submissions := judge.Submissions()
+		refresh := false
+		for _, submission := range submissions {
+			refresh = refresh || !submission.Status.Finished()
+		}
+		render(w, http.StatusOK, "submissions", page{Title: "Submissions", Refresh: refresh, Submissions: submissions})
+	})
+	mux.HandleFunc("/submissions/", func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/submissions/"))
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		submission, ok := judge.Submission(id)
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		render(w, http.StatusOK, "submission", page{
+			Title:      fmt.Sprint("Submission #", submission.ID),
+			Refresh:    !submission.Status.Finished(),
+			Submission: submission,
+		})
+	})
+	if !options.loopbackOnly {
+		return mux
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !isLoopbackHost(r.Host) {
+			http.Error(w, "host is not allowed: "+r.Host, http.StatusForbidden)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// IsLoopbackAddress returns whether the address, like "127.0.0.1:10045", only listens on a loopback interface.
+func IsLoopbackAddress(address string) bool {
+	host, _, err := net.SplitHostPort(address)
+	return err == nil && host != "" && isLoopbackHost(host)
+}
+
+func isLoopbackHost(host string) bool {
+	if hostname, _, err := net.SplitHostPort(host); err == nil {
+		host = hostname
+	}
+	host = strings.Trim(host, "[]")
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// isSameOrigin returns whether the request is sent by the judge's own pages. Browsers set the Origin header on cross origin POST
+// requests, older browsers only set the Referer header. Requests without both headers don't come from a browser, so they are
+// allowed.
+func isSameOrigin(r *http.Request) bool {
+	source := r.Header.Get("Origin")
+	if source == "" {
+		source = r.Header.Get("Referer")
+	}
+	if source == "" {
+		return true
+	}
+	sourceURL, err := url.Parse(source)
+	return err == nil && sourceURL.Host != "" && strings.EqualFold(sourceURL.Host, r.Host)
+}
+
+func handleSubmit(judge *Judge, w http.ResponseWriter, r *http.Request) {
+	form := page{Title: "Submit", Problems: judge.Problems(), Languages: judge.Languages()}
+	if cookie, err := r.Cookie(teamCookie); err == nil {
+		form.Team, _ = url.QueryUnescape(cookie.Value)
+	}
+	if r.Method == http.MethodGet {
+		render(w, http.StatusOK, "submit", form)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !isSameOrigin(r) {
+		http.Error(w, "cross origin submission is not allowed", http.StatusForbidden)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, 2*maxSourceSize)
+	source := r.FormValue("source")
+	if file, _, err := r.FormFile("file"); err == nil {
+		content, err := ioutil.ReadAll(file)
+		file.Close()
+		if err == nil && len(content) > 0 {
+			source = string(content)
+		}
+	}
+	form.Team = r.FormValue("team")
+	submission, err := judge.Submit(form.Team, r.FormValue("problem"), r.FormValue("language"), source)
+	if err != nil {
+		form.Error = err.Error()
+		render(w, http.StatusBadRequest, "submit", form)
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{Name: teamCookie, Value: url.QueryEscape(submission.Team), Path: "/", MaxAge: 30 * 24 * 60 * 60})
+	http.Redirect(w, r, fmt.Sprint("/submissions/", submission.ID), http.StatusSeeOther)
+}
+
+// render executes the page's template before writing the response, so a template error doesn't produce a partial page.
+func render(w http.ResponseWriter, status int, name string, data page) {
+	buffer := &bytes.Buffer{}
+	if err := templates[name].ExecuteTemplate(buffer, "layout", data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	buffer.WriteTo(w)
+}
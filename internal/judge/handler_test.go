@@ -0,0 +1,156 @@
+package judge
+
+import (
+	"bytes"
+	"context"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestHandlerSubmitForm(t *testing.T) {
+	judge, _ := newTest(t)
+	handler := NewHandler(judge)
+
+	request := httptest.NewRequest(http.MethodGet, "/submit", nil)
+	request.AddCookie(&http.Cookie{Name: teamCookie, Value: url.QueryEscape("the team")})
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	body := recorder.Body.String()
+	if recorder.Code != http.StatusOK || !strings.Contains(body, "A. Sum") || !strings.Contains(body, "Shell") {
+		t.Error("submit page should show the problems and the languages, found:", recorder.Code, body)
+	}
+	if !strings.Contains(body, "value=\"the team\"") {
+		t.Error("submit page should fill the team from the cookie, found:", body)
+	}
+
+	form := url.Values{"team": {"team"}, "problem": {"unknown"}, "language": {"sh"}, "source": {"echo 3"}}
+	request = httptest.NewRequest(http.MethodPost, "/submit", strings.NewReader(form.Encode()))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusBadRequest || !strings.Contains(recorder.Body.String(), ErrNoSuchProblem.Error()) {
+		t.Error("submit should show the error of invalid submission, found:", recorder.Code, recorder.Body)
+	}
+
+	request = httptest.NewRequest(http.MethodDelete, "/submit", nil)
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusMethodNotAllowed {
+		t.Error("submit should reject wrong method, found:", recorder.Code)
+	}
+}
+
+func TestHandlerSubmitAndVerdict(t *testing.T) {
+	judge, _ := newTest(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	judge.Start(ctx)
+	handler := NewHandler(judge)
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	writer.WriteField("team", "the team")
+	writer.WriteField("problem", "sum")
+	writer.WriteField("language", "sh")
+	file, _ := writer.CreateFormFile("file", "sum.sh")
+	file.Write([]byte("read a b\necho $((a+b))\n"))
+	writer.Close()
+	request := httptest.NewRequest(http.MethodPost, "/submit", body)
+	request.Header.Set("Content-Type", writer.FormDataContentType())
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusSeeOther || recorder.Header().Get("Location") != "/submissions/8" {
+		t.Fatal("submit should redirect to the submission, found:", recorder.Code, recorder.Header())
+	}
+	cookies := recorder.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != teamCookie || cookies[0].Value != url.QueryEscape("the team") {
+		t.Error("submit should remember the team, found:", cookies)
+	}
+	waitJudged(t, judge, 8)
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/submissions/8", nil))
+	if recorder.Code != http.StatusOK || !strings.Contains(recorder.Body.String(), "Accepted") {
+		t.Error("submission page should show the verdict, found:", recorder.Code, recorder.Body)
+	}
+	if strings.Contains(recorder.Body.String(), "http-equiv=\"refresh\"") {
+		t.Error("submission page shouldn't refresh after the submission is judged")
+	}
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+	if recorder.Code != http.StatusOK || !strings.Contains(recorder.Body.String(), "the team") ||
+		!strings.Contains(recorder.Body.String(), "<td></td><td class=\"accepted\">&#43; (0)</td>") {
+		t.Error("scoreboard should show the team's score, found:", recorder.Code, recorder.Body)
+	}
+
+	for _, target := range []string{"/submissions/9", "/submissions/abc", "/unknown"} {
+		recorder = httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, target, nil))
+		if recorder.Code != http.StatusNotFound {
+			t.Error(target, "should respond not found, found:", recorder.Code)
+		}
+	}
+}
+
+func TestHandlerRejectsCrossOriginSubmission(t *testing.T) {
+	judge, _ := newTest(t)
+	handler := NewHandler(judge)
+
+	form := url.Values{"team": {"team"}, "problem": {"sum"}, "language": {"sh"}, "source": {"echo 3"}}
+	for _, header := range []string{"Origin", "Referer"} {
+		for _, source := range []string{"http://evil.example", "null"} {
+			request := httptest.NewRequest(http.MethodPost, "/submit", strings.NewReader(form.Encode()))
+			request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			request.Header.Set(header, source)
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, request)
+			if recorder.Code != http.StatusForbidden {
+				t.Error("submit should reject", header, source, "found:", recorder.Code)
+			}
+		}
+	}
+	if submissions := judge.Submissions(); len(submissions) != 0 {
+		t.Error("cross origin submissions shouldn't be submitted, found:", len(submissions))
+	}
+
+	request := httptest.NewRequest(http.MethodPost, "/submit", strings.NewReader(form.Encode()))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Origin", "http://example.com")
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusSeeOther {
+		t.Error("submit should accept same origin submission, found:", recorder.Code, recorder.Body)
+	}
+}
+
+func TestHandlerWithLoopbackOnly(t *testing.T) {
+	judge, _ := newTest(t)
+	handler := NewHandler(judge, WithLoopbackOnly())
+
+	for host, code := range map[string]int{
+		"127.0.0.1:10045":    http.StatusOK,
+		"localhost:10045":    http.StatusOK,
+		"[::1]:10045":        http.StatusOK,
+		"evil.example:10045": http.StatusForbidden,
+		"192.168.1.10:10045": http.StatusForbidden,
+	} {
+		request := httptest.NewRequest(http.MethodGet, "/", nil)
+		request.Host = host
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		if recorder.Code != code {
+			t.Error("request to", host, "should respond", code, "found:", recorder.Code)
+		}
+	}
+
+	for address, expected := range map[string]bool{"127.0.0.1:10045": true, "localhost:80": true, ":10045": false, "0.0.0.0:10045": false} {
+		if IsLoopbackAddress(address) != expected {
+			t.Error("IsLoopbackAddress of", address, "should be", expected)
+		}
+	}
+}
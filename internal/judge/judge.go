@@ -0,0 +1,597 @@
+// Package judge hosts a small judge for practice sessions. Submissions are queued and judged by a pool of workers using the
+// problems' test cases, and the results are ranked in an ICPC style scoreboard.
+package judge
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jauhararifin/cptool/pkg/cptool"
+)
+
+// Status is the status of a submission.
+type Status int
+
+const (
+	// Queued indicates the submission is waiting for a worker.
+	Queued Status = iota
+
+	// Judging indicates the submission is being compiled or tested.
+	Judging
+
+	// Accepted indicates the submission passes every test case.
+	Accepted
+
+	// WrongAnswer indicates the submission's output is rejected on some test case.
+	WrongAnswer
+
+	// TimeLimitExceeded indicates the submission runs longer than the time limit on some test case, or judging it takes longer
+	// than the judge's timeout.
+	TimeLimitExceeded
+
+	// MemoryLimitExceeded indicates the submission uses more memory than the memory limit on some test case.
+	MemoryLimitExceeded
+
+	// RuntimeError indicates the submission exits with error on some test case.
+	RuntimeError
+
+	// CompilationError indicates the compiler rejects the submission.
+	CompilationError
+
+	// JudgeError indicates the submission cannot be judged, like when the problem has no test cases or a test case cannot be read.
+	// Submission.Error contains the reason.
+	JudgeError
+)
+
+var statusNames = map[Status]string{
+	Queued:              "Queued",
+	Judging:             "Judging",
+	Accepted:            "Accepted",
+	WrongAnswer:         "Wrong Answer",
+	TimeLimitExceeded:   "Time Limit Exceeded",
+	MemoryLimitExceeded: "Memory Limit Exceeded",
+	RuntimeError:        "Runtime Error",
+	CompilationError:    "Compilation Error",
+	JudgeError:          "Judge Error",
+}
+
+var verdictStatuses = map[cptool.Verdict]Status{
+	cptool.Accepted:            Accepted,
+	cptool.WrongAnswer:         WrongAnswer,
+	cptool.TimeLimitExceeded:   TimeLimitExceeded,
+	cptool.MemoryLimitExceeded: MemoryLimitExceeded,
+}
+
+// resultStatus returns the status of a submission that is not accepted on a test case. A skipped test case is a runtime error when
+// the submission exits with error or is killed, otherwise the test case cannot be judged, like when its files cannot be read.
+func resultStatus(result cptool.TestCaseResult) Status {
+	if result.Verdict != cptool.Skipped {
+		return verdictStatuses[result.Verdict]
+	}
+	var exitErr *exec.ExitError
+	if errors.As(result.Err, &exitErr) {
+		return RuntimeError
+	}
+	return JudgeError
+}
+
+func (status Status) String() string {
+	if name, ok := statusNames[status]; ok {
+		return name
+	}
+	return "Unknown"
+}
+
+// Finished indicates the submission is judged.
+func (status Status) Finished() bool {
+	return status != Queued && status != Judging
+}
+
+// Rejected indicates the submission is judged and counted as a rejected attempt in the scoreboard. Compilation errors and judge
+// errors are not counted.
+func (status Status) Rejected() bool {
+	return status.Finished() && status != Accepted && status != CompilationError && status != JudgeError
+}
+
+// Problem is a directory inside the problems directory. Its test cases and problem.toml are loaded the same way as cptool test does
+// in that directory. ID is the directory's name, and Name is the name in problem.toml or the ID when it is not configured.
+type Problem struct {
+	ID          string
+	Name        string
+	TimeLimit   time.Duration
+	MemoryLimit int
+	TestCount   int
+
+	client *cptool.CPTool
+}
+
+// Submission is a solution submitted by a team. FailedTest is the 1-based index of the first test case that is not accepted, and
+// Results contains the result of every test case. CompilationOutput contains the compiler's message when the compilation failed.
+type Submission struct {
+	ID          int
+	Team        string
+	ProblemID   string
+	Language    string
+	SubmittedAt time.Time
+	Status      Status
+	FailedTest  int
+	Results     []cptool.TestCaseResult
+	Duration    time.Duration
+	Memory      uint64
+	Error       string
+
+	CompilationOutput string
+
+	path string
+}
+
+// Judge queues and judges the submissions.
+type Judge struct {
+	problemsDirectory string
+	dataDirectory     string
+	workers           int
+	timeout           time.Duration
+	queueSize         int
+	clock             func() time.Time
+	clientOptions     []cptool.Option
+
+	startedAt time.Time
+	problems  []*Problem
+	languages []cptool.Language
+	queue     chan int
+
+	mutex       sync.Mutex
+	nextID      int
+	submissions map[int]*Submission
+}
+
+// Option configures a Judge created by New.
+type Option func(judge *Judge)
+
+// WithDataDirectory sets the directory where the submissions' source code is saved. By default, it is ".judge" inside the
+// problems directory.
+func WithDataDirectory(directory string) Option {
+	return func(judge *Judge) {
+		judge.dataDirectory = directory
+	}
+}
+
+// WithWorkers sets the number of submissions judged in parallel. By default, one submission is judged at a time, so the running
+// time of the submissions doesn't affect each other. Values below one are ignored.
+func WithWorkers(workers int) Option {
+	return func(judge *Judge) {
+		if workers > 0 {
+			judge.workers = workers
+		}
+	}
+}
+
+// WithTimeout sets the time limit of judging a submission, including its compilation. By default, it is one minute. Non positive
+// values are ignored.
+func WithTimeout(timeout time.Duration) Option {
+	return func(judge *Judge) {
+		if timeout > 0 {
+			judge.timeout = timeout
+		}
+	}
+}
+
+// WithQueueSize sets the number of submissions that can wait for a worker. By default, it is 1024. Values below one are ignored.
+func WithQueueSize(size int) Option {
+	return func(judge *Judge) {
+		if size > 0 {
+			judge.queueSize = size
+		}
+	}
+}
+
+// WithClock sets the function that returns current time, it is used for the submission time and the scoreboard's penalty.
+func WithClock(now func() time.Time) Option {
+	return func(judge *Judge) {
+		judge.clock = now
+	}
+}
+
+// WithClientOptions sets the options of the cptool used for every problem, like where the configuration and languages are loaded.
+func WithClientOptions(opts ...cptool.Option) Option {
+	return func(judge *Judge) {
+		judge.clientOptions = append(judge.clientOptions, opts...)
+	}
+}
+
+// ErrNoProblems indicates that the problems directory has no problem directory.
+var ErrNoProblems = errors.New("No problems found")
+
+// ErrNoSuchProblem indicates that the submitted problem doesn't exist.
+var ErrNoSuchProblem = errors.New("No such problem")
+
+// ErrNoSuchLanguage indicates that the submitted language doesn't exist.
+var ErrNoSuchLanguage = errors.New("No such language")
+
+// ErrInvalidTeam indicates that the team name is empty or too long.
+var ErrInvalidTeam = errors.New("Team name should contain 1 to 64 characters")
+
+// ErrInvalidSource indicates that the source code is empty or too large.
+var ErrInvalidSource = errors.New("Source code should not be empty or larger than 256 KB")
+
+// ErrQueueFull indicates that too many submissions are waiting to be judged.
+var ErrQueueFull = errors.New("Submission queue is full, try again later")
+
+const maxSourceSize = 256 * 1024
+const maxTeamLength = 64
+
+// New creates a Judge using every directory inside problemsDirectory as a problem, except the hidden ones. ErrNoProblems returned
+// when there is no problem directory, and cptool.ConfigError returned when a problem's configuration is malformed.
+func New(problemsDirectory string, opts ...Option) (*Judge, error) {
+	judge := &Judge{
+		problemsDirectory: problemsDirectory,
+		dataDirectory:     filepath.Join(problemsDirectory, ".judge"),
+		workers:           1,
+		timeout:           time.Minute,
+		queueSize:         1024,
+		clock:             time.Now,
+		submissions:       make(map[int]*Submission),
+	}
+	for _, opt := range opts {
+		opt(judge)
+	}
+	judge.startedAt = judge.clock()
+	judge.queue = make(chan int, judge.queueSize)
+
+	entries, err := ioutil.ReadDir(problemsDirectory)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		problem, err := judge.loadProblem(filepath.Join(problemsDirectory, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		judge.problems = append(judge.problems, problem)
+	}
+	if len(judge.problems) == 0 {
+		return nil, ErrNoProblems
+	}
+	judge.languages = judge.problems[0].client.Languages()
+
+	if err := os.MkdirAll(judge.getSubmissionsDirectory(), os.ModePerm); err != nil {
+		return nil, err
+	}
+	judge.nextID = judge.findNextID()
+	return judge, nil
+}
+
+func (judge *Judge) loadProblem(directory string) (*Problem, error) {
+	client, err := cptool.New(append(judge.clientOptions, cptool.WithWorkingDirectory(directory))...)
+	if err != nil {
+		return nil, err
+	}
+	config := client.Problem()
+	problem := &Problem{
+		ID:          filepath.Base(directory),
+		Name:        config.Name,
+		TimeLimit:   config.TimeLimit,
+		MemoryLimit: config.MemoryLimit,
+		TestCount:   len(client.TestCases("")),
+		client:      client,
+	}
+	if len(problem.Name) == 0 {
+		problem.Name = problem.ID
+	}
+	return problem, nil
+}
+
+func (judge *Judge) getSubmissionsDirectory() string {
+	return filepath.Join(judge.dataDirectory, "submissions")
+}
+
+// findNextID returns the ID after the submissions saved by the previous sessions, so their source code is not overwritten.
+func (judge *Judge) findNextID() int {
+	nextID := 1
+	entries, _ := ioutil.ReadDir(judge.getSubmissionsDirectory())
+	for _, entry := range entries {
+		if id, err := strconv.Atoi(entry.Name()); err == nil && id >= nextID {
+			nextID = id + 1
+		}
+	}
+	return nextID
+}
+
+// Problems returns every problem sorted by the ID.
+func (judge *Judge) Problems() []Problem {
+	problems := make([]Problem, 0, len(judge.problems))
+	for _, problem := range judge.problems {
+		problems = append(problems, *problem)
+	}
+	return problems
+}
+
+func (judge *Judge) getProblem(id string) (*Problem, bool) {
+	for _, problem := range judge.problems {
+		if problem.ID == id {
+			return problem, true
+		}
+	}
+	return nil, false
+}
+
+// Languages returns the languages that can be submitted.
+func (judge *Judge) Languages() []cptool.Language {
+	return judge.languages
+}
+
+func (judge *Judge) getLanguage(name string) (cptool.Language, bool) {
+	for _, language := range judge.languages {
+		if language.Name == name {
+			return language, true
+		}
+	}
+	return cptool.Language{}, false
+}
+
+// Start starts the workers, they judge the queued submissions until ctx is done.
+func (judge *Judge) Start(ctx context.Context) {
+	for i := 0; i < judge.workers; i++ {
+		go func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case id := <-judge.queue:
+					judge.judge(ctx, id)
+				}
+			}
+		}()
+	}
+}
+
+// Submit saves the source code and queues the submission. The source code is saved as "Main" with the language's extension, so it
+// can be compiled by languages that require the file name to match the class name.
+func (judge *Judge) Submit(team string, problemID string, languageName string, source string) (Submission, error) {
+	team = strings.TrimSpace(team)
+	if len(team) == 0 || len(team) > maxTeamLength {
+		return Submission{}, ErrInvalidTeam
+	}
+	if len(strings.TrimSpace(source)) == 0 || len(source) > maxSourceSize {
+		return Submission{}, ErrInvalidSource
+	}
+	if _, ok := judge.getProblem(problemID); !ok {
+		return Submission{}, ErrNoSuchProblem
+	}
+	language, ok := judge.getLanguage(languageName)
+	if !ok {
+		return Submission{}, ErrNoSuchLanguage
+	}
+
+	judge.mutex.Lock()
+	defer judge.mutex.Unlock()
+	if len(judge.queue) == cap(judge.queue) {
+		return Submission{}, ErrQueueFull
+	}
+
+	id := judge.nextID
+	directory := filepath.Join(judge.getSubmissionsDirectory(), strconv.Itoa(id))
+	if err := os.MkdirAll(directory, os.ModePerm); err != nil {
+		return Submission{}, err
+	}
+	sourcePath := filepath.Join(directory, "Main."+language.Extension)
+	if err := ioutil.WriteFile(sourcePath, []byte(source), 0644); err != nil {
+		return Submission{}, err
+	}
+
+	submission := &Submission{
+		ID:          id,
+		Team:        team,
+		ProblemID:   problemID,
+		Language:    language.Name,
+		SubmittedAt: judge.clock(),
+		Status:      Queued,
+		path:        sourcePath,
+	}
+	judge.nextID++
+	judge.submissions[id] = submission
+	judge.queue <- id
+	return *submission, nil
+}
+
+// Submission returns the submission with the ID, false returned when no such submission exists.
+func (judge *Judge) Submission(id int) (Submission, bool) {
+	judge.mutex.Lock()
+	defer judge.mutex.Unlock()
+	submission, ok := judge.submissions[id]
+	if !ok {
+		return Submission{}, false
+	}
+	return *submission, true
+}
+
+// Submissions returns every submission, the latest submission first.
+func (judge *Judge) Submissions() []Submission {
+	judge.mutex.Lock()
+	defer judge.mutex.Unlock()
+	submissions := make([]Submission, 0, len(judge.submissions))
+	for _, submission := range judge.submissions {
+		submissions = append(submissions, *submission)
+	}
+	sort.Slice(submissions, func(i, j int) bool {
+		return submissions[i].ID > submissions[j].ID
+	})
+	return submissions
+}
+
+func (judge *Judge) update(id int, update func(submission *Submission)) {
+	judge.mutex.Lock()
+	defer judge.mutex.Unlock()
+	update(judge.submissions[id])
+}
+
+// judge compiles and tests a submission using every test case of its problem. The submission gets the status of the first test
+// case that is not accepted, and every test case is tested so the verdict page can show all results. When judging takes longer
+// than the timeout, the first test case that is not finished in time gets TimeLimitExceeded, so a submission that never stops
+// is rejected even when the problem has no time limit.
+func (judge *Judge) judge(ctx context.Context, id int) {
+	var submission Submission
+	judge.update(id, func(s *Submission) {
+		s.Status = Judging
+		submission = *s
+	})
+	problem, _ := judge.getProblem(submission.ProblemID)
+
+	ctx, cancel := context.WithTimeout(ctx, judge.timeout)
+	defer cancel()
+	solution, err := problem.client.Solution(submission.path, submission.Language)
+	if err != nil {
+		judge.fail(id, err.Error())
+		return
+	}
+	// the test cases are tested one at a time, so the first finished ones are tested before the timeout.
+	finished := 0
+	observer := func(event cptool.Event) {
+		if event.Type == cptool.TestFinished && ctx.Err() == nil {
+			finished++
+		}
+	}
+	report, err := problem.client.Test(ctx, solution, cptool.TestOptions{Jobs: 1, Observer: observer})
+	var compilationErr *cptool.CompilationError
+	if errors.As(err, &compilationErr) {
+		judge.update(id, func(s *Submission) {
+			s.Status = CompilationError
+			s.CompilationOutput = compilationErr.Output
+		})
+		return
+	} else if err != nil {
+		judge.fail(id, err.Error())
+		return
+	}
+	if report.Aborted && ctx.Err() != context.DeadlineExceeded {
+		judge.fail(id, "Judging is stopped")
+		return
+	}
+	if len(report.Results) == 0 {
+		judge.fail(id, "Problem has no test cases")
+		return
+	}
+
+	judge.update(id, func(s *Submission) {
+		s.Status = Accepted
+		s.Results = report.Results
+		for i, result := range report.Results {
+			if result.Duration > s.Duration {
+				s.Duration = result.Duration
+			}
+			if result.Memory > s.Memory {
+				s.Memory = result.Memory
+			}
+			if s.Status == Accepted && i >= finished && report.Aborted {
+				s.Status = TimeLimitExceeded
+				s.FailedTest = i + 1
+				s.Error = "Judging takes longer than " + judge.timeout.String()
+				s.Results[i].Verdict = cptool.TimeLimitExceeded
+			}
+			if s.Status == Accepted && result.Verdict != cptool.Accepted {
+				s.Status = resultStatus(result)
+				s.FailedTest = i + 1
+				if s.Status == JudgeError && result.Err != nil {
+					s.Error = result.Err.Error()
+				}
+			}
+		}
+	})
+}
+
+// fail marks the submission as judge error.
+func (judge *Judge) fail(id int, message string) {
+	judge.update(id, func(s *Submission) {
+		s.Status = JudgeError
+		s.Error = message
+	})
+}
+
+// penaltyMinutes is the penalty of every rejected attempt before the problem is solved.
+const penaltyMinutes = 20
+
+// ProblemScore is a team's result of a problem. Attempts is the number of rejected submissions before the problem is solved, and
+// SolvedAt is the minutes since the judge started until the first accepted submission. Pending indicates the team has submissions
+// that are not judged yet.
+type ProblemScore struct {
+	Attempts int
+	Solved   bool
+	SolvedAt int
+	Pending  bool
+}
+
+// ScoreboardRow is a team's result. Penalty is the sum of the solved problems' SolvedAt, plus 20 minutes for every rejected attempt
+// before they are solved. Scores is keyed by the problem's ID.
+type ScoreboardRow struct {
+	Rank    int
+	Team    string
+	Solved  int
+	Penalty int
+	Scores  map[string]ProblemScore
+}
+
+// Scoreboard ranks the teams by the number of solved problems, then by the penalty. Teams with the same number of solved problems
+// and penalty have the same rank. Compilation errors and judge errors are not counted as rejected attempts, and the submissions
+// after the problem is solved are ignored.
+func (judge *Judge) Scoreboard() []ScoreboardRow {
+	submissions := judge.Submissions()
+	sort.Slice(submissions, func(i, j int) bool {
+		return submissions[i].ID < submissions[j].ID
+	})
+
+	rows := make(map[string]*ScoreboardRow)
+	for _, submission := range submissions {
+		row, ok := rows[submission.Team]
+		if !ok {
+			row = &ScoreboardRow{Team: submission.Team, Scores: make(map[string]ProblemScore)}
+			rows[submission.Team] = row
+		}
+		score := row.Scores[submission.ProblemID]
+		switch {
+		case score.Solved:
+		case !submission.Status.Finished():
+			score.Pending = true
+		case submission.Status == Accepted:
+			score.Solved = true
+			score.SolvedAt = int(submission.SubmittedAt.Sub(judge.startedAt).Minutes())
+			row.Solved++
+			row.Penalty += score.SolvedAt + score.Attempts*penaltyMinutes
+		case submission.Status.Rejected():
+			score.Attempts++
+		}
+		row.Scores[submission.ProblemID] = score
+	}
+
+	scoreboard := make([]ScoreboardRow, 0, len(rows))
+	for _, row := range rows {
+		scoreboard = append(scoreboard, *row)
+	}
+	sort.Slice(scoreboard, func(i, j int) bool {
+		if scoreboard[i].Solved != scoreboard[j].Solved {
+			return scoreboard[i].Solved > scoreboard[j].Solved
+		}
+		if scoreboard[i].Penalty != scoreboard[j].Penalty {
+			return scoreboard[i].Penalty < scoreboard[j].Penalty
+		}
+		return scoreboard[i].Team < scoreboard[j].Team
+	})
+	for i := range scoreboard {
+		scoreboard[i].Rank = i + 1
+		if i > 0 && scoreboard[i].Solved == scoreboard[i-1].Solved && scoreboard[i].Penalty == scoreboard[i-1].Penalty {
+			scoreboard[i].Rank = scoreboard[i-1].Rank
+		}
+	}
+	return scoreboard
+}
@@ -0,0 +1,285 @@
+package judge
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jauhararifin/cptool/pkg/cptool"
+)
+
+const shellLanguageConf = "verbose_name=\"Shell\"\nextension=\"sh\"\ninterpreted=true\n" +
+	"compile=[\"sh\", \"-n\", \"{source}\"]\nrun=[\"sh\", \"{source}\"]\n"
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (clock *fakeClock) Now() time.Time {
+	return clock.now
+}
+
+func writeFiles(t *testing.T, files map[string]string) {
+	for filePath, content := range files {
+		if err := os.MkdirAll(filepath.Dir(filePath), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filePath, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// newTest creates a judge with "sum" problem that has two test cases, and "empty" problem without test cases. The submissions
+// are written in shell, so they can be judged without a compiler.
+func newTest(t *testing.T, opts ...Option) (*Judge, *fakeClock) {
+	directory := t.TempDir()
+	home := filepath.Join(directory, "home")
+	problems := filepath.Join(directory, "problems")
+	writeFiles(t, map[string]string{
+		filepath.Join(home, ".cptool/langs/sh/lang.conf"):       shellLanguageConf,
+		filepath.Join(problems, "sum/problem.toml"):             "name = \"A. Sum\"\ntime_limit = \"2s\"\n",
+		filepath.Join(problems, "sum/1.in"):                     "1 2\n",
+		filepath.Join(problems, "sum/1.out"):                    "3\n",
+		filepath.Join(problems, "sum/2.in"):                     "5 6\n",
+		filepath.Join(problems, "sum/2.out"):                    "11\n",
+		filepath.Join(problems, "empty/problem.toml"):           "",
+		filepath.Join(problems, ".hidden/problem.toml"):         "",
+		filepath.Join(problems, ".judge/submissions/7/Main.sh"): "",
+	})
+
+	clock := &fakeClock{now: time.Date(2020, 1, 1, 10, 0, 0, 0, time.UTC)}
+	judge, err := New(problems, append([]Option{
+		WithClock(clock.Now),
+		WithClientOptions(
+			cptool.WithHomeDirectory(home),
+			cptool.WithCptoolHomeDirectory(""),
+			cptool.WithEnviron([]string{"PATH=" + os.Getenv("PATH")}),
+		),
+	}, opts...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return judge, clock
+}
+
+// waitJudged waits until the submission is judged.
+func waitJudged(t *testing.T, judge *Judge, id int) Submission {
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		if submission, _ := judge.Submission(id); submission.Status.Finished() {
+			return submission
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("submission is not judged:", id)
+	return Submission{}
+}
+
+func TestNew(t *testing.T) {
+	judge, _ := newTest(t)
+
+	problems := judge.Problems()
+	if len(problems) != 2 || problems[0].ID != "empty" || problems[1].ID != "sum" {
+		t.Fatal("New should load every problem directory except the hidden ones, found:", problems)
+	}
+	if problems[1].Name != "A. Sum" || problems[1].TimeLimit != 2*time.Second || problems[1].TestCount != 2 {
+		t.Error("New should load the problem configuration and test cases, found:", problems[1])
+	}
+	if problems[0].Name != "empty" {
+		t.Error("problem name should be its ID when not configured, found:", problems[0].Name)
+	}
+
+	if _, err := New(t.TempDir()); err != ErrNoProblems {
+		t.Error("New should return ErrNoProblems, found:", err)
+	}
+}
+
+func TestSubmit(t *testing.T) {
+	judge, _ := newTest(t)
+
+	submission, err := judge.Submit(" team ", "sum", "sh", "read a b\necho $((a+b))\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if submission.ID != 8 || submission.Team != "team" || submission.Status != Queued {
+		t.Error("Submit should queue the submission after the saved submissions, found:", submission)
+	}
+	if content, _ := ioutil.ReadFile(submission.path); string(content) != "read a b\necho $((a+b))\n" {
+		t.Error("Submit should save the source code, found:", string(content))
+	}
+
+	if _, err := judge.Submit("", "sum", "sh", "echo"); err != ErrInvalidTeam {
+		t.Error("Submit should reject empty team, found:", err)
+	}
+	if _, err := judge.Submit("team", "unknown", "sh", "echo"); err != ErrNoSuchProblem {
+		t.Error("Submit should reject unknown problem, found:", err)
+	}
+	if _, err := judge.Submit("team", "sum", "unknown", "echo"); err != ErrNoSuchLanguage {
+		t.Error("Submit should reject unknown language, found:", err)
+	}
+	if _, err := judge.Submit("team", "sum", "sh", " \n"); err != ErrInvalidSource {
+		t.Error("Submit should reject empty source code, found:", err)
+	}
+}
+
+func TestNewWithInvalidOptions(t *testing.T) {
+	judge, _ := newTest(t, WithWorkers(0), WithTimeout(-time.Second), WithQueueSize(-1))
+	if judge.workers != 1 || judge.timeout != time.Minute || judge.queueSize != 1024 {
+		t.Error("invalid options should be ignored, found:", judge.workers, judge.timeout, judge.queueSize)
+	}
+}
+
+func TestSubmitWithFullQueue(t *testing.T) {
+	judge, _ := newTest(t, WithQueueSize(1))
+
+	if _, err := judge.Submit("team", "sum", "sh", "echo 3"); err != nil {
+		t.Error(err)
+	}
+	if _, err := judge.Submit("team", "sum", "sh", "echo 3"); err != ErrQueueFull {
+		t.Error("Submit should reject submission when the queue is full, found:", err)
+	}
+}
+
+func TestJudge(t *testing.T) {
+	judge, _ := newTest(t, WithWorkers(2))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	judge.Start(ctx)
+
+	tests := []struct {
+		problem    string
+		source     string
+		status     Status
+		failedTest int
+	}{
+		{"sum", "read a b\necho $((a+b))\n", Accepted, 0},
+		{"sum", "echo 3\n", WrongAnswer, 2},
+		{"sum", "exit 1\n", RuntimeError, 1},
+		{"sum", "if then\n", CompilationError, 0},
+		{"empty", "echo 3\n", JudgeError, 0},
+	}
+	for _, test := range tests {
+		submission, err := judge.Submit("team", test.problem, "sh", test.source)
+		if err != nil {
+			t.Fatal(err)
+		}
+		submission = waitJudged(t, judge, submission.ID)
+		if submission.Status != test.status || submission.FailedTest != test.failedTest {
+			t.Error("submission", test.source, "should get", test.status, "on test", test.failedTest, ", found:",
+				submission.Status, submission.FailedTest, submission.Error)
+		}
+		if test.status == CompilationError && len(submission.CompilationOutput) == 0 {
+			t.Error("submission should contain the compiler's message")
+		}
+		if test.status == WrongAnswer && len(submission.Results) != 2 {
+			t.Error("submission should contain the result of every test case, found:", submission.Results)
+		}
+	}
+}
+
+func TestJudgeWithUnreadableTestCase(t *testing.T) {
+	judge, _ := newTest(t)
+	inputPath := filepath.Join(judge.problemsDirectory, "sum/2.in")
+	os.Remove(inputPath)
+	if err := os.Symlink("missing.in", inputPath); err != nil {
+		t.Fatal(err)
+	}
+
+	submission, err := judge.Submit("team", "sum", "sh", "read a b\necho $((a+b))\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	judge.judge(context.Background(), <-judge.queue)
+	submission = waitJudged(t, judge, submission.ID)
+	if submission.Status != JudgeError || submission.FailedTest != 2 || len(submission.Error) == 0 {
+		t.Error("submission should get judge error on test 2, found:", submission.Status, submission.FailedTest, submission.Error)
+	}
+	if score := judge.Scoreboard()[0].Scores["sum"]; score.Attempts != 0 {
+		t.Error("judge error should not be counted as rejected attempt, found:", score)
+	}
+}
+
+func TestJudgeWithTimeout(t *testing.T) {
+	judge, _ := newTest(t, WithTimeout(500*time.Millisecond))
+	writeFiles(t, map[string]string{
+		filepath.Join(judge.problemsDirectory, "loop/problem.toml"): "name = \"B. Loop\"\n",
+		filepath.Join(judge.problemsDirectory, "loop/1.in"):         "1\n",
+		filepath.Join(judge.problemsDirectory, "loop/1.out"):        "1\n",
+		filepath.Join(judge.problemsDirectory, "loop/2.in"):         "2\n",
+		filepath.Join(judge.problemsDirectory, "loop/2.out"):        "2\n",
+	})
+	problem, err := judge.loadProblem(filepath.Join(judge.problemsDirectory, "loop"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	judge.problems = append(judge.problems, problem)
+
+	submission, err := judge.Submit("team", "loop", "sh", "read a\nif [ $a = 2 ]; then while :; do :; done; fi\necho $a\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	judge.judge(context.Background(), <-judge.queue)
+	submission = waitJudged(t, judge, submission.ID)
+	if submission.Status != TimeLimitExceeded || submission.FailedTest != 2 {
+		t.Error("submission should get time limit exceeded on test 2, found:", submission.Status, submission.FailedTest,
+			submission.Error)
+	}
+	if len(submission.Results) != 2 || submission.Results[0].Verdict != cptool.Accepted ||
+		submission.Results[1].Verdict != cptool.TimeLimitExceeded {
+		t.Error("submission should contain the results before the timeout, found:", submission.Results)
+	}
+	if score := judge.Scoreboard()[0].Scores["loop"]; score.Attempts != 1 {
+		t.Error("time limit exceeded should be counted as rejected attempt, found:", score)
+	}
+}
+
+func TestScoreboard(t *testing.T) {
+	judge, clock := newTest(t)
+
+	submit := func(team string, source string, minutes int) {
+		clock.now = judge.startedAt.Add(time.Duration(minutes) * time.Minute)
+		submission, err := judge.Submit(team, "sum", "sh", source)
+		if err != nil {
+			t.Fatal(err)
+		}
+		judge.judge(context.Background(), <-judge.queue)
+		waitJudged(t, judge, submission.ID)
+	}
+	submit("alpha", "echo 3\n", 5)
+	submit("alpha", "if then\n", 6)
+	submit("alpha", "read a b\necho $((a+b))\n", 15)
+	submit("alpha", "echo 3\n", 16)
+	submit("beta", "read a b\necho $((a+b))\n", 30)
+	submit("gamma", "echo 3\n", 1)
+	submit("delta", "read a b\necho $((a+b))\n", 30)
+	if _, err := judge.Submit("gamma", "empty", "sh", "echo 3\n"); err != nil {
+		t.Fatal(err)
+	}
+
+	scoreboard := judge.Scoreboard()
+	if len(scoreboard) != 4 {
+		t.Fatal("scoreboard should contain every team, found:", scoreboard)
+	}
+	expected := []struct {
+		rank    int
+		team    string
+		solved  int
+		penalty int
+	}{{1, "beta", 1, 30}, {1, "delta", 1, 30}, {3, "alpha", 1, 35}, {4, "gamma", 0, 0}}
+	for i, row := range scoreboard {
+		if row.Rank != expected[i].rank || row.Team != expected[i].team || row.Solved != expected[i].solved ||
+			row.Penalty != expected[i].penalty {
+			t.Error("scoreboard row", i, "should be", expected[i], ", found:", row)
+		}
+	}
+	if score := scoreboard[2].Scores["sum"]; !score.Solved || score.Attempts != 1 || score.SolvedAt != 15 {
+		t.Error("alpha should solve sum at minute 15 after one rejected attempt, found:", score)
+	}
+	if score := scoreboard[3].Scores["empty"]; !score.Pending {
+		t.Error("gamma should have pending submission, found:", score)
+	}
+}
@@ -20,10 +20,11 @@ const WARN uint = 5
 // ERROR describe ERROR log level
 const ERROR uint = 6
 
-// Logger handle logging activity to the output target
+// Logger handle logging activity to the output target. When Colored is false, the messages are printed without color escape codes.
 type Logger struct {
 	OutputTarget io.Writer
 	LoggingLevel uint
+	Colored      bool
 }
 
 // New creates instance of logger
@@ -31,9 +32,17 @@ func New(outputTarget io.Writer, loggingLevel uint) *Logger {
 	return &Logger{
 		OutputTarget: outputTarget,
 		LoggingLevel: loggingLevel,
+		Colored:      true,
 	}
 }
 
+func (logger *Logger) color(code string) string {
+	if !logger.Colored {
+		return ""
+	}
+	return code
+}
+
 func (logger *Logger) printfLevel(level uint, format string, messages ...interface{}) {
 	if level >= logger.LoggingLevel {
 		fmt.Fprintf(logger.OutputTarget, format, messages...)
@@ -48,30 +57,30 @@ func (logger *Logger) printLevel(level uint, messages ...interface{}) {
 
 // PrintInfo print message in screen with INFO level
 func (logger *Logger) PrintInfo(messages ...interface{}) {
-	logger.printfLevel(INFO, "\033[0;34m[   Info    ] ")
+	logger.printfLevel(INFO, logger.color("\033[0;34m")+"[   Info    ] ")
 	logger.printLevel(INFO, messages...)
-	logger.printfLevel(INFO, "\033[0m\n")
+	logger.printfLevel(INFO, logger.color("\033[0m")+"\n")
 }
 
 // PrintWarning print message in screen with WARNING level
 func (logger *Logger) PrintWarning(messages ...interface{}) {
-	logger.printLevel(WARN, "\033[0;93m[   Warn    ] ")
+	logger.printLevel(WARN, logger.color("\033[0;93m")+"[   Warn    ] ")
 	logger.printLevel(WARN, messages...)
-	logger.printfLevel(WARN, "\033[0m\n")
+	logger.printfLevel(WARN, logger.color("\033[0m")+"\n")
 }
 
 // PrintError print message in screen with ERROR level
 func (logger *Logger) PrintError(messages ...interface{}) {
-	logger.printLevel(ERROR, "\033[0;31m[   Error   ] ")
+	logger.printLevel(ERROR, logger.color("\033[0;31m")+"[   Error   ] ")
 	logger.printLevel(ERROR, messages...)
-	logger.printfLevel(ERROR, "\033[0m\n")
+	logger.printfLevel(ERROR, logger.color("\033[0m")+"\n")
 }
 
 // PrintSuccess print message in screen with SUCCESS level
 func (logger *Logger) PrintSuccess(messages ...interface{}) {
-	logger.printLevel(INFO, "\033[0;32m[  Success  ] ")
+	logger.printLevel(INFO, logger.color("\033[0;32m")+"[  Success  ] ")
 	logger.printLevel(INFO, messages...)
-	logger.printfLevel(INFO, "\033[0m\n")
+	logger.printfLevel(INFO, logger.color("\033[0m")+"\n")
 }
 
 // Print print normal message in screen
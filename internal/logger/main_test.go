@@ -145,3 +145,14 @@ func TestPrintfWithHigherLevel(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestPrintErrorWithoutColor(t *testing.T) {
+	buf := new(bytes.Buffer)
+	logger := New(buf, INFO)
+	logger.Colored = false
+	logger.PrintError("just", "some", "test")
+	str := buf.String()
+	if strings.Contains(str, "\033[") || !strings.Contains(str, "justsometest") {
+		t.Fail()
+	}
+}
@@ -0,0 +1,10 @@
+// Package langs contains the stock language definitions. The definitions are embedded in cptool's binary, so they are available
+// without installing them into a configuration directory.
+package langs
+
+import "embed"
+
+// FS contains the stock language definitions, every language is a directory named after the language.
+//
+//go:embed c cpp cpp11 java kotlin pas python3
+var FS embed.FS
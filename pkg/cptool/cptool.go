@@ -0,0 +1,634 @@
+// Package cptool is the public API of cptool for embedding it in other tools, like contest dashboards and editor plugins. It
+// finds solutions and test cases the same way as the cptool command, and compiles, runs and tests solutions using the languages
+// defined in the configuration paths. The results are plain structs and the errors are typed, so they can be inspected using
+// errors.As. The cptool command is a client of this package.
+package cptool
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"time"
+
+	"github.com/jauhararifin/cptool/internal/core"
+	"github.com/jauhararifin/cptool/internal/executioner"
+	"github.com/jauhararifin/cptool/internal/logger"
+	"github.com/spf13/afero"
+)
+
+// CPTool compiles, runs and tests solutions. It is created using New.
+type CPTool struct {
+	core  *core.CPTool
+	clock func() time.Time
+	opts  []Option
+}
+
+type options struct {
+	core      []core.Option
+	clock     func() time.Time
+	logOutput io.Writer
+	verbose   bool
+}
+
+// Option configures a CPTool created by New.
+type Option func(opts *options)
+
+// WithFs sets the filesystem used to read and write every file. By default, the operating system's filesystem is used. The
+// compilers and solutions are run as processes, so they only see the operating system's filesystem.
+func WithFs(fs afero.Fs) Option {
+	return func(opts *options) {
+		opts.core = append(opts.core, core.WithFs(fs))
+	}
+}
+
+// WithWorkingDirectory sets the directory that solutions, test cases and problem.toml are searched from. By default, the process's
+// working directory is used.
+func WithWorkingDirectory(directory string) Option {
+	return func(opts *options) {
+		opts.core = append(opts.core, core.WithWorkingDirectory(directory))
+	}
+}
+
+// WithHomeDirectory sets the user's home directory that contains .cptool directory. By default, the current user's home directory
+// is used.
+func WithHomeDirectory(directory string) Option {
+	return func(opts *options) {
+		opts.core = append(opts.core, core.WithHomeDirectory(directory))
+	}
+}
+
+// WithCptoolHomeDirectory sets the cptool home directory. By default, $CPTOOL_HOME is used.
+func WithCptoolHomeDirectory(directory string) Option {
+	return func(opts *options) {
+		opts.core = append(opts.core, core.WithCptoolHomeDirectory(directory))
+	}
+}
+
+// WithEnviron sets the environment variables, in "key=value" form, that override the configuration. By default, the process's
+// environment variables are used.
+func WithEnviron(environ []string) Option {
+	return func(opts *options) {
+		opts.core = append(opts.core, core.WithEnviron(environ))
+	}
+}
+
+// WithClock sets the function that returns current time, it is used to measure durations. By default, time.Now is used.
+func WithClock(now func() time.Time) Option {
+	return func(opts *options) {
+		opts.clock = now
+		opts.core = append(opts.core, core.WithClock(now))
+	}
+}
+
+// WithLogOutput sets where the logs, like compilation errors, are written. Verbose logs are only written when verbose is true.
+// By default, nothing is logged.
+func WithLogOutput(output io.Writer, verbose bool) Option {
+	return func(opts *options) {
+		opts.logOutput = output
+		opts.verbose = verbose
+	}
+}
+
+// WithStderr sets where the stderr of solutions, checkers and interactors is written when testing. By default, the process's stderr
+// is used.
+func WithStderr(stderr io.Writer) Option {
+	return func(opts *options) {
+		opts.core = append(opts.core, core.WithStderr(stderr))
+	}
+}
+
+// withExec sets the executioner used to run compilers and solutions, it is used for testing.
+func withExec(exec executioner.Exec) Option {
+	return func(opts *options) {
+		opts.core = append(opts.core, core.WithExec(exec))
+	}
+}
+
+// New creates a CPTool, and loads the configuration files, languages and problem configuration. ConfigError returned when some
+// configuration file is malformed.
+func New(opts ...Option) (*CPTool, error) {
+	options := &options{clock: time.Now, logOutput: ioutil.Discard}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	level := logger.INFO
+	if options.verbose {
+		level = logger.VERBOSE
+	}
+	log := logger.New(options.logOutput, level)
+
+	cptool, err := core.New(append(options.core, core.WithLogger(log))...)
+	if err != nil {
+		return nil, err
+	}
+	if err := cptool.Bootstrap(); err != nil {
+		return nil, &ConfigError{Err: err}
+	}
+	log.Colored = cptool.GetConfig().Colors
+
+	return &CPTool{core: cptool, clock: options.clock, opts: opts}, nil
+}
+
+// Config contains the global configuration loaded from the configuration files. Timeout is the default timeout of running and
+// testing a solution, and Colors indicates whether the output should be colored.
+type Config struct {
+	DefaultLanguage string
+	Jobs            int
+	KeepOutputs     bool
+	Timeout         time.Duration
+	Colors          bool
+}
+
+// Config returns the global configuration.
+func (cptool *CPTool) Config() Config {
+	conf := cptool.core.GetConfig()
+	return Config{
+		DefaultLanguage: conf.DefaultLanguage,
+		Jobs:            conf.Jobs,
+		KeepOutputs:     conf.KeepOutputs,
+		Timeout:         conf.Timeout,
+		Colors:          conf.Colors,
+	}
+}
+
+// Problem contains the problem configuration loaded from problem.toml. Name is the problem's name, it is empty when not configured.
+// TimeLimit and MemoryLimit (in megabytes) are the limits before adjusted for the solution's language, zero means unlimited.
+type Problem struct {
+	Name            string
+	TimeLimit       time.Duration
+	MemoryLimit     int
+	Checker         string
+	Interactor      string
+	TestPattern     string
+	DefaultLanguage string
+}
+
+// Problem returns the problem configuration.
+func (cptool *CPTool) Problem() Problem {
+	return newProblem(cptool.core.GetProblemConfig())
+}
+
+// SolutionProblem returns the configuration of the problem that a solution belongs to. It is loaded from problem.toml in the
+// solution's directory when the solution is outside the working directory, otherwise it is the same as Problem. ConfigError
+// returned when the solution's problem.toml is malformed.
+func (cptool *CPTool) SolutionProblem(solution Solution) (Problem, error) {
+	source, err := cptool.findSolution(cptool.core, solution.Path, solution.Language.Name)
+	if err != nil {
+		return Problem{}, err
+	}
+	problem, err := cptool.core.GetSolutionProblemConfig(source)
+	if err != nil {
+		return Problem{}, &ConfigError{Err: err}
+	}
+	return newProblem(problem), nil
+}
+
+func newProblem(problem core.ProblemConfig) Problem {
+	return Problem{
+		Name:            problem.Name,
+		TimeLimit:       problem.TimeLimit,
+		MemoryLimit:     problem.MemoryLimit,
+		Checker:         problem.Checker,
+		Interactor:      problem.Interactor,
+		TestPattern:     problem.TestPattern,
+		DefaultLanguage: problem.DefaultLanguage,
+	}
+}
+
+// Language describes a language that solutions can be written in. TimeFactor, TimeBonus and MemoryBonus (in megabytes) adjust the
+// problem's limits for solutions in the language, see Language.Limits.
+type Language struct {
+	Name        string
+	VerboseName string
+	Extension   string
+	Interpreted bool
+	Debuggable  bool
+	TimeFactor  float64
+	TimeBonus   time.Duration
+	MemoryBonus int
+}
+
+func newLanguage(language core.Language) Language {
+	return Language{
+		Name:        language.Name,
+		VerboseName: language.VerboseName,
+		Extension:   language.Extension,
+		Interpreted: language.Interpreted,
+		Debuggable:  language.Debuggable,
+		TimeFactor:  language.TimeFactor,
+		TimeBonus:   language.TimeBonus,
+		MemoryBonus: language.MemoryBonus,
+	}
+}
+
+// Limits returns the time limit and memory limit (in megabytes) of solutions in the language. Zero limit means unlimited.
+func (language Language) Limits(timeLimit time.Duration, memoryLimit int) (time.Duration, int) {
+	return core.Language{
+		TimeFactor:  language.TimeFactor,
+		TimeBonus:   language.TimeBonus,
+		MemoryBonus: language.MemoryBonus,
+	}.GetLimits(timeLimit, memoryLimit)
+}
+
+// Languages returns every loaded language sorted by the name.
+func (cptool *CPTool) Languages() []Language {
+	languages, _ := cptool.core.GetAllLanguages()
+	result := make([]Language, 0, len(languages))
+	for _, language := range languages {
+		result = append(result, newLanguage(language))
+	}
+	return result
+}
+
+// Language returns the language with such name. LanguageNotFoundError returned when no such language is loaded.
+func (cptool *CPTool) Language(name string) (Language, error) {
+	language, err := cptool.core.GetLanguageByName(name)
+	if err != nil {
+		return Language{}, convertError(err, name)
+	}
+	return newLanguage(language), nil
+}
+
+// DefaultLanguage returns the default language of the problem or configuration, or the first language when no default language
+// is configured. LanguageNotFoundError returned when no language is loaded.
+func (cptool *CPTool) DefaultLanguage() (Language, error) {
+	language, err := cptool.core.GetDefaultLanguage()
+	if err != nil {
+		return Language{}, convertError(err, "")
+	}
+	return newLanguage(language), nil
+}
+
+// Solution is a source code file of a solution. Name is the path relative to the working directory without the extension, or only
+// the file name without the extension when the solution is outside the working directory.
+type Solution struct {
+	Name        string
+	Path        string
+	Language    Language
+	LastUpdated time.Time
+}
+
+// Solution finds a solution. The name can be a path relative to the working directory or an absolute path, with or without the
+// extension. The language is detected from the file's extension when language is empty. LanguageNotFoundError,
+// SolutionNotFoundError or AmbiguousSolutionError returned when the solution cannot be found.
+func (cptool *CPTool) Solution(name string, language string) (Solution, error) {
+	solution, err := cptool.findSolution(cptool.core, name, language)
+	if err != nil {
+		return Solution{}, err
+	}
+	return newSolution(solution), nil
+}
+
+func (cptool *CPTool) findSolution(base *core.CPTool, name string, languageName string) (core.Solution, error) {
+	if len(languageName) == 0 {
+		solution, err := base.FindSolution(name)
+		return solution, convertError(err, name)
+	}
+	language, err := base.GetLanguageByName(languageName)
+	if err != nil {
+		return core.Solution{}, convertError(err, languageName)
+	}
+	solution, err := base.GetSolution(name, language)
+	return solution, convertError(err, name)
+}
+
+func newSolution(solution core.Solution) Solution {
+	return Solution{
+		Name:        solution.Name,
+		Path:        solution.Path,
+		Language:    newLanguage(solution.Language),
+		LastUpdated: solution.LastUpdated,
+	}
+}
+
+// BundleOptions configures bundling a solution. StripLocal removes "#ifdef LOCAL" blocks, and LibraryPaths are searched for local
+// includes before the library paths in the configuration.
+type BundleOptions struct {
+	StripLocal   bool
+	LibraryPaths []string
+}
+
+// Bundle inlines the local includes of a solution into a single file, and returns the bundled solution.
+func (cptool *CPTool) Bundle(solution Solution, opts BundleOptions) (Solution, error) {
+	source, err := cptool.findSolution(cptool.core, solution.Path, solution.Language.Name)
+	if err != nil {
+		return Solution{}, err
+	}
+	bundle, err := cptool.core.Bundle(source, opts.StripLocal, opts.LibraryPaths)
+	if err != nil {
+		return Solution{}, err
+	}
+	return newSolution(bundle), nil
+}
+
+// CompileResult is the result of compiling a solution. Skipped indicates the solution was already compiled and is not changed since.
+// TargetPath is the compiled program, it is a directory for languages like java, and the source code for interpreted languages.
+// Output contains the compiler's messages, like warnings, and Diagnostics contains the warnings and notes parsed from it. Both are
+// empty when the compilation is skipped.
+type CompileResult struct {
+	Skipped     bool
+	TargetPath  string
+	Duration    time.Duration
+	Output      string
+	Diagnostics []Diagnostic
+}
+
+// Compile compiles a solution when it is not compiled yet. Debug compiles using the language's debug command.
+// LanguageNotDebuggableError returned when the language has no debug command, and CompilationError returned when the compiler
+// rejects the solution. Other errors, like a missing compiler or the context being done, are returned as is.
+func (cptool *CPTool) Compile(ctx context.Context, solution Solution, debug bool) (CompileResult, error) {
+	source, err := cptool.findSolution(cptool.core, solution.Path, solution.Language.Name)
+	if err != nil {
+		return CompileResult{}, err
+	}
+	result, err := cptool.compile(ctx, source, debug)
+	if err != nil {
+		return CompileResult{}, err
+	}
+	return newCompileResult(result), nil
+}
+
+func (cptool *CPTool) compile(ctx context.Context, source core.Solution, debug bool) (core.CompilationResult, error) {
+	start := cptool.clock()
+	result, err := cptool.core.Compile(ctx, source, debug)
+	if err == core.ErrLanguageNotDebuggable {
+		return core.CompilationResult{}, &LanguageNotDebuggableError{Name: source.Language.Name}
+	} else if err != nil {
+		return core.CompilationResult{}, newCompilationError(source, result, err)
+	}
+	result.Duration = cptool.clock().Sub(start)
+	return result, nil
+}
+
+func newCompileResult(result core.CompilationResult) CompileResult {
+	return CompileResult{
+		Skipped:     result.Skipped,
+		TargetPath:  result.TargetPath,
+		Duration:    result.Duration,
+		Output:      result.Output,
+		Diagnostics: newDiagnostics(result.Diagnostics),
+	}
+}
+
+// RunResult is the result of running a solution. Memory is the maximum memory usage in bytes, it is zero when the memory usage
+// cannot be measured. TimeLimit and MemoryLimit (in megabytes) are the problem's limits adjusted for the solution's language,
+// TimeLimitExceeded and MemoryLimitExceeded indicate whether the execution exceeded them.
+type RunResult struct {
+	Compilation         CompileResult
+	Duration            time.Duration
+	Memory              uint64
+	TimeLimit           time.Duration
+	MemoryLimit         int
+	TimeLimitExceeded   bool
+	MemoryLimitExceeded bool
+}
+
+// Run compiles and runs a solution using stdin, stdout and stderr. The solution is killed when ctx is done. CompilationError
+// returned when the compiler rejects the solution, and RuntimeError returned when the solution exits with error or is killed.
+func (cptool *CPTool) Run(ctx context.Context, solution Solution, stdin io.Reader, stdout io.Writer, stderr io.Writer) (RunResult, error) {
+	source, err := cptool.findSolution(cptool.core, solution.Path, solution.Language.Name)
+	if err != nil {
+		return RunResult{}, err
+	}
+	result, err := cptool.compile(ctx, source, false)
+	if err != nil {
+		return RunResult{}, err
+	}
+	// the compiled program is executed directly, so the solution is not compiled twice.
+	compilation := newCompileResult(result)
+	execution, err := cptool.core.Execute(ctx, source, result, stdin, stdout, stderr)
+	if err != nil {
+		return RunResult{Compilation: compilation}, &RuntimeError{Solution: source.Name, Err: err}
+	}
+
+	problem, err := cptool.core.GetSolutionProblemConfig(source)
+	if err != nil {
+		return RunResult{Compilation: compilation}, &ConfigError{Err: err}
+	}
+	timeLimit, memoryLimit := source.Language.GetLimits(problem.TimeLimit, problem.MemoryLimit)
+	return RunResult{
+		Compilation:         compilation,
+		Duration:            execution.Duration,
+		Memory:              execution.Memory,
+		TimeLimit:           timeLimit,
+		MemoryLimit:         memoryLimit,
+		TimeLimitExceeded:   timeLimit > 0 && execution.Duration > timeLimit,
+		MemoryLimitExceeded: memoryLimit > 0 && execution.Memory > uint64(memoryLimit)*1024*1024,
+	}, nil
+}
+
+// TestCase is a pair of input file and expected output file. A file named "example.in" and "example.out" is a test case named
+// "example".
+type TestCase struct {
+	Name       string
+	InputPath  string
+	OutputPath string
+}
+
+// TestCases returns the test cases whose name starts with prefix. The test cases are searched using the problem's test pattern
+// when it is configured, otherwise in the directory of the prefix.
+func (cptool *CPTool) TestCases(prefix string) []TestCase {
+	testCases := cptool.core.GetTestCases(prefix)
+	result := make([]TestCase, 0, len(testCases))
+	for _, testCase := range testCases {
+		result = append(result, TestCase(testCase))
+	}
+	return result
+}
+
+// Verdict is the result of testing a solution using a test case.
+type Verdict int
+
+const (
+	// Skipped indicates the test case cannot be tested, like when its files cannot be read. TestCaseResult.Err contains the reason.
+	Skipped Verdict = iota
+
+	// WrongAnswer indicates the solution's output is different from the expected output, or rejected by the checker.
+	WrongAnswer
+
+	// Accepted indicates the solution's output is accepted.
+	Accepted
+
+	// TimeLimitExceeded indicates the solution runs longer than the time limit.
+	TimeLimitExceeded
+
+	// MemoryLimitExceeded indicates the solution uses more memory than the memory limit.
+	MemoryLimitExceeded
+)
+
+var verdictNames = map[Verdict]string{
+	Skipped:             "skipped",
+	WrongAnswer:         "wrong answer",
+	Accepted:            "accepted",
+	TimeLimitExceeded:   "time limit exceeded",
+	MemoryLimitExceeded: "memory limit exceeded",
+}
+
+var verdicts = map[int]Verdict{
+	core.TestCaseSkipped:             Skipped,
+	core.TestCaseFailed:              WrongAnswer,
+	core.TestCaseSuccess:             Accepted,
+	core.TestCaseTimeLimitExceeded:   TimeLimitExceeded,
+	core.TestCaseMemoryLimitExceeded: MemoryLimitExceeded,
+}
+
+func (verdict Verdict) String() string {
+	if name, ok := verdictNames[verdict]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// TestCaseResult is the result of testing a solution using a test case. Memory is the maximum memory usage in bytes.
+// ActualOutputPath is the solution's saved output, the output is saved when the test case is not accepted or
+// TestOptions.KeepOutputs is set, otherwise it is empty.
+type TestCaseResult struct {
+	TestCase
+	Verdict          Verdict
+	Duration         time.Duration
+	Memory           uint64
+	Err              error
+	ActualOutputPath string
+}
+
+func newTestCaseResult(result core.TestCaseResult) TestCaseResult {
+	return TestCaseResult{
+		TestCase: TestCase(result.Testcase),
+		Verdict:  verdicts[result.Status],
+		Duration: result.Duration,
+		Memory:   result.Memory,
+		Err:      result.Err,
+
+		ActualOutputPath: result.ActualOutputPath,
+	}
+}
+
+// TestOptions configures testing a solution. Zero values mean the settings of the configuration and problem.toml are used. Prefix
+// selects the test cases whose name starts with it, and Names selects only the test cases with these names when it is not empty.
+// Recompile compiles the solution even when it is not changed since compiled. Checker and Interactor are paths to programs,
+// relative to the process's working directory. Observer receives the progress when it is not nil.
+type TestOptions struct {
+	Prefix      string
+	Names       []string
+	Recompile   bool
+	Observer    Observer
+	Jobs        int
+	KeepOutputs bool
+	TimeLimit   time.Duration
+	MemoryLimit int
+	Checker     string
+	Interactor  string
+	Pattern     string
+}
+
+// TestReport is the result of testing a solution. Failed is the number of test cases that are not accepted. Duration includes the
+// compilation. TimeLimit and MemoryLimit (in megabytes) are the limits of every test case adjusted for the solution's language.
+// Aborted indicates the context was done before every test case was tested.
+type TestReport struct {
+	Compilation CompileResult
+	Results     []TestCaseResult
+	Failed      int
+	Duration    time.Duration
+	TimeLimit   time.Duration
+	MemoryLimit int
+	Aborted     bool
+}
+
+// Test compiles a solution and tests it using the test cases selected by opts. CompilationError returned when the compiler rejects
+// the solution.
+func (cptool *CPTool) Test(ctx context.Context, solution Solution, opts TestOptions) (TestReport, error) {
+	base := cptool.core.Copy()
+	conf := base.GetConfig()
+	if opts.Jobs > 0 {
+		conf.Jobs = opts.Jobs
+	}
+	if opts.KeepOutputs {
+		conf.KeepOutputs = true
+	}
+	base.SetConfig(conf)
+
+	source, err := cptool.findSolution(base, solution.Path, solution.Language.Name)
+	if err != nil {
+		return TestReport{}, err
+	}
+	problem, err := base.GetSolutionProblemConfig(source)
+	if err != nil {
+		return TestReport{}, &ConfigError{Err: err}
+	}
+	if opts.TimeLimit > 0 {
+		problem.TimeLimit = opts.TimeLimit
+	}
+	if opts.MemoryLimit > 0 {
+		problem.MemoryLimit = opts.MemoryLimit
+	}
+	if len(opts.Checker) > 0 {
+		problem.Checker = opts.Checker
+	}
+	if len(opts.Interactor) > 0 {
+		problem.Interactor = opts.Interactor
+	}
+	if len(opts.Pattern) > 0 {
+		problem.TestPattern = opts.Pattern
+	}
+	base.SetProblemConfig(problem)
+
+	var observer core.TestObserver
+	if opts.Observer != nil {
+		observer = func(event core.TestEvent) {
+			opts.Observer(newEvent(source, event))
+		}
+	}
+	if opts.Recompile {
+		source.LastUpdated = cptool.clock()
+	}
+	testCases := base.GetTestCases(opts.Prefix)
+	if len(opts.Names) > 0 {
+		selected := make([]core.TestCase, 0, len(opts.Names))
+		for _, testCase := range testCases {
+			for _, name := range opts.Names {
+				if testCase.Name == name {
+					selected = append(selected, testCase)
+					break
+				}
+			}
+		}
+		testCases = selected
+	}
+	result, err := base.TestTestCases(ctx, source, testCases, observer)
+	if err != nil {
+		return TestReport{}, newCompilationError(source, result.Compilation, err)
+	}
+
+	report := TestReport{
+		Compilation: newCompileResult(result.Compilation),
+		Results:     make([]TestCaseResult, 0, len(result.TestCaseResults)),
+		Failed:      int(result.UnsuccessfullTestsCount),
+		Duration:    result.Duration,
+		TimeLimit:   result.TimeLimit,
+		MemoryLimit: result.MemoryLimit,
+		Aborted:     ctx.Err() != nil,
+	}
+	for _, testCase := range result.TestCaseResults {
+		report.Results = append(report.Results, newTestCaseResult(testCase))
+	}
+	return report, nil
+}
+
+// convertError converts the errors of core package into the errors of this package. The name is the name of the language or
+// solution that is searched.
+func convertError(err error, name string) error {
+	var ambiguous *core.AmbiguousSolutionError
+	switch {
+	case err == nil:
+		return nil
+	case err == core.ErrNoSuchLanguage:
+		return &LanguageNotFoundError{Name: name}
+	case err == core.ErrNoSuchSolution:
+		return &SolutionNotFoundError{Name: name}
+	case errors.As(err, &ambiguous):
+		return &AmbiguousSolutionError{Name: name, Paths: ambiguous.Paths}
+	}
+	return err
+}
@@ -0,0 +1,421 @@
+package cptool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jauhararifin/cptool/internal/executioner"
+	"github.com/spf13/afero"
+)
+
+const fakeLanguageConf = "verbose_name=\"Fake\"\nextension=\"fk\"\ncompile=[\"fakec\", \"{source}\", \"{target}\"]\n" +
+	"run=[\"{target}\"]\n"
+
+func newTest(t *testing.T, memexec *executioner.MemExec, files map[string]string) *CPTool {
+	return newTestWithFs(t, memexec, afero.NewMemMapFs(), files)
+}
+
+func newTestWithFs(t *testing.T, memexec *executioner.MemExec, fs afero.Fs, files map[string]string) *CPTool {
+	fs.MkdirAll("/home/test/problem", 0755)
+	for filePath, content := range files {
+		afero.WriteFile(fs, filePath, []byte(content), 0644)
+	}
+	cptool, err := New(
+		withExec(memexec),
+		WithFs(fs),
+		WithWorkingDirectory("/home/test/problem"),
+		WithHomeDirectory("/home/test"),
+		WithCptoolHomeDirectory(""),
+		WithEnviron([]string{}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cptool
+}
+
+func TestNewWithInvalidConfig(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	afero.WriteFile(fs, "/home/test/.cptool/config", []byte("jobs = \"many\"\n"), 0644)
+	_, err := New(WithFs(fs), WithWorkingDirectory("/home/test"), WithHomeDirectory("/home/test"), WithEnviron([]string{}))
+	var configErr *ConfigError
+	if !errors.As(err, &configErr) {
+		t.Error("New should return ConfigError when the configuration file is malformed, found:", err)
+	}
+}
+
+func TestLanguage(t *testing.T) {
+	cptool := newTest(t, executioner.NewMemExec(), map[string]string{
+		"/home/test/.cptool/langs/fake/lang.conf": fakeLanguageConf + "time_factor=2.0\n",
+	})
+
+	language, err := cptool.Language("fake")
+	if err != nil {
+		t.Error(err)
+	}
+	if language.VerboseName != "Fake" || language.Extension != "fk" || language.TimeFactor != 2 {
+		t.Error("Language should return the loaded language, found:", language)
+	}
+	if timeLimit, _ := language.Limits(time.Second, 0); timeLimit != 2*time.Second {
+		t.Error("Limits should adjust the time limit, found:", timeLimit)
+	}
+
+	_, err = cptool.Language("unknown")
+	var notFound *LanguageNotFoundError
+	if !errors.As(err, &notFound) || notFound.Name != "unknown" {
+		t.Error("Language should return LanguageNotFoundError, found:", err)
+	}
+}
+
+func TestSolution(t *testing.T) {
+	cptool := newTest(t, executioner.NewMemExec(), map[string]string{
+		"/home/test/.cptool/langs/fake/lang.conf":  fakeLanguageConf,
+		"/home/test/.cptool/langs/other/lang.conf": strings.Replace(fakeLanguageConf, "\"fk\"", "\"ot\"", 1),
+		"/home/test/problem/a.fk":                  "",
+		"/home/test/problem/b.fk":                  "",
+		"/home/test/problem/b.ot":                  "",
+	})
+
+	solution, err := cptool.Solution("a", "")
+	if err != nil {
+		t.Error(err)
+	}
+	if solution.Name != "a" || solution.Path != "/home/test/problem/a.fk" || solution.Language.Name != "fake" {
+		t.Error("Solution should detect the solution's language, found:", solution)
+	}
+	if solution, err := cptool.Solution("b", "other"); err != nil || solution.Path != "/home/test/problem/b.ot" {
+		t.Error("Solution should use the specified language, found:", solution, err)
+	}
+
+	_, err = cptool.Solution("c", "")
+	var notFound *SolutionNotFoundError
+	if !errors.As(err, &notFound) || notFound.Name != "c" {
+		t.Error("Solution should return SolutionNotFoundError, found:", err)
+	}
+	_, err = cptool.Solution("b", "")
+	var ambiguous *AmbiguousSolutionError
+	if !errors.As(err, &ambiguous) || len(ambiguous.Paths) != 2 {
+		t.Error("Solution should return AmbiguousSolutionError, found:", err)
+	}
+	_, err = cptool.Solution("a", "unknown")
+	var languageNotFound *LanguageNotFoundError
+	if !errors.As(err, &languageNotFound) {
+		t.Error("Solution should return LanguageNotFoundError, found:", err)
+	}
+}
+
+func TestTestCases(t *testing.T) {
+	cptool := newTest(t, executioner.NewMemExec(), map[string]string{
+		"/home/test/problem/sample1.in":  "",
+		"/home/test/problem/sample1.out": "",
+		"/home/test/problem/sample2.in":  "",
+		"/home/test/problem/other.in":    "",
+		"/home/test/problem/other.out":   "",
+	})
+
+	testCases := cptool.TestCases("sample")
+	if len(testCases) != 1 || testCases[0].Name != "sample1" || testCases[0].InputPath != "/home/test/problem/sample1.in" ||
+		testCases[0].OutputPath != "/home/test/problem/sample1.out" {
+		t.Error("TestCases should return the test cases with the prefix, found:", testCases)
+	}
+}
+
+func TestCompileWithCompilationError(t *testing.T) {
+	memexec := executioner.NewMemExec()
+	memexec.StderrPipeCallback = func(m *executioner.MemCmd) (io.ReadCloser, error) {
+		return ioutil.NopCloser(strings.NewReader("a.fk:1:2: error: syntax error")), nil
+	}
+	memexec.WaitCallback = func(m *executioner.MemCmd) error {
+		return &exec.ExitError{}
+	}
+	cptool := newTest(t, memexec, map[string]string{
+		"/home/test/.cptool/langs/fake/lang.conf": fakeLanguageConf,
+		"/home/test/problem/a.fk":                 "",
+	})
+	solution, _ := cptool.Solution("a", "")
+
+	_, err := cptool.Compile(context.Background(), solution, false)
+	var compilationErr *CompilationError
+	if !errors.As(err, &compilationErr) || compilationErr.Output != "a.fk:1:2: error: syntax error" || compilationErr.Solution != "a" {
+		t.Error("Compile should return CompilationError with the compiler's message, found:", err)
+	}
+	if len(compilationErr.Diagnostics) != 1 || compilationErr.Diagnostics[0].String() != "a.fk:1:2: error: syntax error" {
+		t.Error("CompilationError should contain the parsed diagnostics, found:", compilationErr.Diagnostics)
+	}
+	_, err = cptool.Compile(context.Background(), solution, true)
+	var notDebuggable *LanguageNotDebuggableError
+	if !errors.As(err, &notDebuggable) || notDebuggable.Name != "fake" {
+		t.Error("Compile should return LanguageNotDebuggableError, found:", err)
+	}
+}
+
+func TestCompileWithMissingCompiler(t *testing.T) {
+	memexec := executioner.NewMemExec()
+	memexec.StartCallback = func(m *executioner.MemCmd) error {
+		return &exec.Error{Name: "fakec", Err: exec.ErrNotFound}
+	}
+	cptool := newTest(t, memexec, map[string]string{
+		"/home/test/.cptool/langs/fake/lang.conf": fakeLanguageConf,
+		"/home/test/problem/a.fk":                 "",
+		"/home/test/problem/tc1.in":               "1",
+		"/home/test/problem/tc1.out":              "1",
+	})
+	solution, _ := cptool.Solution("a", "")
+
+	_, err := cptool.Compile(context.Background(), solution, false)
+	var compilationErr *CompilationError
+	if errors.As(err, &compilationErr) || !errors.Is(err, exec.ErrNotFound) {
+		t.Error("Compile should return the error of missing compiler as is, found:", err)
+	}
+	_, err = cptool.Test(context.Background(), solution, TestOptions{})
+	if errors.As(err, &compilationErr) || !errors.Is(err, exec.ErrNotFound) {
+		t.Error("Test should return the error of missing compiler as is, found:", err)
+	}
+
+	memexec.StartCallback = nil
+	memexec.WaitCallback = func(m *executioner.MemCmd) error {
+		return &exec.ExitError{}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = cptool.Compile(ctx, solution, false)
+	if errors.As(err, &compilationErr) || err != context.Canceled {
+		t.Error("Compile should return the context's error when the compiler is killed, found:", err)
+	}
+}
+
+func TestCompileWithWarnings(t *testing.T) {
+	memexec := executioner.NewMemExec()
+	memexec.StderrPipeCallback = func(m *executioner.MemCmd) (io.ReadCloser, error) {
+		return ioutil.NopCloser(strings.NewReader("a.fk:3: warning: unused variable\n")), nil
+	}
+	cptool := newTest(t, memexec, map[string]string{
+		"/home/test/.cptool/langs/fake/lang.conf": fakeLanguageConf,
+		"/home/test/problem/a.fk":                 "",
+	})
+	solution, _ := cptool.Solution("a", "")
+
+	result, err := cptool.Compile(context.Background(), solution, false)
+	if err != nil {
+		t.Error(err)
+	}
+	if result.Output != "a.fk:3: warning: unused variable\n" || len(result.Diagnostics) != 1 {
+		t.Fatal("Compile should return the compiler's warnings, found:", result)
+	}
+	encoded, _ := json.Marshal(result.Diagnostics[0])
+	if string(encoded) != `{"file":"a.fk","line":3,"column":0,"severity":"warning","message":"unused variable"}` {
+		t.Error("diagnostic should be encoded as JSON with readable severity, found:", string(encoded))
+	}
+}
+
+func TestRun(t *testing.T) {
+	memexec := executioner.NewMemExec()
+	memexec.RunCallback = func(m *executioner.MemCmd) error {
+		_, err := m.Stdout.Write([]byte("output"))
+		return err
+	}
+	compilations := 0
+	memexec.StartCallback = func(m *executioner.MemCmd) error {
+		compilations++
+		return nil
+	}
+	cptool := newTest(t, memexec, map[string]string{
+		"/home/test/.cptool/langs/fake/lang.conf": fakeLanguageConf + "time_bonus=\"1s\"\n",
+		"/home/test/problem/a.fk":                 "",
+		"/home/test/problem/problem.toml":         "time_limit = \"1s\"\n",
+	})
+	solution, _ := cptool.Solution("a", "")
+
+	output := new(strings.Builder)
+	result, err := cptool.Run(context.Background(), solution, strings.NewReader(""), output, ioutil.Discard)
+	if err != nil {
+		t.Error(err)
+	}
+	if output.String() != "output" {
+		t.Error("Run should write the solution's output, found:", output.String())
+	}
+	if result.TimeLimit != 2*time.Second || result.TimeLimitExceeded {
+		t.Error("Run should return the adjusted time limit, found:", result)
+	}
+	if compilations != 1 {
+		t.Error("Run should compile the solution once, found:", compilations)
+	}
+
+	memexec.RunCallback = func(m *executioner.MemCmd) error {
+		return &exec.ExitError{}
+	}
+	_, err = cptool.Run(context.Background(), solution, strings.NewReader(""), ioutil.Discard, ioutil.Discard)
+	var runtimeErr *RuntimeError
+	if !errors.As(err, &runtimeErr) {
+		t.Error("Run should return RuntimeError when the solution exits with error, found:", err)
+	}
+}
+
+func TestTest(t *testing.T) {
+	memexec := executioner.NewMemExec()
+	memexec.RunCallback = func(m *executioner.MemCmd) error {
+		input, _ := ioutil.ReadAll(m.Stdin)
+		_, err := m.Stdout.Write(input)
+		return err
+	}
+	cptool := newTest(t, memexec, map[string]string{
+		"/home/test/.cptool/langs/fake/lang.conf": fakeLanguageConf,
+		"/home/test/problem/a.fk":                 "",
+		"/home/test/problem/tc1.in":               "1",
+		"/home/test/problem/tc1.out":              "1",
+		"/home/test/problem/tc2.in":               "2",
+		"/home/test/problem/tc2.out":              "3",
+		"/home/test/problem/sample.in":            "1",
+		"/home/test/problem/sample.out":           "1",
+	})
+	solution, _ := cptool.Solution("a", "")
+
+	report, err := cptool.Test(context.Background(), solution, TestOptions{Prefix: "tc", MemoryLimit: 64})
+	if err != nil {
+		t.Error(err)
+	}
+	if len(report.Results) != 2 || report.Failed != 1 || report.MemoryLimit != 64 || report.Aborted {
+		t.Fatal("Test should test the test cases with the prefix, found:", report)
+	}
+	if report.Results[0].Name != "tc1" || report.Results[0].Verdict != Accepted {
+		t.Error("tc1 should be accepted, found:", report.Results[0])
+	}
+	if report.Results[1].Name != "tc2" || report.Results[1].Verdict != WrongAnswer {
+		t.Error("tc2 should get wrong answer, found:", report.Results[1])
+	}
+	if cptool.Problem().MemoryLimit != 0 {
+		t.Error("Test options should not change the problem configuration")
+	}
+	if WrongAnswer.String() != "wrong answer" {
+		t.Error("verdict should have readable name, found:", WrongAnswer.String())
+	}
+}
+
+func TestTestWithSolutionProblem(t *testing.T) {
+	memexec := executioner.NewMemExec()
+	memexec.RunCallback = func(m *executioner.MemCmd) error {
+		input, _ := ioutil.ReadAll(m.Stdin)
+		_, err := m.Stdout.Write(input)
+		return err
+	}
+	cptool := newTest(t, memexec, map[string]string{
+		"/home/test/.cptool/langs/fake/lang.conf": fakeLanguageConf,
+		"/home/test/problem/problem.toml":         "time_limit = \"1s\"\n",
+		"/home/test/problem/a/problem.toml":       "time_limit = \"3s\"\ntest_pattern = \"tests/*.in\"\n",
+		"/home/test/problem/a/sol.fk":             "",
+		"/home/test/problem/a/tests/sample.in":    "1",
+		"/home/test/problem/a/tests/sample.out":   "1",
+		"/home/test/problem/a/ignored.in":         "1",
+		"/home/test/problem/a/ignored.out":        "1",
+	})
+	solution, err := cptool.Solution("a/sol.fk", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if problem, err := cptool.SolutionProblem(solution); err != nil || problem.TimeLimit != 3*time.Second {
+		t.Error("SolutionProblem should load problem.toml in the solution's directory, found:", problem, err)
+	}
+	report, err := cptool.Test(context.Background(), solution, TestOptions{Prefix: "a/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.TimeLimit != 3*time.Second || len(report.Results) != 1 || report.Results[0].Name != "a/tests/sample" {
+		t.Error("Test should use problem.toml in the solution's directory, found:", report)
+	}
+	if cptool.Problem().TimeLimit != time.Second {
+		t.Error("Problem should be loaded from the working directory, found:", cptool.Problem())
+	}
+}
+
+func TestTestWithObserver(t *testing.T) {
+	memexec := executioner.NewMemExec()
+	memexec.StderrPipeCallback = func(m *executioner.MemCmd) (io.ReadCloser, error) {
+		return ioutil.NopCloser(strings.NewReader("syntax error")), nil
+	}
+	memexec.WaitCallback = func(m *executioner.MemCmd) error {
+		return &exec.ExitError{}
+	}
+	cptool := newTest(t, memexec, map[string]string{
+		"/home/test/.cptool/langs/fake/lang.conf": fakeLanguageConf,
+		"/home/test/problem/a.fk":                 "",
+		"/home/test/problem/tc1.in":               "1",
+		"/home/test/problem/tc1.out":              "1",
+	})
+	solution, _ := cptool.Solution("a", "")
+
+	events := make([]Event, 0)
+	_, err := cptool.Test(context.Background(), solution, TestOptions{Observer: func(event Event) {
+		events = append(events, event)
+	}})
+	var compilationErr *CompilationError
+	if !errors.As(err, &compilationErr) || compilationErr.Output != "syntax error" {
+		t.Error("Test should return CompilationError, found:", err)
+	}
+	if len(events) != 2 || events[0].Type != CompileStarted || events[1].Type != CompileFinished {
+		t.Fatal("observer should receive compile events, found:", events)
+	}
+	if !errors.As(events[1].Err, &compilationErr) {
+		t.Error("compile finished event should contain CompilationError, found:", events[1].Err)
+	}
+
+	memexec.WaitCallback = nil
+	events = events[:0]
+	if _, err := cptool.Test(context.Background(), solution, TestOptions{Observer: func(event Event) {
+		events = append(events, event)
+	}}); err != nil {
+		t.Error(err)
+	}
+	if len(events) != 4 || events[2].Type != TestStarted || events[3].Type != TestFinished ||
+		events[3].Result.Name != "tc1" || events[3].Result.Verdict != WrongAnswer {
+		t.Error("observer should receive test events, found:", events)
+	}
+	if TestFinished.String() != "test_finished" {
+		t.Error("event type should have readable name, found:", TestFinished.String())
+	}
+}
+
+func TestTestWithNames(t *testing.T) {
+	memexec := executioner.NewMemExec()
+	fs := afero.NewMemMapFs()
+	compilations := 0
+	memexec.StartCallback = func(m *executioner.MemCmd) error {
+		compilations++
+		return afero.WriteFile(fs, m.GetArgs()[2], []byte{}, 0755)
+	}
+	memexec.RunCallback = func(m *executioner.MemCmd) error {
+		_, err := m.Stdout.Write([]byte("wrong"))
+		return err
+	}
+	cptool := newTestWithFs(t, memexec, fs, map[string]string{
+		"/home/test/.cptool/langs/fake/lang.conf": fakeLanguageConf,
+		"/home/test/problem/a.fk":                 "",
+		"/home/test/problem/tc1.in":               "1",
+		"/home/test/problem/tc1.out":              "1",
+		"/home/test/problem/tc10.in":              "10",
+		"/home/test/problem/tc10.out":             "10",
+	})
+	solution, _ := cptool.Solution("a", "")
+	cptool.Compile(context.Background(), solution, false)
+
+	report, err := cptool.Test(context.Background(), solution, TestOptions{Names: []string{"tc1"}, Recompile: true})
+	if err != nil {
+		t.Error(err)
+	}
+	if len(report.Results) != 1 || report.Results[0].Name != "tc1" {
+		t.Fatal("Test should only test the test cases with the names, found:", report.Results)
+	}
+	if report.Results[0].ActualOutputPath == "" {
+		t.Error("the output of wrong answer should be saved")
+	}
+	if compilations != 2 {
+		t.Error("Test should recompile the solution, found compilations:", compilations)
+	}
+}
@@ -0,0 +1,109 @@
+package cptool
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+
+	"github.com/jauhararifin/cptool/internal/core"
+)
+
+// Severity is the severity of a Diagnostic.
+type Severity int
+
+const (
+	// SeverityError indicates the compiler rejected the source code.
+	SeverityError Severity = iota
+
+	// SeverityWarning indicates the source code is accepted but may be wrong, like the warnings enabled by -Wall.
+	SeverityWarning
+
+	// SeverityNote indicates additional information, usually about the preceding error or warning.
+	SeverityNote
+)
+
+var severityNames = map[Severity]string{
+	SeverityError:   "error",
+	SeverityWarning: "warning",
+	SeverityNote:    "note",
+}
+
+var severities = map[int]Severity{
+	core.DiagnosticError:   SeverityError,
+	core.DiagnosticWarning: SeverityWarning,
+	core.DiagnosticNote:    SeverityNote,
+}
+
+func (severity Severity) String() string {
+	if name, ok := severityNames[severity]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// MarshalText encodes the severity as its name, so it is encoded in JSON as "error", "warning" or "note".
+func (severity Severity) MarshalText() ([]byte, error) {
+	return []byte(severity.String()), nil
+}
+
+// UnmarshalText decodes the severity from its name.
+func (severity *Severity) UnmarshalText(text []byte) error {
+	for value, name := range severityNames {
+		if name == string(text) {
+			*severity = value
+			return nil
+		}
+	}
+	return fmt.Errorf("Unknown severity: %s", text)
+}
+
+// Diagnostic is an error, warning or note parsed from the compiler's messages, GCC, Clang and Free Pascal formats are recognized.
+// File is the path as printed by the compiler. Line and Column start from 1, and are zero when the compiler doesn't report them.
+type Diagnostic struct {
+	File     string   `json:"file"`
+	Line     int      `json:"line"`
+	Column   int      `json:"column"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+}
+
+// String formats the diagnostic like GCC does, "FILE:LINE:COLUMN: SEVERITY: MESSAGE".
+func (diagnostic Diagnostic) String() string {
+	position := diagnostic.File
+	if diagnostic.Line > 0 {
+		position += fmt.Sprint(":", diagnostic.Line)
+	}
+	if diagnostic.Column > 0 {
+		position += fmt.Sprint(":", diagnostic.Column)
+	}
+	return fmt.Sprint(position, ": ", diagnostic.Severity, ": ", diagnostic.Message)
+}
+
+func newDiagnostics(diagnostics []core.Diagnostic) []Diagnostic {
+	result := make([]Diagnostic, 0, len(diagnostics))
+	for _, diagnostic := range diagnostics {
+		result = append(result, Diagnostic{
+			File:     diagnostic.File,
+			Line:     diagnostic.Line,
+			Column:   diagnostic.Column,
+			Severity: severities[diagnostic.Severity],
+			Message:  diagnostic.Message,
+		})
+	}
+	return result
+}
+
+// newCompilationError creates CompilationError from the result of the failed compilation. Only the compiler exiting with error
+// is a compilation error, other errors like a missing compiler or the context being done are returned unchanged.
+func newCompilationError(solution core.Solution, result core.CompilationResult, err error) error {
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return err
+	}
+	return &CompilationError{
+		Solution:    solution.Name,
+		Output:      result.ErrorMessage,
+		Diagnostics: newDiagnostics(result.Diagnostics),
+		Err:         err,
+	}
+}
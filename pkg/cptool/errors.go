@@ -0,0 +1,92 @@
+package cptool
+
+import (
+	"strings"
+)
+
+// LanguageNotFoundError indicates that no language with such name is defined.
+type LanguageNotFoundError struct {
+	Name string
+}
+
+func (e *LanguageNotFoundError) Error() string {
+	if len(e.Name) == 0 {
+		return "No language defined"
+	}
+	return "No such language: " + e.Name
+}
+
+// LanguageNotDebuggableError indicates that the language has no debug compile command, so solutions in the language cannot be
+// compiled in debug mode.
+type LanguageNotDebuggableError struct {
+	Name string
+}
+
+func (e *LanguageNotDebuggableError) Error() string {
+	return "Language is not debuggable: " + e.Name
+}
+
+// SolutionNotFoundError indicates that no solution file with such name exists.
+type SolutionNotFoundError struct {
+	Name string
+}
+
+func (e *SolutionNotFoundError) Error() string {
+	return "No such solution exists: " + e.Name
+}
+
+// AmbiguousSolutionError indicates that several solution files with the same name exist in different languages, so the solution's
+// language cannot be detected. Paths contains the path of every solution file.
+type AmbiguousSolutionError struct {
+	Name  string
+	Paths []string
+}
+
+func (e *AmbiguousSolutionError) Error() string {
+	return "Several solution files found: " + strings.Join(e.Paths, ", ")
+}
+
+// CompilationError indicates that the compiler rejected the solution. Output contains the compiler's error message, Diagnostics
+// contains the errors and warnings parsed from it, and Err contains the error returned by the compiler's execution. Diagnostics is
+// empty when the compiler's format is not recognized.
+type CompilationError struct {
+	Solution    string
+	Output      string
+	Diagnostics []Diagnostic
+	Err         error
+}
+
+func (e *CompilationError) Error() string {
+	return "Compilation failed: " + e.Solution
+}
+
+func (e *CompilationError) Unwrap() error {
+	return e.Err
+}
+
+// RuntimeError indicates that the solution exited with error, or was killed because the context is done.
+type RuntimeError struct {
+	Solution string
+	Err      error
+}
+
+func (e *RuntimeError) Error() string {
+	return "Program execution error: " + e.Err.Error()
+}
+
+func (e *RuntimeError) Unwrap() error {
+	return e.Err
+}
+
+// ConfigError indicates that a configuration file, problem configuration file or language definition is malformed.
+type ConfigError struct {
+	Err error
+}
+
+func (e *ConfigError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ConfigError) Unwrap() error {
+	return e.Err
+}
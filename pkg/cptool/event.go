@@ -0,0 +1,80 @@
+package cptool
+
+import (
+	"github.com/jauhararifin/cptool/internal/core"
+)
+
+// EventType is the type of an Event.
+type EventType int
+
+const (
+	// CompileStarted indicates the solution is being compiled.
+	CompileStarted EventType = iota
+
+	// CompileFinished indicates the compilation is finished. Event.Compilation contains its result, and Event.Err contains
+	// CompilationError when the compiler rejected the solution.
+	CompileFinished
+
+	// TestStarted indicates a test case is being tested.
+	TestStarted
+
+	// TestFinished indicates a test case is tested, Event.Result contains its result.
+	TestFinished
+
+	// Aborted indicates the context is done before every test case is tested, Event.Err contains the context's error.
+	Aborted
+)
+
+var eventTypeNames = map[EventType]string{
+	CompileStarted:  "compile_started",
+	CompileFinished: "compile_finished",
+	TestStarted:     "test_started",
+	TestFinished:    "test_finished",
+	Aborted:         "aborted",
+}
+
+func (eventType EventType) String() string {
+	if name, ok := eventTypeNames[eventType]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// Event describes the progress of testing a solution, only the fields that are relevant to its type are set.
+type Event struct {
+	Type        EventType
+	Compilation CompileResult
+	TestCase    TestCase
+	Result      TestCaseResult
+	Err         error
+}
+
+// Observer receives the events of testing a solution as they happen. The events are delivered one at a time, even when the test
+// cases are tested in parallel.
+type Observer func(event Event)
+
+var eventTypes = map[int]EventType{
+	core.TestEventCompileStarted:  CompileStarted,
+	core.TestEventCompileFinished: CompileFinished,
+	core.TestEventTestStarted:     TestStarted,
+	core.TestEventTestFinished:    TestFinished,
+	core.TestEventAborted:         Aborted,
+}
+
+func newEvent(solution core.Solution, event core.TestEvent) Event {
+	result := Event{
+		Type:     eventTypes[event.Type],
+		TestCase: TestCase(event.TestCase),
+		Err:      event.Err,
+	}
+	switch event.Type {
+	case core.TestEventCompileFinished:
+		result.Compilation = newCompileResult(event.Compilation)
+		if event.Err != nil {
+			result.Err = newCompilationError(solution, event.Compilation, event.Err)
+		}
+	case core.TestEventTestFinished:
+		result.Result = newTestCaseResult(event.Result)
+	}
+	return result
+}
@@ -0,0 +1,620 @@
+package cptool
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"mime"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxRunOutputSize limits how much of the solution's stdout and stderr is returned by the run endpoint.
+const maxRunOutputSize = 16 * 1024 * 1024
+
+// NewHandler creates http handler that serves cptool as a local JSON API, so editor plugins can compile, run and test solutions
+// without starting cptool for every request. The endpoints are:
+//
+//	GET  /languages            every loaded language
+//	GET  /tests?prefix=PREFIX  the test cases whose name starts with PREFIX
+//	POST /compile              compiles a solution
+//	POST /run                  compiles and runs a solution using the supplied stdin
+//	POST /test                 tests a solution, the progress is streamed as newline delimited JSON events
+//	POST /reload               reloads the configuration files, languages and problem configurations
+//
+// Every request may set "directory" (a query parameter for GET requests) to an absolute path of a problem directory, cptool is
+// loaded once for every directory and reused by later requests. Requests are executed in their own context, so closing the
+// connection cancels the compilation or execution. Only requests to a loopback host are accepted, and POST requests must have
+// "application/json" content type, to prevent web pages from sending requests to the handler.
+func NewHandler(cptool *CPTool) http.Handler {
+	server := &server{base: cptool, clients: make(map[string]*CPTool), running: make(map[string]chan struct{})}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/languages", server.handle(http.MethodGet, server.languages))
+	mux.HandleFunc("/tests", server.handle(http.MethodGet, server.tests))
+	mux.HandleFunc("/compile", server.handle(http.MethodPost, server.compile))
+	mux.HandleFunc("/run", server.handle(http.MethodPost, server.run))
+	mux.HandleFunc("/test", server.handle(http.MethodPost, server.test))
+	mux.HandleFunc("/reload", server.handle(http.MethodPost, server.reload))
+	return mux
+}
+
+type server struct {
+	// mutex guards base, clients, the cptool loaded for every directory, and running.
+	mutex   sync.Mutex
+	base    *CPTool
+	clients map[string]*CPTool
+
+	// running contains a lock for every solution path, it is held while the solution is compiled, run or tested. Compiling the
+	// same solution concurrently would overwrite the compiled program, so the requests of a solution are executed one at a time.
+	running map[string]chan struct{}
+}
+
+type handlerFunc func(w http.ResponseWriter, r *http.Request) error
+
+// requestError indicates that the request is malformed.
+type requestError struct {
+	message string
+}
+
+func (e *requestError) Error() string {
+	return e.message
+}
+
+func (server *server) handle(method string, handler handlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !isLoopbackHost(r.Host) {
+			writeError(w, &requestError{message: "Host is not allowed: " + r.Host})
+			return
+		}
+		if r.Method != method {
+			w.Header().Set("Allow", method)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if method == http.MethodPost {
+			if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType != "application/json" {
+				writeError(w, &requestError{message: "Content type should be application/json"})
+				return
+			}
+		}
+		if err := handler(w, r); err != nil {
+			writeError(w, err)
+		}
+	}
+}
+
+func isLoopbackHost(host string) bool {
+	if hostname, _, err := net.SplitHostPort(host); err == nil {
+		host = hostname
+	}
+	host = strings.Trim(host, "[]")
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// client returns the cptool loaded for the directory, or the base cptool when the directory is empty.
+func (server *server) client(directory string) (*CPTool, error) {
+	if len(directory) > 0 && !filepath.IsAbs(directory) {
+		return nil, &requestError{message: "Directory should be an absolute path: " + directory}
+	}
+
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	if len(directory) == 0 {
+		return server.base, nil
+	}
+	directory = filepath.Clean(directory)
+	if client, ok := server.clients[directory]; ok {
+		return client, nil
+	}
+	opts := make([]Option, 0, len(server.base.opts)+1)
+	opts = append(opts, server.base.opts...)
+	client, err := New(append(opts, WithWorkingDirectory(directory))...)
+	if err != nil {
+		return nil, err
+	}
+	server.clients[directory] = client
+	return client, nil
+}
+
+// lock waits until no other request compiles, runs or tests the solution, and returns the function that unlocks it. The context's
+// error is returned when the context is done first, like when the client has disconnected.
+func (server *server) lock(ctx context.Context, solutionPath string) (func(), error) {
+	server.mutex.Lock()
+	running, ok := server.running[solutionPath]
+	if !ok {
+		running = make(chan struct{}, 1)
+		server.running[solutionPath] = running
+	}
+	server.mutex.Unlock()
+
+	select {
+	case running <- struct{}{}:
+		return func() { <-running }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+type languageJSON struct {
+	Name        string  `json:"name"`
+	VerboseName string  `json:"verbose_name"`
+	Extension   string  `json:"extension"`
+	Interpreted bool    `json:"interpreted"`
+	Debuggable  bool    `json:"debuggable"`
+	TimeFactor  float64 `json:"time_factor"`
+	TimeBonus   float64 `json:"time_bonus"`
+	MemoryBonus int     `json:"memory_bonus"`
+}
+
+func newLanguageJSON(language Language) languageJSON {
+	return languageJSON{
+		Name:        language.Name,
+		VerboseName: language.VerboseName,
+		Extension:   language.Extension,
+		Interpreted: language.Interpreted,
+		Debuggable:  language.Debuggable,
+		TimeFactor:  language.TimeFactor,
+		TimeBonus:   language.TimeBonus.Seconds(),
+		MemoryBonus: language.MemoryBonus,
+	}
+}
+
+func (server *server) languages(w http.ResponseWriter, r *http.Request) error {
+	client, err := server.client(r.URL.Query().Get("directory"))
+	if err != nil {
+		return err
+	}
+	languages := make([]languageJSON, 0)
+	for _, language := range client.Languages() {
+		languages = append(languages, newLanguageJSON(language))
+	}
+	writeJSON(w, http.StatusOK, languages)
+	return nil
+}
+
+type testCaseJSON struct {
+	Name       string `json:"name"`
+	InputPath  string `json:"input_path"`
+	OutputPath string `json:"output_path"`
+}
+
+func (server *server) tests(w http.ResponseWriter, r *http.Request) error {
+	client, err := server.client(r.URL.Query().Get("directory"))
+	if err != nil {
+		return err
+	}
+	testCases := make([]testCaseJSON, 0)
+	for _, testCase := range client.TestCases(r.URL.Query().Get("prefix")) {
+		testCases = append(testCases, testCaseJSON(testCase))
+	}
+	writeJSON(w, http.StatusOK, testCases)
+	return nil
+}
+
+// solutionRequest is the part of compile, run and test requests that selects the solution. Timeout is a duration like "5s", the
+// timeout in the configuration is used when it is empty.
+type solutionRequest struct {
+	Directory string `json:"directory"`
+	Solution  string `json:"solution"`
+	Language  string `json:"language"`
+	Timeout   string `json:"timeout"`
+}
+
+type solutionJSON struct {
+	Name     string `json:"name"`
+	Path     string `json:"path"`
+	Language string `json:"language"`
+}
+
+// prepare finds the solution of the request, and returns the timeout of executing it.
+func (server *server) prepare(request solutionRequest) (*CPTool, Solution, time.Duration, error) {
+	if len(request.Solution) == 0 {
+		return nil, Solution{}, 0, &requestError{message: "Solution should be specified"}
+	}
+	client, err := server.client(request.Directory)
+	if err != nil {
+		return nil, Solution{}, 0, err
+	}
+	timeout := client.Config().Timeout
+	if len(request.Timeout) > 0 {
+		if timeout, err = time.ParseDuration(request.Timeout); err != nil {
+			return nil, Solution{}, 0, &requestError{message: "Invalid timeout: " + request.Timeout}
+		}
+	}
+	solution, err := client.Solution(request.Solution, request.Language)
+	if err != nil {
+		return nil, Solution{}, 0, err
+	}
+	return client, solution, timeout, nil
+}
+
+type compileRequest struct {
+	solutionRequest
+	Debug bool `json:"debug"`
+}
+
+type compileResultJSON struct {
+	Skipped     bool         `json:"skipped"`
+	TargetPath  string       `json:"target_path"`
+	Duration    float64      `json:"duration"`
+	Output      string       `json:"output,omitempty"`
+	Diagnostics []Diagnostic `json:"diagnostics"`
+}
+
+func newCompileResultJSON(result CompileResult) compileResultJSON {
+	diagnostics := result.Diagnostics
+	if diagnostics == nil {
+		diagnostics = make([]Diagnostic, 0)
+	}
+	return compileResultJSON{
+		Skipped:     result.Skipped,
+		TargetPath:  result.TargetPath,
+		Duration:    result.Duration.Seconds(),
+		Output:      result.Output,
+		Diagnostics: diagnostics,
+	}
+}
+
+func (server *server) compile(w http.ResponseWriter, r *http.Request) error {
+	request := compileRequest{}
+	if err := readJSON(r, &request); err != nil {
+		return err
+	}
+	client, solution, timeout, err := server.prepare(request.solutionRequest)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+
+	unlock, err := server.lock(ctx, solution.Path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	result, err := client.Compile(ctx, solution, request.Debug)
+	if err != nil {
+		return err
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"solution":    newSolutionJSON(solution),
+		"compilation": newCompileResultJSON(result),
+	})
+	return nil
+}
+
+func newSolutionJSON(solution Solution) solutionJSON {
+	return solutionJSON{Name: solution.Name, Path: solution.Path, Language: solution.Language.Name}
+}
+
+type runRequest struct {
+	solutionRequest
+	Stdin string `json:"stdin"`
+}
+
+type runResultJSON struct {
+	Compilation         compileResultJSON `json:"compilation"`
+	Duration            float64           `json:"duration"`
+	Memory              uint64            `json:"memory"`
+	TimeLimit           float64           `json:"time_limit"`
+	MemoryLimit         int               `json:"memory_limit"`
+	TimeLimitExceeded   bool              `json:"time_limit_exceeded"`
+	MemoryLimitExceeded bool              `json:"memory_limit_exceeded"`
+}
+
+// limitedBuffer stores at most max bytes, the rest of the written bytes are discarded.
+type limitedBuffer struct {
+	bytes.Buffer
+	max       int
+	truncated bool
+}
+
+func (buffer *limitedBuffer) Write(p []byte) (int, error) {
+	if remaining := buffer.max - buffer.Len(); len(p) > remaining {
+		buffer.truncated = true
+		buffer.Buffer.Write(p[:remaining])
+		return len(p), nil
+	}
+	return buffer.Buffer.Write(p)
+}
+
+// run responds with the solution's stdout and stderr. The response has "error" instead of "result" when the solution exits with
+// error or is killed, the other errors are responded as error response.
+func (server *server) run(w http.ResponseWriter, r *http.Request) error {
+	request := runRequest{}
+	if err := readJSON(r, &request); err != nil {
+		return err
+	}
+	client, solution, timeout, err := server.prepare(request.solutionRequest)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+
+	unlock, err := server.lock(ctx, solution.Path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	stdout := &limitedBuffer{max: maxRunOutputSize}
+	stderr := &limitedBuffer{max: maxRunOutputSize}
+	result, err := client.Run(ctx, solution, strings.NewReader(request.Stdin), stdout, stderr)
+	var runtimeErr *RuntimeError
+	if err != nil && !errors.As(err, &runtimeErr) {
+		return err
+	}
+
+	response := map[string]interface{}{
+		"solution":         newSolutionJSON(solution),
+		"stdout":           stdout.String(),
+		"stderr":           stderr.String(),
+		"output_truncated": stdout.truncated || stderr.truncated,
+	}
+	if runtimeErr != nil {
+		response["error"] = newErrorJSON(runtimeErr)
+	} else {
+		response["result"] = runResultJSON{
+			Compilation:         newCompileResultJSON(result.Compilation),
+			Duration:            result.Duration.Seconds(),
+			Memory:              result.Memory,
+			TimeLimit:           result.TimeLimit.Seconds(),
+			MemoryLimit:         result.MemoryLimit,
+			TimeLimitExceeded:   result.TimeLimitExceeded,
+			MemoryLimitExceeded: result.MemoryLimitExceeded,
+		}
+	}
+	writeJSON(w, http.StatusOK, response)
+	return nil
+}
+
+type testRequest struct {
+	solutionRequest
+	Prefix      string   `json:"prefix"`
+	Names       []string `json:"names"`
+	Recompile   bool     `json:"recompile"`
+	Jobs        int      `json:"jobs"`
+	KeepOutputs bool     `json:"keep_outputs"`
+	TimeLimit   string   `json:"time_limit"`
+	MemoryLimit int      `json:"memory_limit"`
+}
+
+type testCaseResultJSON struct {
+	testCaseJSON
+	Verdict          string  `json:"verdict"`
+	Duration         float64 `json:"duration"`
+	Memory           uint64  `json:"memory"`
+	Error            string  `json:"error,omitempty"`
+	ActualOutputPath string  `json:"actual_output_path,omitempty"`
+}
+
+func newTestCaseResultJSON(result TestCaseResult) testCaseResultJSON {
+	resultJSON := testCaseResultJSON{
+		testCaseJSON:     testCaseJSON(result.TestCase),
+		Verdict:          result.Verdict.String(),
+		Duration:         result.Duration.Seconds(),
+		Memory:           result.Memory,
+		ActualOutputPath: result.ActualOutputPath,
+	}
+	if result.Err != nil {
+		resultJSON.Error = result.Err.Error()
+	}
+	return resultJSON
+}
+
+type eventJSON struct {
+	Type        string              `json:"type"`
+	Compilation *compileResultJSON  `json:"compilation,omitempty"`
+	TestCase    *testCaseJSON       `json:"test_case,omitempty"`
+	Result      *testCaseResultJSON `json:"result,omitempty"`
+	Error       *errorJSON          `json:"error,omitempty"`
+	Report      *testReportJSON     `json:"report,omitempty"`
+}
+
+type testReportJSON struct {
+	Compilation compileResultJSON    `json:"compilation"`
+	Results     []testCaseResultJSON `json:"results"`
+	Failed      int                  `json:"failed"`
+	Duration    float64              `json:"duration"`
+	TimeLimit   float64              `json:"time_limit"`
+	MemoryLimit int                  `json:"memory_limit"`
+	Aborted     bool                 `json:"aborted"`
+}
+
+func newEventJSON(event Event) eventJSON {
+	result := eventJSON{Type: event.Type.String()}
+	switch event.Type {
+	case CompileFinished:
+		compilation := newCompileResultJSON(event.Compilation)
+		result.Compilation = &compilation
+	case TestStarted:
+		testCase := testCaseJSON(event.TestCase)
+		result.TestCase = &testCase
+	case TestFinished:
+		testCaseResult := newTestCaseResultJSON(event.Result)
+		result.Result = &testCaseResult
+	}
+	if event.Err != nil {
+		errJSON := newErrorJSON(event.Err)
+		result.Error = &errJSON
+	}
+	return result
+}
+
+// test streams the events of testing the solution as newline delimited JSON, one event per line. The last line is a "report"
+// event containing the TestReport, or an "error" event when the solution cannot be tested.
+func (server *server) test(w http.ResponseWriter, r *http.Request) error {
+	request := testRequest{}
+	if err := readJSON(r, &request); err != nil {
+		return err
+	}
+	opts := TestOptions{
+		Prefix:      request.Prefix,
+		Names:       request.Names,
+		Recompile:   request.Recompile,
+		Jobs:        request.Jobs,
+		KeepOutputs: request.KeepOutputs,
+		MemoryLimit: request.MemoryLimit,
+	}
+	if len(request.TimeLimit) > 0 {
+		timeLimit, err := time.ParseDuration(request.TimeLimit)
+		if err != nil {
+			return &requestError{message: "Invalid time limit: " + request.TimeLimit}
+		}
+		opts.TimeLimit = timeLimit
+	}
+	client, solution, timeout, err := server.prepare(request.solutionRequest)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+
+	unlock, err := server.lock(ctx, solution.Path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	encoder := json.NewEncoder(w)
+	flusher, _ := w.(http.Flusher)
+	writeEvent := func(event eventJSON) {
+		encoder.Encode(event)
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+	opts.Observer = func(event Event) {
+		writeEvent(newEventJSON(event))
+	}
+
+	report, err := client.Test(ctx, solution, opts)
+	if err != nil {
+		errJSON := newErrorJSON(err)
+		writeEvent(eventJSON{Type: "error", Error: &errJSON})
+		return nil
+	}
+	reportJSON := testReportJSON{
+		Compilation: newCompileResultJSON(report.Compilation),
+		Results:     make([]testCaseResultJSON, 0, len(report.Results)),
+		Failed:      report.Failed,
+		Duration:    report.Duration.Seconds(),
+		TimeLimit:   report.TimeLimit.Seconds(),
+		MemoryLimit: report.MemoryLimit,
+		Aborted:     report.Aborted,
+	}
+	for _, result := range report.Results {
+		reportJSON.Results = append(reportJSON.Results, newTestCaseResultJSON(result))
+	}
+	writeEvent(eventJSON{Type: "report", Report: &reportJSON})
+	return nil
+}
+
+// reload forgets every loaded directory, so the next requests load the configuration files and languages again. The base cptool
+// is replaced as well, it is loaded using the same options.
+func (server *server) reload(w http.ResponseWriter, r *http.Request) error {
+	server.mutex.Lock()
+	opts := server.base.opts
+	server.mutex.Unlock()
+	base, err := New(opts...)
+	if err != nil {
+		return err
+	}
+	server.mutex.Lock()
+	server.base = base
+	server.clients = make(map[string]*CPTool)
+	server.mutex.Unlock()
+	writeJSON(w, http.StatusOK, map[string]interface{}{})
+	return nil
+}
+
+// errorJSON describes an error. Type is "bad_request", "language_not_found", "language_not_debuggable", "solution_not_found",
+// "ambiguous_solution", "compilation_error", "runtime_error", "config_error" or "internal_error". Output and Diagnostics are the
+// compiler's error message of compilation error, and Paths are the solution files of ambiguous solution error.
+type errorJSON struct {
+	Type        string       `json:"type"`
+	Message     string       `json:"message"`
+	Output      string       `json:"output,omitempty"`
+	Diagnostics []Diagnostic `json:"diagnostics,omitempty"`
+	Paths       []string     `json:"paths,omitempty"`
+}
+
+func newErrorJSON(err error) errorJSON {
+	var requestErr *requestError
+	var languageNotFound *LanguageNotFoundError
+	var notDebuggable *LanguageNotDebuggableError
+	var solutionNotFound *SolutionNotFoundError
+	var ambiguous *AmbiguousSolutionError
+	var compilationErr *CompilationError
+	var runtimeErr *RuntimeError
+	var configErr *ConfigError
+
+	result := errorJSON{Type: "internal_error", Message: err.Error()}
+	switch {
+	case errors.As(err, &requestErr):
+		result.Type = "bad_request"
+	case errors.As(err, &languageNotFound):
+		result.Type = "language_not_found"
+	case errors.As(err, &notDebuggable):
+		result.Type = "language_not_debuggable"
+	case errors.As(err, &solutionNotFound):
+		result.Type = "solution_not_found"
+	case errors.As(err, &ambiguous):
+		result.Type = "ambiguous_solution"
+		result.Paths = ambiguous.Paths
+	case errors.As(err, &compilationErr):
+		result.Type = "compilation_error"
+		result.Output = compilationErr.Output
+		result.Diagnostics = compilationErr.Diagnostics
+	case errors.As(err, &runtimeErr):
+		result.Type = "runtime_error"
+	case errors.As(err, &configErr):
+		result.Type = "config_error"
+	}
+	return result
+}
+
+var errorStatuses = map[string]int{
+	"bad_request":             http.StatusBadRequest,
+	"language_not_found":      http.StatusNotFound,
+	"solution_not_found":      http.StatusNotFound,
+	"language_not_debuggable": http.StatusUnprocessableEntity,
+	"ambiguous_solution":      http.StatusUnprocessableEntity,
+	"compilation_error":       http.StatusUnprocessableEntity,
+	"runtime_error":           http.StatusUnprocessableEntity,
+	"config_error":            http.StatusUnprocessableEntity,
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	errJSON := newErrorJSON(err)
+	status, ok := errorStatuses[errJSON.Type]
+	if !ok {
+		status = http.StatusInternalServerError
+	}
+	writeJSON(w, status, map[string]interface{}{"error": errJSON})
+}
+
+func readJSON(r *http.Request, value interface{}) error {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(value); err != nil {
+		return &requestError{message: "Invalid request: " + err.Error()}
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
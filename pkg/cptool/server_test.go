@@ -0,0 +1,244 @@
+package cptool
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/jauhararifin/cptool/internal/executioner"
+)
+
+func serveTest(handler http.Handler, method string, target string, body string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(method, target, strings.NewReader(body))
+	request.Host = "127.0.0.1:10044"
+	if method == http.MethodPost {
+		request.Header.Set("Content-Type", "application/json")
+	}
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestHandlerLanguagesAndTests(t *testing.T) {
+	cptool := newTest(t, executioner.NewMemExec(), map[string]string{
+		"/home/test/.cptool/langs/fake/lang.conf": fakeLanguageConf,
+		"/home/test/problem/tc1.in":               "1",
+		"/home/test/problem/tc1.out":              "1",
+		"/home/test/other/other1.in":              "1",
+		"/home/test/other/other1.out":             "1",
+	})
+	handler := NewHandler(cptool)
+
+	response := serveTest(handler, http.MethodGet, "/languages", "")
+	languages := make([]map[string]interface{}, 0)
+	json.NewDecoder(response.Body).Decode(&languages)
+	if response.Code != http.StatusOK || len(languages) == 0 {
+		t.Fatal("languages should respond the loaded languages, found:", response.Code, languages)
+	}
+	found := false
+	for _, language := range languages {
+		found = found || (language["name"] == "fake" && language["extension"] == "fk")
+	}
+	if !found {
+		t.Error("languages should contain fake language, found:", languages)
+	}
+
+	response = serveTest(handler, http.MethodGet, "/tests?prefix=tc", "")
+	testCases := make([]map[string]interface{}, 0)
+	json.NewDecoder(response.Body).Decode(&testCases)
+	if len(testCases) != 1 || testCases[0]["name"] != "tc1" || testCases[0]["input_path"] != "/home/test/problem/tc1.in" {
+		t.Error("tests should respond the test cases with the prefix, found:", testCases)
+	}
+
+	response = serveTest(handler, http.MethodGet, "/tests?prefix=other&directory=/home/test/other", "")
+	testCases = testCases[:0]
+	json.NewDecoder(response.Body).Decode(&testCases)
+	if len(testCases) != 1 || testCases[0]["name"] != "other1" {
+		t.Error("tests should search the test cases in the directory, found:", testCases)
+	}
+}
+
+func TestHandlerRejectsRequests(t *testing.T) {
+	handler := NewHandler(newTest(t, executioner.NewMemExec(), map[string]string{}))
+
+	if response := serveTest(handler, http.MethodPost, "/languages", ""); response.Code != http.StatusMethodNotAllowed {
+		t.Error("handler should reject wrong method, found:", response.Code)
+	}
+	if response := serveTest(handler, http.MethodGet, "/tests?directory=relative", ""); response.Code != http.StatusBadRequest {
+		t.Error("handler should reject relative directory, found:", response.Code)
+	}
+	if response := serveTest(handler, http.MethodPost, "/compile", "{\"unknown\": 1}"); response.Code != http.StatusBadRequest {
+		t.Error("handler should reject unknown fields, found:", response.Code)
+	}
+
+	request := httptest.NewRequest(http.MethodGet, "/languages", nil)
+	request.Host = "example.com"
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusBadRequest {
+		t.Error("handler should reject non loopback host, found:", recorder.Code)
+	}
+
+	request = httptest.NewRequest(http.MethodPost, "/compile", strings.NewReader("{\"solution\": \"a\"}"))
+	request.Host = "localhost"
+	request.Header.Set("Content-Type", "text/plain")
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusBadRequest {
+		t.Error("handler should reject non json content type, found:", recorder.Code)
+	}
+}
+
+func TestHandlerCompileWithError(t *testing.T) {
+	memexec := executioner.NewMemExec()
+	memexec.StderrPipeCallback = func(m *executioner.MemCmd) (io.ReadCloser, error) {
+		return ioutil.NopCloser(strings.NewReader("a.fk:1:1: error: syntax error")), nil
+	}
+	memexec.WaitCallback = func(m *executioner.MemCmd) error {
+		return &exec.ExitError{}
+	}
+	handler := NewHandler(newTest(t, memexec, map[string]string{
+		"/home/test/.cptool/langs/fake/lang.conf": fakeLanguageConf,
+		"/home/test/problem/a.fk":                 "",
+	}))
+
+	response := serveTest(handler, http.MethodPost, "/compile", "{\"solution\": \"a\"}")
+	body := struct {
+		Error errorJSON `json:"error"`
+	}{}
+	if err := json.NewDecoder(response.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if response.Code != http.StatusUnprocessableEntity || body.Error.Type != "compilation_error" ||
+		len(body.Error.Diagnostics) != 1 || body.Error.Diagnostics[0].Severity != SeverityError {
+		t.Error("compile should respond compilation error with the diagnostics, found:", response.Code, body)
+	}
+
+	response = serveTest(handler, http.MethodPost, "/compile", "{\"solution\": \"b\"}")
+	if response.Code != http.StatusNotFound || !strings.Contains(response.Body.String(), "solution_not_found") {
+		t.Error("compile should respond not found when the solution doesn't exist, found:", response.Code, response.Body)
+	}
+}
+
+func TestHandlerRun(t *testing.T) {
+	memexec := executioner.NewMemExec()
+	memexec.RunCallback = func(m *executioner.MemCmd) error {
+		input, _ := ioutil.ReadAll(m.Stdin)
+		_, err := m.Stdout.Write(append(input, '!'))
+		return err
+	}
+	handler := NewHandler(newTest(t, memexec, map[string]string{
+		"/home/test/.cptool/langs/fake/lang.conf": fakeLanguageConf,
+		"/home/test/problem/a.fk":                 "",
+	}))
+
+	response := serveTest(handler, http.MethodPost, "/run", "{\"solution\": \"a\", \"stdin\": \"hello\", \"timeout\": \"5s\"}")
+	body := struct {
+		Stdout string         `json:"stdout"`
+		Result *runResultJSON `json:"result"`
+		Error  *errorJSON     `json:"error"`
+	}{}
+	json.NewDecoder(response.Body).Decode(&body)
+	if response.Code != http.StatusOK || body.Stdout != "hello!" || body.Result == nil || body.Error != nil {
+		t.Error("run should respond the solution's output, found:", response.Code, response.Body)
+	}
+
+	memexec.RunCallback = func(m *executioner.MemCmd) error {
+		m.Stdout.Write([]byte("partial"))
+		return &exec.ExitError{}
+	}
+	response = serveTest(handler, http.MethodPost, "/run", "{\"solution\": \"a\"}")
+	body.Result = nil
+	json.NewDecoder(response.Body).Decode(&body)
+	if response.Code != http.StatusOK || body.Stdout != "partial" || body.Error == nil || body.Error.Type != "runtime_error" {
+		t.Error("run should respond the output and the runtime error, found:", response.Code, response.Body)
+	}
+
+	response = serveTest(handler, http.MethodPost, "/run", "{\"solution\": \"a\", \"timeout\": \"soon\"}")
+	if response.Code != http.StatusBadRequest {
+		t.Error("run should reject invalid timeout, found:", response.Code)
+	}
+}
+
+func TestHandlerTest(t *testing.T) {
+	memexec := executioner.NewMemExec()
+	memexec.RunCallback = func(m *executioner.MemCmd) error {
+		input, _ := ioutil.ReadAll(m.Stdin)
+		_, err := m.Stdout.Write(input)
+		return err
+	}
+	handler := NewHandler(newTest(t, memexec, map[string]string{
+		"/home/test/.cptool/langs/fake/lang.conf": fakeLanguageConf,
+		"/home/test/problem/a.fk":                 "",
+		"/home/test/problem/tc1.in":               "1",
+		"/home/test/problem/tc1.out":              "1",
+		"/home/test/problem/tc2.in":               "2",
+		"/home/test/problem/tc2.out":              "3",
+	}))
+
+	response := serveTest(handler, http.MethodPost, "/test", "{\"solution\": \"a\", \"prefix\": \"tc\", \"names\": [\"tc2\"]}")
+	if response.Header().Get("Content-Type") != "application/x-ndjson" {
+		t.Error("test should stream newline delimited json, found:", response.Header().Get("Content-Type"))
+	}
+	events := make([]eventJSON, 0)
+	scanner := bufio.NewScanner(response.Body)
+	for scanner.Scan() {
+		event := eventJSON{}
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatal(err)
+		}
+		events = append(events, event)
+	}
+	types := make([]string, 0, len(events))
+	for _, event := range events {
+		types = append(types, event.Type)
+	}
+	if strings.Join(types, ",") != "compile_started,compile_finished,test_started,test_finished,report" {
+		t.Fatal("test should stream the events and the report, found:", types)
+	}
+	if events[3].Result == nil || events[3].Result.Name != "tc2" || events[3].Result.Verdict != "wrong answer" {
+		t.Error("test finished event should contain the result, found:", events[3].Result)
+	}
+	if report := events[4].Report; report == nil || report.Failed != 1 || len(report.Results) != 1 {
+		t.Error("report should contain every result, found:", report)
+	}
+
+	response = serveTest(handler, http.MethodPost, "/test", "{\"solution\": \"a\", \"time_limit\": \"long\"}")
+	if response.Code != http.StatusBadRequest {
+		t.Error("test should reject invalid time limit, found:", response.Code)
+	}
+}
+
+func TestServerLock(t *testing.T) {
+	server := &server{running: make(map[string]chan struct{})}
+
+	unlock, err := server.lock(context.Background(), "/home/test/problem/a.fk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	unlockOther, err := server.lock(context.Background(), "/home/test/problem/b.fk")
+	if err != nil {
+		t.Error("lock of other solution should not wait, found:", err)
+	}
+	unlockOther()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := server.lock(ctx, "/home/test/problem/a.fk"); err != context.Canceled {
+		t.Error("lock should return the context's error when the solution is locked, found:", err)
+	}
+
+	unlock()
+	unlock, err = server.lock(context.Background(), "/home/test/problem/a.fk")
+	if err != nil {
+		t.Error("lock should succeed after the solution is unlocked, found:", err)
+	}
+	unlock()
+}